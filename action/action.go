@@ -1,12 +1,14 @@
 package action
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -29,6 +31,14 @@ const (
 	ActionStatus = "io.cnab.status"
 )
 
+// DefaultLockTTL is the default duration of the lease on an installation that
+// is locked by an Action.
+const DefaultLockTTL = time.Minute
+
+// lockRetryInterval is how often a locked installation is checked when an
+// Action is waiting for the lock.
+var lockRetryInterval = 250 * time.Millisecond
+
 // Action executes a bundle operation and helps save the results.
 type Action struct {
 	Claims         claim.Provider
@@ -36,6 +46,23 @@ type Action struct {
 	SaveAllOutputs bool
 	SaveOutputs    []string
 	SaveLogs       bool
+
+	// LockInstallation locks the installation during modifying actions, so
+	// that only one modifying action runs against an installation at a time.
+	// The lock is acquired by SaveInitialClaim, or Run when the initial claim
+	// is not saved, and released by SaveOperationResult. The Claims provider
+	// must implement claim.InstallationLocker.
+	LockInstallation bool
+
+	// LockTTL is the duration of the lease on the installation, defaulting to
+	// DefaultLockTTL. The lease is renewed while the bundle is running, so it
+	// only expires when the process stops without releasing the lock.
+	LockTTL time.Duration
+
+	// LockWait is how long to wait for the lock when the installation is
+	// locked by another action. When zero, the action is refused immediately
+	// with claim.ErrInstallationLocked.
+	LockWait time.Duration
 }
 
 // New creates an Action.
@@ -53,15 +80,36 @@ func New(d driver.Driver, cp claim.Provider) Action {
 // SaveOperationResult function. An error is only returned when the operation could not
 // be executed, otherwise any error is returned in the OperationResult.
 func (a Action) Run(c claim.Claim, creds valuesource.Set, opCfgs ...OperationConfigFunc) (driver.OperationResult, claim.Result, error) {
+	return a.RunWithContext(context.Background(), c, creds, opCfgs...)
+}
+
+// RunWithContext executes the action, like Run, stopping the operation when the
+// context is done. When the operation is stopped, the claim result has a status
+// of canceled.
+func (a Action) RunWithContext(ctx context.Context, c claim.Claim, creds valuesource.Set, opCfgs ...OperationConfigFunc) (opResult driver.OperationResult, cr claim.Result, err error) {
 	if a.Driver == nil {
 		return driver.OperationResult{}, claim.Result{}, errors.New("the action driver is not set")
 	}
 
-	err := c.Validate()
+	err = c.Validate()
 	if err != nil {
 		return driver.OperationResult{}, claim.Result{}, err
 	}
 
+	// Renew the lock acquired by SaveInitialClaim, or acquire it now
+	locked, err := a.lockInstallation(ctx, c)
+	if err != nil {
+		return driver.OperationResult{}, claim.Result{}, errors.Wrap(err, "the bundle was not executed")
+	}
+	if locked {
+		defer func() {
+			// The bundle was not executed, so the caller will not save the result
+			if err != nil {
+				a.UnlockInstallation(c)
+			}
+		}()
+	}
+
 	invocImage, err := a.selectInvocationImage(c)
 	if err != nil {
 		return driver.OperationResult{}, claim.Result{}, err
@@ -78,7 +126,9 @@ func (a Action) Run(c claim.Claim, creds valuesource.Set, opCfgs ...OperationCon
 	}
 
 	var opErr *multierror.Error
-	opResult, err := a.Driver.Run(op)
+	stopRenewing := a.renewLock(c, locked)
+	opResult, err = driver.RunWithContext(ctx, a.Driver, op)
+	stopRenewing()
 	if err != nil {
 		opErr = multierror.Append(opErr, err)
 	}
@@ -88,9 +138,11 @@ func (a Action) Run(c claim.Claim, creds valuesource.Set, opCfgs ...OperationCon
 		opErr = multierror.Append(opErr, err)
 	}
 
-	cr, err := buildClaimResult(c, opResult, opErr)
+	cr, err = buildClaimResult(c, opResult, opErr)
 	if err != nil {
 		opErr = multierror.Append(opErr, err)
+	} else if ctx.Err() != nil {
+		cr.Status = claim.StatusCanceled
 	}
 
 	// These are any errors from running the operation or processing the result,
@@ -105,22 +157,37 @@ func (a Action) Run(c claim.Claim, creds valuesource.Set, opCfgs ...OperationCon
 
 // SaveInitialClaim with the specified status. If not used, the caller is
 // responsible for persisting the claim.
+//
+// When LockInstallation is set, the installation is locked before the claim is
+// saved for modifying actions.
 func (a Action) SaveInitialClaim(c claim.Claim, status string) error {
 	if a.Claims == nil {
 		return errors.New("the action claims provider is not set")
 	}
 
-	err := a.saveClaimWithStatus(c, status)
+	locked, err := a.lockInstallation(context.Background(), c)
+	if err != nil {
+		return errors.Wrap(err, "could not save the pending action's status, the bundle was not executed")
+	}
+
+	err = a.saveClaimWithStatus(c, status)
+	if err != nil && locked {
+		a.UnlockInstallation(c)
+	}
 	return errors.Wrap(err, "could not save the pending action's status, the bundle was not executed")
 }
 
 // SaveOperationResult saves the ClaimResult and Outputs. The caller is
 // responsible for having already persisted the claim itself, for example using
 // SaveInitialClaim.
+//
+// When LockInstallation is set, the lock on the installation is released after
+// the result is saved.
 func (a Action) SaveOperationResult(opResult driver.OperationResult, c claim.Claim, r claim.Result) error {
 	if a.Claims == nil {
 		return errors.New("the action claims provider is not set")
 	}
+	defer a.UnlockInstallation(c)
 
 	// Keep accumulating errors from any error returned from the operation
 	// We must save the claim even when the op failed, but we want to report
@@ -148,6 +215,109 @@ func (a Action) SaveOperationResult(opResult driver.OperationResult, c claim.Cla
 	return bigerr.ErrorOrNil()
 }
 
+// UnlockInstallation releases the lock on the installation acquired for the
+// claim, when LockInstallation is set. Use it to release the lock when the
+// operation is abandoned without calling SaveOperationResult.
+func (a Action) UnlockInstallation(c claim.Claim) error {
+	locker, required, err := a.getInstallationLocker(c)
+	if err != nil || !required {
+		return err
+	}
+	return locker.UnlockInstallation(c.Installation, c.ID)
+}
+
+// lockInstallation acquires, or renews, the lock on the installation for the
+// claim when the action modifies the installation, waiting up to LockWait
+// for another action to release it. The claim ID is used as the lease owner.
+// Returns true when the installation was locked.
+func (a Action) lockInstallation(ctx context.Context, c claim.Claim) (bool, error) {
+	locker, required, err := a.getInstallationLocker(c)
+	if err != nil || !required {
+		return false, err
+	}
+
+	deadline := time.Now().Add(a.LockWait)
+	for {
+		err := locker.LockInstallation(c.Installation, c.ID, a.lockTTL())
+		if err == nil {
+			return true, nil
+		}
+		if errors.Cause(err) != claim.ErrInstallationLocked || !time.Now().Before(deadline) {
+			return false, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, errors.Wrapf(ctx.Err(), "stopped waiting for the lock on installation %s", c.Installation)
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// renewLock renews the lease on the installation in the background until the
+// returned function is called, so that the lease does not expire while the
+// bundle is running. Failures are retried on the next renewal.
+func (a Action) renewLock(c claim.Claim, locked bool) func() {
+	if !locked {
+		return func() {}
+	}
+
+	locker := a.Claims.(claim.InstallationLocker)
+	ttl := a.lockTTL()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				locker.LockInstallation(c.Installation, c.ID, ttl)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// getInstallationLocker returns the Claims provider as a locker, and if the
+// claim requires the installation to be locked.
+func (a Action) getInstallationLocker(c claim.Claim) (claim.InstallationLocker, bool, error) {
+	if !a.LockInstallation {
+		return nil, false, nil
+	}
+
+	modifies, err := c.IsModifyingAction()
+	if err != nil {
+		return nil, false, err
+	}
+	if !modifies {
+		return nil, false, nil
+	}
+
+	if a.Claims == nil {
+		return nil, false, errors.New("the action claims provider is not set")
+	}
+	locker, ok := a.Claims.(claim.InstallationLocker)
+	if !ok {
+		return nil, false, fmt.Errorf("the %T claims provider does not support locking installations", a.Claims)
+	}
+	return locker, true, nil
+}
+
+func (a Action) lockTTL() time.Duration {
+	if a.LockTTL > 0 {
+		return a.LockTTL
+	}
+	return DefaultLockTTL
+}
+
 func (a Action) shouldSaveOutput(name string) bool {
 	if a.SaveAllOutputs {
 		return true
@@ -321,6 +491,10 @@ func validateOutputType(bundle bundle.Bundle, outputName string, outputDef bundl
 	if outputSchema == nil {
 		return fmt.Errorf("invalid bundle: output %q references definition %q, which was not found", outputName, name)
 	}
+	outputSchema, err := bundle.Definitions.Dereference(outputSchema)
+	if err != nil {
+		return errors.Wrapf(err, "invalid bundle: unable to resolve definition %q for output %q", name, outputName)
+	}
 	outputTypes, err := allowedTypes(*outputSchema)
 	if err != nil {
 		return err
@@ -1,9 +1,11 @@
 package action
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/cnabio/cnab-go/claim"
 	"github.com/cnabio/cnab-go/driver"
 	"github.com/cnabio/cnab-go/driver/debug"
+	"github.com/cnabio/cnab-go/utils/crud"
 	"github.com/cnabio/cnab-go/valuesource"
 
 	"github.com/hashicorp/go-multierror"
@@ -851,6 +854,27 @@ func TestAction_RunAction(t *testing.T) {
 		assert.Equal(t, someContentDigest, contentDigest, "invalid output content digest")
 	})
 
+	t.Run("canceled operation", func(t *testing.T) {
+		c := newClaim(claim.ActionInstall)
+		d := &mockDriver{
+			shouldHandle: true,
+			Result: driver.OperationResult{
+				Outputs: map[string]string{
+					"some-output": someContent,
+				},
+			},
+		}
+		inst := New(d, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		opResult, claimResult, err := inst.RunWithContext(ctx, c, mockSet, out)
+		require.NoError(t, err)
+		require.Contains(t, opResult.Error.Error(), context.Canceled.Error())
+		assert.Nil(t, d.Operation, "the driver should not have been called")
+		assert.Equal(t, claim.StatusCanceled, claimResult.Status)
+	})
+
 	t.Run("error case: unknown actions should fail", func(t *testing.T) {
 		c := newClaim("missing")
 		d := &mockDriver{
@@ -1050,6 +1074,177 @@ func TestSaveAction(t *testing.T) {
 	})
 }
 
+// runFuncDriver is a driver that calls a function to run the operation.
+type runFuncDriver func(op *driver.Operation) (driver.OperationResult, error)
+
+func (d runFuncDriver) Handles(imageType string) bool {
+	return true
+}
+
+func (d runFuncDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	return d(op)
+}
+
+func TestAction_LockInstallation(t *testing.T) {
+	defer func(interval time.Duration) { lockRetryInterval = interval }(lockRetryInterval)
+	lockRetryInterval = 10 * time.Millisecond
+
+	out := func(op *driver.Operation) error {
+		op.Out = ioutil.Discard
+		return nil
+	}
+
+	newLockingClaim := func(id string, action string) claim.Claim {
+		c := newClaim(action)
+		c.ID = id
+		return c
+	}
+
+	// newSharedClaimStores creates two claim stores that share storage, like
+	// separate processes would.
+	newSharedClaimStores := func(t *testing.T) (claim.Store, claim.Store, func()) {
+		tempDir, err := ioutil.TempDir("", "cnabtest")
+		require.NoError(t, err, "Failed to create temp dir")
+		newStore := func() claim.Store {
+			datastore := crud.NewFileSystemStore(tempDir, claim.NewClaimStoreFileExtensions())
+			return claim.NewClaimStore(crud.NewBackingStore(datastore), nil, nil)
+		}
+		return newStore(), newStore(), func() { os.RemoveAll(tempDir) }
+	}
+
+	newLockingAction := func(d driver.Driver, cp claim.Provider) Action {
+		a := New(d, cp)
+		a.LockInstallation = true
+		return a
+	}
+
+	t.Run("refuse when locked", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		a := newLockingAction(nil, cp)
+		c1 := newLockingClaim("claim1", claim.ActionUpgrade)
+		c2 := newLockingClaim("claim2", claim.ActionUpgrade)
+
+		require.NoError(t, a.SaveInitialClaim(c1, claim.StatusRunning), "SaveInitialClaim failed")
+
+		err := a.SaveInitialClaim(c2, claim.StatusRunning)
+		require.Error(t, err, "a second modifying action should be refused")
+		assert.Contains(t, err.Error(), claim.ErrInstallationLocked.Error())
+		assert.Contains(t, err.Error(), "locked by claim1")
+		_, err = cp.ReadClaim(c2.ID)
+		assert.Error(t, err, "the refused claim should not be saved")
+
+		r, err := c1.NewResult(claim.StatusSucceeded)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, a.SaveOperationResult(driver.OperationResult{}, c1, r), "SaveOperationResult failed")
+
+		require.NoError(t, a.SaveInitialClaim(c2, claim.StatusRunning), "the lock should be released after the result is saved")
+	})
+
+	t.Run("wait for the lock", func(t *testing.T) {
+		cp, other, cleanup := newSharedClaimStores(t)
+		defer cleanup()
+		require.NoError(t, other.LockInstallation("name", "other", time.Minute))
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			other.UnlockInstallation("name", "other")
+		}()
+
+		a := newLockingAction(nil, cp)
+		a.LockWait = 5 * time.Second
+		require.NoError(t, a.SaveInitialClaim(newLockingClaim("claim1", claim.ActionUpgrade), claim.StatusRunning), "the action should wait for the lock")
+	})
+
+	t.Run("wait times out", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		require.NoError(t, cp.LockInstallation("name", "other", time.Minute))
+
+		a := newLockingAction(nil, cp)
+		a.LockWait = 50 * time.Millisecond
+		err := a.SaveInitialClaim(newLockingClaim("claim1", claim.ActionUpgrade), claim.StatusRunning)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), claim.ErrInstallationLocked.Error())
+	})
+
+	t.Run("stale lock expires", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		require.NoError(t, cp.LockInstallation("name", "crashed", 10*time.Millisecond))
+		time.Sleep(20 * time.Millisecond)
+
+		a := newLockingAction(nil, cp)
+		require.NoError(t, a.SaveInitialClaim(newLockingClaim("claim1", claim.ActionUpgrade), claim.StatusRunning))
+	})
+
+	t.Run("lock is renewed while running", func(t *testing.T) {
+		cp, other, cleanup := newSharedClaimStores(t)
+		defer cleanup()
+		c := newLockingClaim("claim1", claim.ActionUpgrade)
+		var lockErr error
+		d := runFuncDriver(func(op *driver.Operation) (driver.OperationResult, error) {
+			// Run for longer than the lease, and then check that it is still held
+			time.Sleep(100 * time.Millisecond)
+			lockErr = other.LockInstallation("name", "other", time.Minute)
+			return driver.OperationResult{}, nil
+		})
+
+		a := newLockingAction(d, cp)
+		a.LockTTL = 30 * time.Millisecond
+		opResult, r, err := a.Run(c, mockSet, out)
+		require.NoError(t, err, "Run failed")
+		require.Error(t, lockErr, "the installation should be locked while the bundle is running")
+		assert.Contains(t, lockErr.Error(), claim.ErrInstallationLocked.Error())
+
+		require.NoError(t, a.SaveOperationResult(opResult, c, r), "SaveOperationResult failed")
+		require.NoError(t, other.LockInstallation("name", "other", time.Minute), "the lock should be released after the result is saved")
+	})
+
+	t.Run("run refused when locked", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		require.NoError(t, cp.LockInstallation("name", "other", time.Minute))
+		d := &mockDriver{shouldHandle: true}
+
+		a := newLockingAction(d, cp)
+		_, _, err := a.Run(newLockingClaim("claim1", claim.ActionUpgrade), mockSet, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), claim.ErrInstallationLocked.Error())
+		assert.Nil(t, d.Operation, "the bundle should not be executed")
+	})
+
+	t.Run("lock released when the bundle is not run", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		d := &mockDriver{shouldHandle: false}
+
+		a := newLockingAction(d, cp)
+		_, _, err := a.Run(newLockingClaim("claim1", claim.ActionUpgrade), mockSet, out)
+		require.Error(t, err, "the driver should not handle the invocation image")
+		require.NoError(t, cp.LockInstallation("name", "other", time.Minute), "the lock should be released")
+	})
+
+	t.Run("non-modifying action", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		require.NoError(t, cp.LockInstallation("name", "other", time.Minute))
+
+		a := newLockingAction(nil, cp)
+		require.NoError(t, a.SaveInitialClaim(newLockingClaim("claim1", "logs"), claim.StatusRunning), "non-modifying actions should not be locked")
+	})
+
+	t.Run("locking disabled", func(t *testing.T) {
+		cp := claim.NewMockStore(nil, nil)
+		require.NoError(t, cp.LockInstallation("name", "other", time.Minute))
+
+		a := New(nil, cp)
+		require.NoError(t, a.SaveInitialClaim(newLockingClaim("claim1", claim.ActionUpgrade), claim.StatusRunning))
+	})
+
+	t.Run("provider does not support locking", func(t *testing.T) {
+		cp := struct{ claim.Provider }{claim.NewMockStore(nil, nil)}
+
+		a := newLockingAction(nil, cp)
+		err := a.SaveInitialClaim(newLockingClaim("claim1", claim.ActionUpgrade), claim.StatusRunning)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not support locking installations")
+	})
+}
+
 func TestExpandCredentials(t *testing.T) {
 	t.Run("all creds expanded", func(t *testing.T) {
 		b := bundle.Bundle{
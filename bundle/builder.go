@@ -0,0 +1,269 @@
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	pkgErrors "github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/schema"
+)
+
+// Builder constructs a bundle, adding the definitions used by parameters and
+// outputs as they are added. Mistakes are reported by Build, which also
+// validates the bundle, so that invalid bundles are never generated.
+//
+//	b, err := bundle.NewBuilder("wordpress", "0.1.0").
+//	    InvocationImage(bundle.InvocationImage{BaseImage: bundle.BaseImage{ImageType: "docker", Image: "example.com/wordpress:0.1.0"}}).
+//	    Parameter("port", &definition.Schema{Type: "integer", Default: 8080}, bundle.Parameter{Destination: &bundle.Location{EnvironmentVariable: "PORT"}}).
+//	    Credential("kubeconfig", bundle.Credential{Location: bundle.Location{Path: "/root/.kube/config"}}).
+//	    Build()
+//
+// The builder should not be used after Build is called.
+type Builder struct {
+	bun  Bundle
+	errs []error
+}
+
+// NewBuilder starts a bundle with the name and version.
+func NewBuilder(name string, version string) *Builder {
+	return &Builder{
+		bun: Bundle{
+			Name:    name,
+			Version: version,
+		},
+	}
+}
+
+func (b *Builder) addError(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+// SchemaVersion sets the schema version of the bundle. When it is not set,
+// Build uses GetDefaultSchemaVersion.
+func (b *Builder) SchemaVersion(version schema.Version) *Builder {
+	b.bun.SchemaVersion = version
+	return b
+}
+
+// Description sets the description of the bundle.
+func (b *Builder) Description(description string) *Builder {
+	b.bun.Description = description
+	return b
+}
+
+// Keywords adds keywords to the bundle.
+func (b *Builder) Keywords(keywords ...string) *Builder {
+	b.bun.Keywords = append(b.bun.Keywords, keywords...)
+	return b
+}
+
+// Maintainer adds a maintainer to the bundle.
+func (b *Builder) Maintainer(m Maintainer) *Builder {
+	b.bun.Maintainers = append(b.bun.Maintainers, m)
+	return b
+}
+
+// License sets the license of the bundle.
+func (b *Builder) License(license string) *Builder {
+	b.bun.License = license
+	return b
+}
+
+// InvocationImage adds an invocation image to the bundle.
+func (b *Builder) InvocationImage(img InvocationImage) *Builder {
+	b.bun.InvocationImages = append(b.bun.InvocationImages, img)
+	return b
+}
+
+// Image adds an image that is used by the bundle.
+func (b *Builder) Image(name string, img Image) *Builder {
+	if _, exists := b.bun.Images[name]; exists {
+		b.addError("image %q is already defined", name)
+		return b
+	}
+	if b.bun.Images == nil {
+		b.bun.Images = make(map[string]Image)
+	}
+	b.bun.Images[name] = img
+	return b
+}
+
+// Definition adds a definition, which can be used by parameters and outputs
+// that are added without a schema.
+func (b *Builder) Definition(name string, s *definition.Schema) *Builder {
+	b.addDefinition(name, s)
+	return b
+}
+
+// addDefinition adds the definition, unless a different definition already
+// has the name.
+func (b *Builder) addDefinition(name string, s *definition.Schema) bool {
+	if s == nil {
+		b.addError("definition %q must have a schema", name)
+		return false
+	}
+	if existing, exists := b.bun.Definitions[name]; exists {
+		if !reflect.DeepEqual(existing, s) {
+			b.addError("definition %q is already defined with a different schema", name)
+			return false
+		}
+		return true
+	}
+	if b.bun.Definitions == nil {
+		b.bun.Definitions = make(definition.Definitions)
+	}
+	b.bun.Definitions[name] = s
+	return true
+}
+
+// Parameter adds a parameter to the bundle. When the schema is set, it is
+// added as the definition of the parameter, named after the parameter unless
+// param.Definition is set. When the schema is nil, param.Definition must name
+// a definition that is added to the builder.
+func (b *Builder) Parameter(name string, s *definition.Schema, param Parameter) *Builder {
+	if _, exists := b.bun.Parameters[name]; exists {
+		b.addError("parameter %q is already defined", name)
+		return b
+	}
+	if param.Definition == "" {
+		param.Definition = name
+	}
+	if s != nil && !b.addDefinition(param.Definition, s) {
+		return b
+	}
+	if b.bun.Parameters == nil {
+		b.bun.Parameters = make(map[string]Parameter)
+	}
+	b.bun.Parameters[name] = param
+	return b
+}
+
+// Credential adds a credential to the bundle.
+func (b *Builder) Credential(name string, cred Credential) *Builder {
+	if _, exists := b.bun.Credentials[name]; exists {
+		b.addError("credential %q is already defined", name)
+		return b
+	}
+	if b.bun.Credentials == nil {
+		b.bun.Credentials = make(map[string]Credential)
+	}
+	b.bun.Credentials[name] = cred
+	return b
+}
+
+// Output adds an output to the bundle. The schema is handled the same way as
+// for Parameter.
+func (b *Builder) Output(name string, s *definition.Schema, output Output) *Builder {
+	if _, exists := b.bun.Outputs[name]; exists {
+		b.addError("output %q is already defined", name)
+		return b
+	}
+	if output.Definition == "" {
+		output.Definition = name
+	}
+	if s != nil && !b.addDefinition(output.Definition, s) {
+		return b
+	}
+	if b.bun.Outputs == nil {
+		b.bun.Outputs = make(map[string]Output)
+	}
+	b.bun.Outputs[name] = output
+	return b
+}
+
+// Action adds a custom action to the bundle. The core actions, install,
+// upgrade and uninstall, can not be added.
+func (b *Builder) Action(name string, action Action) *Builder {
+	if IsCoreAction(name) {
+		b.addError("action %q is a core action and can not be defined by the bundle", name)
+		return b
+	}
+	if _, exists := b.bun.Actions[name]; exists {
+		b.addError("action %q is already defined", name)
+		return b
+	}
+	if b.bun.Actions == nil {
+		b.bun.Actions = make(map[string]Action)
+	}
+	b.bun.Actions[name] = action
+	return b
+}
+
+// Custom adds custom extension metadata to the bundle. When required is true,
+// the extension is also added to RequiredExtensions.
+func (b *Builder) Custom(key string, value interface{}, required bool) *Builder {
+	if b.bun.Custom == nil {
+		b.bun.Custom = make(map[string]interface{})
+	}
+	b.bun.Custom[key] = value
+	if required {
+		b.bun.RequiredExtensions = append(b.bun.RequiredExtensions, key)
+	}
+	return b
+}
+
+// Build returns the bundle, after checking that the parameters and outputs
+// have definitions, and validating it with Validate and the CNAB JSON schema.
+// The mistakes that were made while building the bundle, and any missing
+// definitions, are returned together as a *multierror.Error.
+func (b *Builder) Build() (Bundle, error) {
+	bun := b.bun
+	if bun.SchemaVersion == "" {
+		version, err := GetDefaultSchemaVersion()
+		if err != nil {
+			return Bundle{}, err
+		}
+		bun.SchemaVersion = version
+	}
+
+	var missing []string
+	for name, param := range bun.Parameters {
+		if _, ok := bun.Definitions[param.Definition]; !ok {
+			missing = append(missing, fmt.Sprintf("parameter %q uses definition %q, which is not defined", name, param.Definition))
+		}
+	}
+	for name, output := range bun.Outputs {
+		if _, ok := bun.Definitions[output.Definition]; !ok {
+			missing = append(missing, fmt.Sprintf("output %q uses definition %q, which is not defined", name, output.Definition))
+		}
+	}
+	sort.Strings(missing)
+
+	var errs *multierror.Error
+	errs = multierror.Append(errs, b.errs...)
+	for _, msg := range missing {
+		errs = multierror.Append(errs, errors.New(msg))
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return Bundle{}, err
+	}
+
+	if err := bun.Validate(); err != nil {
+		return Bundle{}, err
+	}
+
+	data, err := json.Marshal(bun)
+	if err != nil {
+		return Bundle{}, pkgErrors.Wrap(err, "could not marshal the bundle")
+	}
+	valErrs, err := schema.ValidateBundle(data)
+	if err != nil {
+		return Bundle{}, pkgErrors.Wrap(err, "could not validate the bundle against the JSON schema")
+	}
+	if len(valErrs) > 0 {
+		msgs := make([]string, len(valErrs))
+		for i, valErr := range valErrs {
+			msgs[i] = valErr.Error()
+		}
+		return Bundle{}, fmt.Errorf("the bundle does not match the JSON schema: %s", strings.Join(msgs, ", "))
+	}
+
+	return bun, nil
+}
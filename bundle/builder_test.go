@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func newTestBuilder() *Builder {
+	return NewBuilder("wordpress", "0.1.0").
+		InvocationImage(InvocationImage{BaseImage: BaseImage{ImageType: "docker", Image: "example.com/wordpress:0.1.0"}})
+}
+
+func TestBuilder_Build(t *testing.T) {
+	writeOnly := true
+	b, err := newTestBuilder().
+		Description("a wordpress bundle").
+		Keywords("wordpress", "blog").
+		Maintainer(Maintainer{Name: "Sally", Email: "sally@example.com"}).
+		License("MIT").
+		Image("web", Image{BaseImage: BaseImage{ImageType: "docker", Image: "example.com/web:0.1.0"}}).
+		Parameter("port", &definition.Schema{Type: "integer", Default: 8080}, Parameter{Destination: &Location{EnvironmentVariable: "PORT"}}).
+		Definition("secret", &definition.Schema{Type: "string", WriteOnly: &writeOnly}).
+		Parameter("password", nil, Parameter{Definition: "secret", Required: true, Destination: &Location{Path: "/cnab/app/password"}}).
+		Credential("kubeconfig", Credential{Location: Location{Path: "/root/.kube/config"}, Required: true}).
+		Output("url", &definition.Schema{Type: "string"}, Output{Path: "/cnab/app/outputs/url"}).
+		Action("logs", Action{Stateless: true}).
+		Custom("com.example.backup", map[string]interface{}{"enabled": true}, true).
+		Build()
+	require.NoError(t, err)
+
+	wantVersion, err := GetDefaultSchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, wantVersion, b.SchemaVersion, "the schema version should be defaulted")
+
+	assert.Equal(t, "wordpress", b.Name)
+	assert.Equal(t, "0.1.0", b.Version)
+	assert.Equal(t, "a wordpress bundle", b.Description)
+	assert.Equal(t, []string{"wordpress", "blog"}, b.Keywords)
+	assert.Equal(t, "MIT", b.License)
+	assert.Len(t, b.Maintainers, 1)
+	assert.Contains(t, b.Images, "web")
+
+	assert.Equal(t, definition.Definitions{
+		"port":   {Type: "integer", Default: 8080},
+		"secret": {Type: "string", WriteOnly: &writeOnly},
+		"url":    {Type: "string"},
+	}, b.Definitions)
+	assert.Equal(t, "port", b.Parameters["port"].Definition, "the definition should be named after the parameter")
+	assert.Equal(t, "secret", b.Parameters["password"].Definition)
+	assert.Equal(t, "url", b.Outputs["url"].Definition)
+	assert.Equal(t, Action{Stateless: true}, b.Actions["logs"])
+	assert.Equal(t, []string{"com.example.backup"}, b.RequiredExtensions)
+}
+
+func TestBuilder_SharedDefinition(t *testing.T) {
+	port := &definition.Schema{Type: "integer"}
+	b, err := newTestBuilder().
+		Parameter("port", port, Parameter{Definition: "port", Destination: &Location{EnvironmentVariable: "PORT"}}).
+		Output("port", &definition.Schema{Type: "integer"}, Output{Definition: "port", Path: "/cnab/app/outputs/port"}).
+		Build()
+	require.NoError(t, err)
+	assert.Len(t, b.Definitions, 1)
+}
+
+func TestBuilder_Build_Errors(t *testing.T) {
+	destination := &Location{EnvironmentVariable: "PORT"}
+
+	testcases := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{
+			name: "duplicate parameter",
+			builder: newTestBuilder().
+				Parameter("port", &definition.Schema{Type: "integer"}, Parameter{Destination: destination}).
+				Parameter("port", &definition.Schema{Type: "integer"}, Parameter{Destination: destination}),
+			wantErr: `parameter "port" is already defined`,
+		},
+		{
+			name: "conflicting definition",
+			builder: newTestBuilder().
+				Parameter("port", &definition.Schema{Type: "integer"}, Parameter{Destination: destination}).
+				Output("url", &definition.Schema{Type: "string"}, Output{Definition: "port", Path: "/cnab/app/outputs/url"}),
+			wantErr: `definition "port" is already defined with a different schema`,
+		},
+		{
+			name: "missing definition",
+			builder: newTestBuilder().
+				Parameter("port", nil, Parameter{Destination: destination}),
+			wantErr: `parameter "port" uses definition "port", which is not defined`,
+		},
+		{
+			name: "core action",
+			builder: newTestBuilder().
+				Action("install", Action{}),
+			wantErr: `action "install" is a core action and can not be defined by the bundle`,
+		},
+		{
+			name: "duplicate credential",
+			builder: newTestBuilder().
+				Credential("token", Credential{Location: Location{EnvironmentVariable: "TOKEN"}}).
+				Credential("token", Credential{Location: Location{EnvironmentVariable: "TOKEN"}}),
+			wantErr: `credential "token" is already defined`,
+		},
+		{
+			name:    "no invocation image",
+			builder: NewBuilder("wordpress", "0.1.0"),
+			wantErr: "at least one invocation image must be defined in the bundle",
+		},
+		{
+			name: "missing parameter destination",
+			builder: newTestBuilder().
+				Parameter("port", &definition.Schema{Type: "integer"}, Parameter{}),
+			wantErr: `validation failed for parameter "port": parameter destination must be provided`,
+		},
+		{
+			name: "json schema",
+			builder: newTestBuilder().
+				Output("url", &definition.Schema{Type: "string"}, Output{Path: "/tmp/url"}),
+			wantErr: "the bundle does not match the JSON schema: outputs.url.path: Does not match pattern",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.builder.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestBuilder_Build_AllErrors(t *testing.T) {
+	destination := &Location{EnvironmentVariable: "PORT"}
+	_, err := newTestBuilder().
+		Parameter("port", &definition.Schema{Type: "integer"}, Parameter{Destination: destination}).
+		Parameter("port", &definition.Schema{Type: "integer"}, Parameter{Destination: destination}).
+		Action("upgrade", Action{}).
+		Parameter("host", nil, Parameter{Destination: destination}).
+		Build()
+	require.Error(t, err)
+
+	merr, ok := err.(*multierror.Error)
+	require.True(t, ok, "expected a *multierror.Error, got %T", err)
+	require.Len(t, merr.Errors, 3, "every mistake should be returned")
+	assert.EqualError(t, merr.Errors[0], `parameter "port" is already defined`)
+	assert.EqualError(t, merr.Errors[1], `action "upgrade" is a core action and can not be defined by the bundle`)
+	assert.EqualError(t, merr.Errors[2], `parameter "host" uses definition "host", which is not defined`)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/docker/go/canonical/json"
@@ -54,6 +55,7 @@ func GetDefaultSchemaVersion() (schema.Version, error) {
 }
 
 //Unmarshal unmarshals a Bundle that was not signed.
+// Signed bundles (bundle.cnab) are verified and unmarshaled with the bundle/signature package.
 func Unmarshal(data []byte) (*Bundle, error) {
 	b := &Bundle{}
 	return b, json.Unmarshal(data, b)
@@ -68,8 +70,7 @@ func ParseReader(r io.Reader) (Bundle, error) {
 
 // WriteFile serializes the bundle and writes it to a file as JSON.
 func (b Bundle) WriteFile(dest string, mode os.FileMode) error {
-	// FIXME: The marshal here should exactly match the Marshal in the signature code.
-	d, err := json.MarshalCanonical(b)
+	d, err := b.MarshalCanonical()
 	if err != nil {
 		return err
 	}
@@ -78,7 +79,7 @@ func (b Bundle) WriteFile(dest string, mode os.FileMode) error {
 
 // WriteTo writes unsigned JSON to an io.Writer using the standard formatting.
 func (b Bundle) WriteTo(w io.Writer) (int64, error) {
-	d, err := json.MarshalCanonical(b)
+	d, err := b.MarshalCanonical()
 	if err != nil {
 		return 0, err
 	}
@@ -185,39 +186,86 @@ type Action struct {
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 }
 
+// coreActions are the actions that every bundle has, which can not be
+// defined as custom actions.
+var coreActions = []string{"install", "upgrade", "uninstall"}
+
+// IsCoreAction returns true when the action is one of the core actions that
+// every bundle has: install, upgrade and uninstall.
+func IsCoreAction(action string) bool {
+	for _, core := range coreActions {
+		if action == core {
+			return true
+		}
+	}
+	return false
+}
+
 // ValuesOrDefaults returns parameter values or the default parameter values. An error is returned when the parameter value does not pass
 // the schema validation or a required parameter is missing, assuming the parameter applies to the provided action.
+//
+// Every parameter is validated, and when any are invalid, or missing, the
+// returned error is a ParameterValidationErrors that lists each of them.
+//
+// The defaults of nested properties and array items in the definition are
+// filled in before a value is validated, see definition.Schema.ApplyDefaults.
 func ValuesOrDefaults(vals map[string]interface{}, b *Bundle, action string) (map[string]interface{}, error) {
 	res := map[string]interface{}{}
 
-	for name, param := range b.Parameters {
+	names := make([]string, 0, len(b.Parameters))
+	for name := range b.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var paramErrs ParameterValidationErrors
+	for _, name := range names {
+		param := b.Parameters[name]
 		// If the parameter doesn't apply to the provided action,
 		// skip validation and do not attempt to include in the returned list
 		if !param.AppliesTo(action) {
 			continue
 		}
-		s, ok := b.Definitions[param.Definition]
+		def, ok := b.Definitions[param.Definition]
 		if !ok {
 			return res, fmt.Errorf("unable to find definition for %s", name)
 		}
+		s, err := b.Definitions.Dereference(def)
+		if err != nil {
+			return res, pkgErrors.Wrapf(err, "unable to resolve definition for %s", name)
+		}
 		if val, ok := vals[name]; ok {
+			val = s.ApplyDefaults(val)
 			valErrs, err := s.Validate(val)
 			if err != nil {
 				return res, pkgErrors.Wrapf(err, "encountered an error validating parameter %s", name)
 			}
-			// This interface returns a single error. Validation can have multiple errors. For now return the first
-			// We should update this later.
-			if len(valErrs) > 0 {
-				valErr := valErrs[0]
-				return res, fmt.Errorf("cannot use value: %v as parameter %s: %s ", val, name, valErr.Error)
+			for _, valErr := range valErrs {
+				paramErrs = append(paramErrs, ParameterError{
+					Parameter: name,
+					Path:      parameterPath(name, valErr.Path),
+					Keyword:   valErr.Keyword,
+					Message:   valErr.Error,
+					Value:     valErr.Value,
+				})
 			}
 			typedVal := s.CoerceValue(val)
 			res[name] = typedVal
 			continue
 		} else if param.Required {
-			return res, fmt.Errorf("parameter %q is required", name)
+			paramErrs = append(paramErrs, ParameterError{
+				Parameter: name,
+				Path:      parameterPath(name, ""),
+				Keyword:   "required",
+				Message:   "the parameter is required",
+			})
+			continue
 		}
-		res[name] = s.Default
+		res[name] = s.ApplyDefaults(nil)
+	}
+
+	if len(paramErrs) > 0 {
+		return res, paramErrs
 	}
 	return res, nil
 }
@@ -253,6 +301,28 @@ func (b Bundle) Validate() error {
 		reqExt[requiredExtension] = true
 	}
 
+	// Validate the dependencies extension
+	deps, ok, err := b.ReadDependencies()
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := deps.Validate(); err != nil {
+			return pkgErrors.Wrapf(err, "invalid %s extension", DependenciesExtensionKey)
+		}
+	}
+
+	// Validate the parameter sources extension
+	sources, ok, err := b.ReadParameterSources()
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := sources.Validate(b); err != nil {
+			return pkgErrors.Wrapf(err, "invalid %s extension", ParameterSourcesExtensionKey)
+		}
+	}
+
 	// Validate the invocation images
 	for _, img := range b.InvocationImages {
 		err := img.Validate()
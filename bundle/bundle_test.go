@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	pkgErrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v2"
@@ -172,6 +173,89 @@ func TestValuesOrDefaults_NoParameter(t *testing.T) {
 	is.Len(vod, 0)
 }
 
+func TestValuesOrDefaults_Ref(t *testing.T) {
+	maximum := 10
+	b := &Bundle{
+		Definitions: map[string]*definition.Schema{
+			"count": {
+				Type:    "integer",
+				Maximum: &maximum,
+			},
+			"replicaCountType": {
+				Ref:     "#/definitions/count",
+				Default: 3,
+			},
+			"cycleType": {
+				Ref: "#/definitions/cycleType",
+			},
+		},
+		Parameters: map[string]Parameter{
+			"replicaCount": {
+				Definition: "replicaCountType",
+			},
+		},
+	}
+
+	vod, err := ValuesOrDefaults(map[string]interface{}{}, b, "install")
+	require.NoError(t, err)
+	assert.Equal(t, 3, vod["replicaCount"])
+
+	_, err = ValuesOrDefaults(map[string]interface{}{"replicaCount": 20}, b, "install")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parameter replicaCount")
+
+	b.Parameters["cycle"] = Parameter{Definition: "cycleType"}
+	_, err = ValuesOrDefaults(map[string]interface{}{}, b, "install")
+	require.Error(t, err)
+	assert.Equal(t, definition.ErrCircularRef, pkgErrors.Cause(err))
+}
+
+func TestValuesOrDefaults_NestedDefaults(t *testing.T) {
+	maxReplicas := 5
+	b := &Bundle{
+		Definitions: map[string]*definition.Schema{
+			"configType": {
+				Type: "object",
+				Properties: map[string]*definition.Schema{
+					"replicas": {Type: "integer", Default: 3, Maximum: &maxReplicas},
+					"image": {
+						Type: "object",
+						Properties: map[string]*definition.Schema{
+							"repository": {Type: "string", Default: "nginx"},
+							"tag":        {Type: "string", Default: "latest"},
+						},
+					},
+				},
+				Required: []string{"replicas"},
+			},
+		},
+		Parameters: map[string]Parameter{
+			"config": {Definition: "configType"},
+		},
+	}
+
+	vals := map[string]interface{}{
+		"config": map[string]interface{}{
+			"image": map[string]interface{}{"tag": "1.17"},
+		},
+	}
+	vod, err := ValuesOrDefaults(vals, b, "install")
+	require.NoError(t, err, "the nested defaults should be applied before validation")
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 3,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.17"},
+	}, vod["config"])
+
+	b.Definitions["configType"].Default = map[string]interface{}{}
+	vod, err = ValuesOrDefaults(map[string]interface{}{}, b, "install")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": 3}, vod["config"], "the nested defaults should be applied to the default")
+
+	vals["config"] = map[string]interface{}{"replicas": 10}
+	_, err = ValuesOrDefaults(vals, b, "install")
+	require.Error(t, err, "a value that is set should still be validated")
+}
+
 func TestValuesOrDefaults_Required(t *testing.T) {
 	is := assert.New(t)
 	vals := map[string]interface{}{
@@ -214,6 +298,59 @@ func TestValuesOrDefaults_Required(t *testing.T) {
 	is.Equal(0, res["minimum"])
 }
 
+func TestValuesOrDefaults_AllErrors(t *testing.T) {
+	min := 10
+	max := 3
+	b := &Bundle{
+		Definitions: map[string]*definition.Schema{
+			"portType":  {Type: "integer", Minimum: &min},
+			"colorType": {Type: "string", Enum: []interface{}{"red", "blue"}},
+			"configType": {
+				Type:       "object",
+				Properties: map[string]*definition.Schema{"replicas": {Type: "integer", Maximum: &max}},
+			},
+			"hostType": {Type: "string"},
+		},
+		Parameters: map[string]Parameter{
+			"port":   {Definition: "portType"},
+			"color":  {Definition: "colorType"},
+			"config": {Definition: "configType"},
+			"host":   {Definition: "hostType", Required: true},
+		},
+	}
+	vals := map[string]interface{}{
+		"port":   5,
+		"color":  "green",
+		"config": map[string]interface{}{"replicas": 5},
+	}
+
+	_, err := ValuesOrDefaults(vals, b, "install")
+	require.Error(t, err)
+	paramErrs, ok := err.(ParameterValidationErrors)
+	require.True(t, ok, "expected ParameterValidationErrors, got %T", err)
+
+	want := []struct {
+		parameter, path, keyword string
+	}{
+		{"color", "/color", "enum"},
+		{"config", "/config/replicas", "maximum"},
+		{"host", "/host", "required"},
+		{"port", "/port", "minimum"},
+	}
+	require.Len(t, paramErrs, len(want), "every invalid parameter should be reported: %v", err)
+	for i, w := range want {
+		assert.Equal(t, w.parameter, paramErrs[i].Parameter)
+		assert.Equal(t, w.path, paramErrs[i].Path)
+		assert.Equal(t, w.keyword, paramErrs[i].Keyword)
+		assert.NotEmpty(t, paramErrs[i].Message)
+	}
+
+	assert.Contains(t, err.Error(), "4 parameter validation errors occurred")
+	assert.Contains(t, err.Error(), `parameter "host" is required`)
+	assert.Contains(t, err.Error(), "cannot use value: 5 as parameter config at /config/replicas")
+	assert.Contains(t, err.Error(), "cannot use value: green as parameter color")
+}
+
 func TestValuesOrDefaults_NotApplicableToAction(t *testing.T) {
 	// vals represent user-supplied parameter values
 	vals := map[string]interface{}{
@@ -898,3 +1035,10 @@ func TestBundle_IsOutputSensitive(t *testing.T) {
 	})
 
 }
+
+func TestIsCoreAction(t *testing.T) {
+	for _, action := range []string{"install", "upgrade", "uninstall"} {
+		assert.True(t, IsCoreAction(action), "%s should be a core action", action)
+	}
+	assert.False(t, IsCoreAction("status"), "custom actions should not be core actions")
+}
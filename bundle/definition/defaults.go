@@ -0,0 +1,108 @@
+package definition
+
+// ApplyDefaults returns a copy of the value with the defaults from the schema
+// filled in, the value itself is not modified. When the value is nil, it is the
+// schema's default.
+//
+// The defaults of an object's properties are added when the property is not
+// set, the defaults of the schema's items are applied to each item of an
+// array, and the defaults of each allOf schema are applied in order. Values
+// that are set are never replaced, for example a property set to null is left
+// as-is.
+//
+// References are not resolved, so dereference the schema first, for example
+// with the Definitions of a bundle.
+func (s *Schema) ApplyDefaults(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+
+	if value == nil {
+		value = copyValue(s.Default)
+	}
+
+	for _, sub := range s.AllOf {
+		value = sub.ApplyDefaults(value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(s.Properties) == 0 {
+			return v
+		}
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = item
+		}
+		for name, prop := range s.Properties {
+			if item, ok := result[name]; ok {
+				if item != nil {
+					result[name] = prop.ApplyDefaults(item)
+				}
+				continue
+			}
+			if item := prop.ApplyDefaults(nil); item != nil {
+				result[name] = item
+			}
+		}
+		return result
+	case []interface{}:
+		if s.Items == nil {
+			return v
+		}
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = s.itemSchema(i).ApplyDefaults(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// itemSchema returns the schema for the item of an array at the index. Items
+// is either a schema for every item, or a list of schemas for each position.
+func (s *Schema) itemSchema(i int) *Schema {
+	switch items := s.Items.(type) {
+	case *Schema:
+		return items
+	case []*Schema:
+		if i < len(items) {
+			return items[i]
+		}
+	case map[string]interface{}:
+		item, err := toSchema(items)
+		if err == nil {
+			return item
+		}
+	case []interface{}:
+		if i < len(items) {
+			item, err := toSchema(items[i])
+			if err == nil {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// copyValue returns a deep copy of the objects and arrays in a value, so that
+// defaults can be changed without modifying the schema.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = copyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
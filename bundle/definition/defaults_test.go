@@ -0,0 +1,122 @@
+package definition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_ApplyDefaults(t *testing.T) {
+	s := `{
+		"type": "object",
+		"properties": {
+			"replicas": {
+				"type": "integer",
+				"default": 3
+			},
+			"image": {
+				"type": "object",
+				"properties": {
+					"repository": {"type": "string", "default": "nginx"},
+					"tag": {"type": "string", "default": "latest"}
+				}
+			},
+			"ports": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"protocol": {"type": "string", "default": "TCP"}
+					}
+				}
+			},
+			"logging": {
+				"type": "object",
+				"default": {"level": "info"},
+				"properties": {
+					"format": {"type": "string", "default": "json"}
+				}
+			}
+		},
+		"allOf": [
+			{
+				"properties": {
+					"debug": {"type": "boolean", "default": false}
+				}
+			}
+		]
+	}`
+	definition := new(Schema)
+	err := json.Unmarshal([]byte(s), definition)
+	require.NoError(t, err)
+
+	value := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "1.17"},
+		"ports": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 53, "protocol": "UDP"},
+		},
+	}
+
+	result := definition.ApplyDefaults(value)
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 3.0,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.17"},
+		"ports": []interface{}{
+			map[string]interface{}{"port": 80, "protocol": "TCP"},
+			map[string]interface{}{"port": 53, "protocol": "UDP"},
+		},
+		"logging": map[string]interface{}{"level": "info", "format": "json"},
+		"debug":   false,
+	}, result)
+
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{"tag": "1.17"},
+		"ports": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 53, "protocol": "UDP"},
+		},
+	}, value, "the value should not be modified")
+	assert.Equal(t, map[string]interface{}{"level": "info"}, definition.Properties["logging"].Default, "the default should not be modified")
+}
+
+func TestSchema_ApplyDefaults_TupleItems(t *testing.T) {
+	s := `{
+		"type": "array",
+		"items": [
+			{"type": "object", "properties": {"name": {"type": "string", "default": "first"}}},
+			{"type": "object", "properties": {"name": {"type": "string", "default": "second"}}}
+		]
+	}`
+	definition := new(Schema)
+	err := json.Unmarshal([]byte(s), definition)
+	require.NoError(t, err)
+
+	result := definition.ApplyDefaults([]interface{}{
+		map[string]interface{}{},
+		map[string]interface{}{},
+		map[string]interface{}{},
+	})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "first"},
+		map[string]interface{}{"name": "second"},
+		map[string]interface{}{},
+	}, result)
+}
+
+func TestSchema_ApplyDefaults_SetValues(t *testing.T) {
+	definition := &Schema{
+		Type:    "object",
+		Default: map[string]interface{}{"a": 1},
+		Properties: map[string]*Schema{
+			"b": {Type: "string", Default: "b"},
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": "b"}, definition.ApplyDefaults(nil))
+	assert.Equal(t, map[string]interface{}{"b": nil}, definition.ApplyDefaults(map[string]interface{}{"b": nil}),
+		"a property set to null should not be replaced")
+	assert.Equal(t, "not an object", definition.ApplyDefaults("not an object"))
+}
@@ -0,0 +1,397 @@
+package definition
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircularRef represents a $ref that refers back to itself, directly or
+// through other references, so the schema cannot be dereferenced.
+var ErrCircularRef = errors.New("circular $ref")
+
+// dataKeywords contain values instead of schemas, so they are not searched
+// for references.
+var dataKeywords = map[string]bool{
+	"const":    true,
+	"default":  true,
+	"enum":     true,
+	"examples": true,
+}
+
+// schemaMapKeywords contain a map of names to schemas, such as the properties
+// of an object, so the keys of their value are names instead of keywords.
+var schemaMapKeywords = map[string]bool{
+	"definitions":       true,
+	"dependencies":      true,
+	"patternProperties": true,
+	"properties":        true,
+}
+
+// Resolve the schema that a $ref refers to, for example
+// "#/definitions/port", relative to a document, such as a bundle, that
+// contains these definitions. References to the definitions of a definition,
+// for example "#/definitions/config/definitions/port", are also supported.
+// The returned schema is not dereferenced.
+func (d Definitions) Resolve(ref string) (*Schema, error) {
+	root, err := d.document()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := resolvePointer(root, ref)
+	if err != nil {
+		return nil, err
+	}
+	return toSchema(target)
+}
+
+// Dereference returns a copy of the schema with every $ref replaced by the
+// schema it refers to, resolved relative to a document, such as a bundle, that
+// contains these definitions. This is the effective schema, for example of a
+// parameter.
+//
+// Keywords next to a $ref take precedence over the keywords of the schema it
+// refers to, so that a reference may override, for example, its default.
+// Recursive references, for example a tree whose children refer to the tree
+// itself, cannot be inlined. They are kept, and the definitions are copied to
+// the returned schema so that they can still be resolved when it is validated.
+// Returns ErrCircularRef when a $ref refers back to itself without describing
+// any structure, for example when two definitions only refer to each other.
+func (d Definitions) Dereference(s *Schema) (*Schema, error) {
+	root, err := d.document()
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := dereferenceSchema(root, s)
+	return result, err
+}
+
+// Dereference returns a copy of the schema with every $ref replaced by the
+// schema it refers to, resolved relative to this schema, for example
+// "#/definitions/port" refers to the port entry of the schema's Definitions.
+// Recursive references are kept, see Definitions.Dereference.
+// Returns ErrCircularRef when a $ref refers back to itself without describing
+// any structure.
+func (s *Schema) Dereference() (*Schema, error) {
+	result, _, err := s.dereference()
+	return result, err
+}
+
+// dereference returns the dereferenced schema and whether it still contains
+// recursive references.
+func (s *Schema) dereference() (*Schema, bool, error) {
+	root, err := toDocument(s)
+	if err != nil {
+		return nil, false, err
+	}
+	return dereferenceSchema(root, s)
+}
+
+// document returns the definitions as a generic JSON document that contains
+// them, which references are resolved against.
+func (d Definitions) document() (interface{}, error) {
+	root, err := toDocument(map[string]interface{}{"definitions": d})
+	return root, errors.Wrap(err, "unable to load definitions")
+}
+
+// dereferenceSchema returns the dereferenced schema and whether it still
+// contains recursive references.
+func dereferenceSchema(root interface{}, s *Schema) (*Schema, bool, error) {
+	doc, err := toDocument(s)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A schema without references is copied as-is, so that the values of its
+	// data keywords, such as an integer default, keep their type.
+	if !hasRefs(doc) {
+		c := *s
+		return &c, false, nil
+	}
+
+	d := dereferencer{root: root}
+	result, err := d.dereference(doc, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if d.recursive {
+		// The references that were kept are relative to the root document
+		if defs, ok := root.(map[string]interface{})["definitions"]; ok {
+			result.(map[string]interface{})["definitions"] = defs
+		}
+	}
+	deref, err := toSchema(result)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The data keywords of the schema take precedence over those of the
+	// schema it refers to, and are kept as they were so that their values
+	// keep their type.
+	if s.Const != nil {
+		deref.Const = s.Const
+	}
+	if s.Default != nil {
+		deref.Default = s.Default
+	}
+	if s.Enum != nil {
+		deref.Enum = s.Enum
+	}
+	if s.Examples != nil {
+		deref.Examples = s.Examples
+	}
+	return deref, d.recursive, nil
+}
+
+// dereferencer replaces the references in a generic JSON schema.
+type dereferencer struct {
+	// root is the document that references are resolved against.
+	root interface{}
+
+	// recursive is set when a recursive reference was kept.
+	recursive bool
+}
+
+// dereference replaces the references in a node. The stack contains the
+// references that are being dereferenced, and chain the references that were
+// followed without describing any structure since, to detect cycles.
+func (d *dereferencer) dereference(node interface{}, stack []string, chain []string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(n))
+		if ref, ok := n["$ref"].(string); ok {
+			if contains(chain, ref) {
+				return nil, errors.Wrapf(ErrCircularRef, "%s", strings.Join(append(chain, ref), " -> "))
+			}
+
+			if contains(stack, ref) {
+				// The reference is recursive, so it is left to the validator
+				result["$ref"] = ref
+				d.recursive = true
+			} else {
+				target, err := resolvePointer(d.root, ref)
+				if err != nil {
+					return nil, err
+				}
+				resolved, err := d.dereference(target, append(stack, ref), append(chain, ref))
+				if err != nil {
+					return nil, err
+				}
+				targetSchema, ok := resolved.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("$ref %s does not refer to a schema", ref)
+				}
+				for k, v := range targetSchema {
+					result[k] = v
+				}
+			}
+		}
+
+		for k, v := range n {
+			if k == "$ref" {
+				continue
+			}
+			// Data keywords contain values, and unused definitions are left
+			// as-is, so that a cycle in them is not reported.
+			if dataKeywords[k] || k == "definitions" {
+				result[k] = v
+				continue
+			}
+			if schemas, ok := v.(map[string]interface{}); ok && schemaMapKeywords[k] {
+				value := make(map[string]interface{}, len(schemas))
+				for name, schema := range schemas {
+					deref, err := d.dereference(schema, stack, nil)
+					if err != nil {
+						return nil, err
+					}
+					value[name] = deref
+				}
+				result[k] = value
+				continue
+			}
+			value, err := d.dereference(v, stack, nil)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = value
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(n))
+		for i, v := range n {
+			value, err := d.dereference(v, stack, nil)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		return node, nil
+	}
+}
+
+func contains(refs []string, ref string) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRefs reports whether a generic JSON schema contains a $ref, outside of
+// its data keywords and definitions.
+func hasRefs(node interface{}) bool {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			if k == "$ref" {
+				return true
+			}
+			if dataKeywords[k] || k == "definitions" {
+				continue
+			}
+			if schemas, ok := v.(map[string]interface{}); ok && schemaMapKeywords[k] {
+				for _, schema := range schemas {
+					if hasRefs(schema) {
+						return true
+					}
+				}
+				continue
+			}
+			if hasRefs(v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if hasRefs(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolvePointer resolves a local reference, which is a JSON pointer in a URI
+// fragment, for example #/definitions/port.
+func resolvePointer(root interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %s, only references within the document are supported", ref)
+	}
+
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid $ref %s, it must be a JSON pointer such as #/definitions/name", ref)
+	}
+
+	node := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("unable to resolve $ref %s, %q was not found", ref, token)
+			}
+			node = value
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("unable to resolve $ref %s, invalid index %q", ref, token)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("unable to resolve $ref %s, %q was not found", ref, token)
+		}
+	}
+	return node, nil
+}
+
+// toDocument converts a value to a generic JSON document.
+func toDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal schema")
+	}
+	var doc interface{}
+	err = json.Unmarshal(data, &doc)
+	return doc, errors.Wrap(err, "unable to unmarshal schema")
+}
+
+// toSchema converts a generic JSON document to a Schema.
+func toSchema(doc interface{}) (*Schema, error) {
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("invalid schema, expected an object but got %T", doc)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal schema")
+	}
+	s := &Schema{}
+	err = json.Unmarshal(data, s)
+	return s, errors.Wrap(err, "unable to unmarshal schema")
+}
+
+// withoutRefs removes the references from a JSON schema, so that it can be
+// checked without resolving them. The schema is returned as-is when it has no
+// references or cannot be parsed.
+func withoutRefs(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`"$ref"`)) {
+		return data
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data
+	}
+	result, err := json.Marshal(removeRefs(doc))
+	if err != nil {
+		return data
+	}
+	return result
+}
+
+func removeRefs(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			if k == "$ref" {
+				continue
+			}
+			if dataKeywords[k] {
+				result[k] = v
+				continue
+			}
+			if schemas, ok := v.(map[string]interface{}); ok && schemaMapKeywords[k] {
+				value := make(map[string]interface{}, len(schemas))
+				for name, schema := range schemas {
+					value[name] = removeRefs(schema)
+				}
+				result[k] = value
+				continue
+			}
+			result[k] = removeRefs(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(n))
+		for i, v := range n {
+			result[i] = removeRefs(v)
+		}
+		return result
+	default:
+		return node
+	}
+}
@@ -0,0 +1,268 @@
+package definition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDefinitions(t *testing.T, s string) Definitions {
+	defs := Definitions{}
+	err := json.Unmarshal([]byte(s), &defs)
+	require.NoError(t, err, "should have been able to unmarshal the definitions")
+	return defs
+}
+
+func TestDefinitions_Resolve(t *testing.T) {
+	defs := loadDefinitions(t, `{
+		"port": {
+			"type": "integer",
+			"minimum": 10
+		},
+		"config": {
+			"type": "object",
+			"definitions": {
+				"replicas": {
+					"type": "integer",
+					"maximum": 5
+				}
+			}
+		},
+		"a/b": {
+			"type": "string"
+		}
+	}`)
+
+	s, err := defs.Resolve("#/definitions/port")
+	require.NoError(t, err)
+	assert.Equal(t, "integer", s.Type)
+
+	s, err = defs.Resolve("#/definitions/config/definitions/replicas")
+	require.NoError(t, err)
+	assert.Equal(t, "integer", s.Type)
+	assert.Equal(t, 5, *s.Maximum)
+
+	s, err = defs.Resolve("#/definitions/a~1b")
+	require.NoError(t, err)
+	assert.Equal(t, "string", s.Type)
+
+	_, err = defs.Resolve("#/definitions/missing")
+	require.EqualError(t, err, `unable to resolve $ref #/definitions/missing, "missing" was not found`)
+
+	_, err = defs.Resolve("https://example.com/schema.json")
+	require.EqualError(t, err, "unsupported $ref https://example.com/schema.json, only references within the document are supported")
+}
+
+func TestDefinitions_Dereference(t *testing.T) {
+	defs := loadDefinitions(t, `{
+		"port": {
+			"type": "integer",
+			"minimum": 10,
+			"default": 80
+		},
+		"replicas": {
+			"$ref": "#/definitions/config/definitions/count"
+		},
+		"config": {
+			"type": "object",
+			"properties": {
+				"port": {
+					"$ref": "#/definitions/port",
+					"default": 8080
+				},
+				"replicas": {
+					"$ref": "#/definitions/replicas"
+				}
+			},
+			"definitions": {
+				"count": {
+					"type": "integer",
+					"maximum": 5
+				}
+			}
+		}
+	}`)
+
+	s, err := defs.Dereference(defs["config"])
+	require.NoError(t, err)
+
+	port := s.Properties["port"]
+	require.NotNil(t, port)
+	assert.Empty(t, port.Ref)
+	assert.Equal(t, "integer", port.Type)
+	assert.Equal(t, 10, *port.Minimum)
+	assert.Equal(t, 8080.0, port.Default, "the keywords next to the $ref should override the definition")
+
+	replicas := s.Properties["replicas"]
+	require.NotNil(t, replicas)
+	assert.Empty(t, replicas.Ref)
+	assert.Equal(t, "integer", replicas.Type)
+	assert.Equal(t, 5, *replicas.Maximum)
+
+	assert.Equal(t, "#/definitions/port", defs["config"].Properties["port"].Ref, "the original schema should not be modified")
+}
+
+func TestDefinitions_Dereference_Cycle(t *testing.T) {
+	defs := loadDefinitions(t, `{
+		"a": {
+			"$ref": "#/definitions/b"
+		},
+		"b": {
+			"$ref": "#/definitions/a"
+		}
+	}`)
+
+	_, err := defs.Dereference(defs["a"])
+	require.Error(t, err)
+	assert.Equal(t, ErrCircularRef, errors.Cause(err))
+	assert.EqualError(t, err, "#/definitions/b -> #/definitions/a -> #/definitions/b: circular $ref")
+}
+
+func TestDefinitions_Dereference_Recursive(t *testing.T) {
+	defs := loadDefinitions(t, `{
+		"node": {
+			"type": "object",
+			"properties": {
+				"name": {
+					"type": "string"
+				},
+				"children": {
+					"type": "array",
+					"items": {
+						"$ref": "#/definitions/node"
+					}
+				}
+			}
+		},
+		"tree": {
+			"$ref": "#/definitions/node"
+		}
+	}`)
+
+	s, err := defs.Dereference(defs["tree"])
+	require.NoError(t, err)
+	assert.Equal(t, "object", s.Type)
+	items, ok := s.Properties["children"].Items.(map[string]interface{})
+	require.True(t, ok, "items should be a schema")
+	assert.Equal(t, "#/definitions/node", items["$ref"], "the recursive reference should be kept")
+	assert.Contains(t, s.Definitions, "node", "the definitions should be copied so that the reference can be resolved")
+
+	tree := map[string]interface{}{
+		"name": "root",
+		"children": []interface{}{
+			map[string]interface{}{"name": "leaf", "children": []interface{}{}},
+		},
+	}
+	valErrors, err := s.Validate(tree)
+	require.NoError(t, err)
+	assert.Empty(t, valErrors)
+
+	tree["children"] = []interface{}{map[string]interface{}{"name": 1}}
+	valErrors, err = s.Validate(tree)
+	require.NoError(t, err)
+	require.Len(t, valErrors, 1)
+	assert.Equal(t, "/children/0/name", valErrors[0].Path)
+}
+
+func TestDefinitions_Dereference_NoRefs(t *testing.T) {
+	defs := Definitions{"port": {Type: "integer", Default: 8080}}
+
+	s, err := defs.Dereference(defs["port"])
+	require.NoError(t, err)
+	assert.Equal(t, 8080, s.Default, "the default should keep its type")
+	assert.False(t, defs["port"] == s, "a copy should be returned")
+}
+
+func TestSchema_Dereference(t *testing.T) {
+	s := `{
+		"type": "object",
+		"properties": {
+			"port": {
+				"$ref": "#/definitions/port"
+			}
+		},
+		"definitions": {
+			"port": {
+				"type": "integer",
+				"maximum": 10240
+			}
+		}
+	}`
+	definition := new(Schema)
+	err := json.Unmarshal([]byte(s), definition)
+	require.NoError(t, err)
+
+	deref, err := definition.Dereference()
+	require.NoError(t, err)
+	assert.Equal(t, "integer", deref.Properties["port"].Type)
+	assert.Equal(t, 10240, *deref.Properties["port"].Maximum)
+}
+
+func TestSchema_Validate_Ref(t *testing.T) {
+	s := `{
+		"type": "object",
+		"properties": {
+			"port": {
+				"$ref": "#/definitions/port"
+			}
+		},
+		"definitions": {
+			"port": {
+				"type": "integer",
+				"maximum": 10240
+			}
+		}
+	}`
+	definition := new(Schema)
+	err := json.Unmarshal([]byte(s), definition)
+	require.NoError(t, err)
+
+	valErrors, err := definition.Validate(map[string]interface{}{"port": 80})
+	require.NoError(t, err)
+	assert.Empty(t, valErrors)
+
+	valErrors, err = definition.Validate(map[string]interface{}{"port": 20000})
+	require.NoError(t, err)
+	require.Len(t, valErrors, 1)
+	assert.Equal(t, "/port", valErrors[0].Path)
+	assert.Equal(t, "maximum", valErrors[0].Keyword)
+}
+
+func TestSchema_Validate_RefKeywordPropertyName(t *testing.T) {
+	s := `{
+		"type": "object",
+		"properties": {
+			"default": {
+				"$ref": "#/definitions/port"
+			}
+		},
+		"definitions": {
+			"port": {
+				"type": "integer",
+				"maximum": 10240
+			}
+		}
+	}`
+	definition := new(Schema)
+	err := json.Unmarshal([]byte(s), definition)
+	require.NoError(t, err)
+
+	deref, err := definition.Dereference()
+	require.NoError(t, err)
+	require.Contains(t, deref.Properties, "default")
+	assert.Empty(t, deref.Properties["default"].Ref)
+	assert.Equal(t, "integer", deref.Properties["default"].Type)
+
+	valErrors, err := deref.Validate(map[string]interface{}{"default": 80})
+	require.NoError(t, err)
+	assert.Empty(t, valErrors)
+
+	valErrors, err = deref.Validate(map[string]interface{}{"default": 20000})
+	require.NoError(t, err)
+	require.Len(t, valErrors, 1)
+	assert.Equal(t, "/default", valErrors[0].Path)
+	assert.Equal(t, "maximum", valErrors[0].Keyword)
+}
@@ -2,6 +2,7 @@ package definition
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -94,9 +95,10 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 
 	// Before we unmarshal into the cnab-go bundle/definition/Schema type, unmarshal into
 	// the library struct so we can handle any validation errors in the schema. If there
-	// are any errors, return those.
+	// are any errors, return those. References are removed first, because they may refer
+	// to the definitions of the bundle, which are resolved by Dereference instead.
 	js := NewRootSchema()
-	if err := js.UnmarshalJSON(data); err != nil {
+	if err := js.UnmarshalJSON(withoutRefs(data)); err != nil {
 		return err
 	}
 	// The schema is valid at this point, so now use an indirect wrapper type to actually
@@ -110,18 +112,73 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, &wrapper)
 }
 
+// typePrecedence is the order in which the types of a schema with multiple
+// types are tried when converting a value, from the most to the least specific,
+// because every value can be converted to a string.
+var typePrecedence = []string{"null", "boolean", "integer", "number", "array", "object", "string"}
+
 // ConvertValue attempts to convert the given string value to the type from the
-// definition. Note: this is only applicable to string, number, integer and boolean types
+// definition, and validates the converted value against the definition.
+//
+// Numbers are parsed as floats, and arrays and objects are parsed as JSON. When
+// the definition has multiple types, or no type, the value is converted to the
+// most specific type that is valid, for example "1" is converted to an integer
+// before a string. When a string definition has a contentEncoding, the value
+// must already be encoded, for example with base64, and it is not changed.
 func (s *Schema) ConvertValue(val string) (interface{}, error) {
-	dataType, ok, err := s.GetType()
-	if !ok {
-		return nil, errors.Wrapf(err, "unable to determine type: %v", s.Type)
+	var types []string
+	if s.Type == nil {
+		types = typePrecedence
+	} else if dataType, ok, _ := s.GetType(); ok {
+		types = []string{dataType}
+	} else {
+		dataTypes, ok, err := s.GetTypes()
+		if !ok {
+			return nil, errors.Wrapf(err, "unable to determine type: %v", s.Type)
+		}
+		for _, t := range typePrecedence {
+			for _, dataType := range dataTypes {
+				if t == dataType {
+					types = append(types, t)
+				}
+			}
+		}
+		if len(types) == 0 {
+			return nil, errors.Errorf("invalid definition, unsupported types: %v", s.Type)
+		}
 	}
+
+	var convErrs []string
+	for _, dataType := range types {
+		converted, err := s.convertValueToType(val, dataType)
+		if err == nil {
+			err = s.validateConvertedValue(converted)
+		}
+		if err == nil {
+			return converted, nil
+		}
+		if len(types) == 1 {
+			return converted, err
+		}
+		convErrs = append(convErrs, fmt.Sprintf("%s: %s", dataType, err))
+	}
+	return nil, errors.Errorf("unable to convert %q to any of the types %v: %s", val, types, strings.Join(convErrs, "; "))
+}
+
+// convertValueToType converts the string value to the data type, without
+// validating it against the definition.
+func (s *Schema) convertValueToType(val string, dataType string) (interface{}, error) {
 	switch dataType {
 	case "string":
 		return val, nil
 	case "integer":
 		return strconv.Atoi(val)
+	case "number":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a valid number", val)
+		}
+		return f, nil
 	case "boolean":
 		switch strings.ToLower(val) {
 		case "true":
@@ -131,7 +188,44 @@ func (s *Schema) ConvertValue(val string) (interface{}, error) {
 		default:
 			return false, errors.Errorf("%q is not a valid boolean", val)
 		}
+	case "null":
+		if val != "null" {
+			return nil, errors.Errorf("%q is not null", val)
+		}
+		return nil, nil
+	case "array":
+		var arr []interface{}
+		if err := json.Unmarshal([]byte(val), &arr); err != nil {
+			return nil, errors.Wrapf(err, "%q is not a valid JSON array", val)
+		}
+		return arr, nil
+	case "object":
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(val), &obj); err != nil || obj == nil {
+			return nil, errors.Errorf("%q is not a valid JSON object", val)
+		}
+		return obj, nil
 	default:
-		return nil, errors.New("invalid definition")
+		return nil, errors.Errorf("invalid definition, unsupported type %q", dataType)
+	}
+}
+
+// validateConvertedValue validates a converted value against the definition.
+func (s *Schema) validateConvertedValue(val interface{}) error {
+	valErrs, err := s.Validate(val)
+	if err != nil {
+		return err
+	}
+	if len(valErrs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(valErrs))
+	for i, valErr := range valErrs {
+		msgs[i] = valErr.Error
+		if valErr.Path != "" && valErr.Path != "/" {
+			msgs[i] = valErr.Path + ": " + valErr.Error
+		}
 	}
+	return errors.Errorf("invalid value %v: %s", val, strings.Join(msgs, ", "))
 }
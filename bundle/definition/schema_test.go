@@ -310,13 +310,13 @@ func TestConvertValue(t *testing.T) {
 	is.Error(err)
 
 	pd.Type = "number"
-	_, err = pd.ConvertValue("123")
-	is.Error(err)
-	is.Contains(err.Error(), "invalid definition")
+	out, err = pd.ConvertValue("123")
+	is.NoError(err)
+	is.Equal(123.0, out)
 
-	_, err = pd.ConvertValue("5.5")
-	is.Error(err)
-	is.Contains(err.Error(), "invalid definition")
+	out, err = pd.ConvertValue("5.5")
+	is.NoError(err)
+	is.Equal(5.5, out)
 
 	_, err = pd.ConvertValue("nope")
 	is.Error(err)
@@ -348,3 +348,91 @@ func TestConvertValue(t *testing.T) {
 	is.Error(err)
 
 }
+
+func TestConvertValue_ArrayAndObject(t *testing.T) {
+	arr := Schema{Type: "array", Items: &Schema{Type: "string"}}
+	out, err := arr.ConvertValue(`["a", "b"]`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, out)
+
+	_, err = arr.ConvertValue(`["a", 1]`)
+	require.Error(t, err, "the items should be validated")
+	assert.Contains(t, err.Error(), "/1: type should be string")
+
+	obj := Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"port": {Type: "integer"}},
+		Required:   []string{"port"},
+	}
+	out, err = obj.ConvertValue(`{"port": 8080, "host": "localhost"}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"port": 8080.0, "host": "localhost"}, out)
+
+	_, err = obj.ConvertValue(`{"host": "localhost"}`)
+	require.Error(t, err, "the object should be validated")
+	assert.Contains(t, err.Error(), `"port" value is required`)
+
+	_, err = obj.ConvertValue(`null`)
+	assert.Error(t, err, "null is not an object")
+}
+
+func TestConvertValue_MultipleTypes(t *testing.T) {
+	min := 10
+	testcases := []struct {
+		name   string
+		schema Schema
+		value  string
+		want   interface{}
+	}{
+		{"integer before string", Schema{Type: []interface{}{"string", "integer"}}, "1", 1},
+		{"string fallback", Schema{Type: []interface{}{"string", "integer"}}, "one", "one"},
+		{"number before string", Schema{Type: []interface{}{"string", "number"}}, "1.5", 1.5},
+		{"boolean", Schema{Type: []interface{}{"string", "boolean"}}, "true", true},
+		{"null", Schema{Type: []interface{}{"null", "string"}}, "null", nil},
+		{"array", Schema{Type: []interface{}{"string", "array"}}, `[1]`, []interface{}{1.0}},
+		{"invalid integer falls back to string", Schema{Type: []interface{}{"integer", "string"}, Minimum: &min}, "5", "5"},
+		{"no type", Schema{}, `{"a": true}`, map[string]interface{}{"a": true}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.schema.ConvertValue(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, out)
+		})
+	}
+
+	s := Schema{Type: []interface{}{"integer", "boolean"}}
+	_, err := s.ConvertValue("one")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unable to convert "one" to any of the types [boolean integer]`)
+}
+
+func TestConvertValue_Validation(t *testing.T) {
+	max := 3
+	s := Schema{Type: "string", MaxLength: &max}
+	_, err := s.ConvertValue("abcd")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max length of 3 characters exceeded")
+
+	s = Schema{Type: "integer", Enum: []interface{}{1, 2}}
+	_, err = s.ConvertValue("3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "should be one of")
+}
+
+func TestConvertValue_ContentEncoding(t *testing.T) {
+	s := Schema{Type: "string", ContentEncoding: "base64"}
+	out, err := s.ConvertValue("aGVsbG8gd29ybGQ=")
+	require.NoError(t, err)
+	assert.Equal(t, "aGVsbG8gd29ybGQ=", out, "encoded content should not be changed")
+
+	_, err = s.ConvertValue("hello world")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid base64 value: hello world")
+
+	s.ContentEncoding = "base32"
+	_, err = s.ConvertValue("NBSWY3DP")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported or invalid contentEncoding type of base32")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/pkg/errors"
+	"github.com/qri-io/jsonschema"
 )
 
 // ValidationError error represents a validation error
@@ -12,12 +13,106 @@ import (
 type ValidationError struct {
 	Path  string
 	Error string
+
+	// Keyword is the JSON Schema keyword that failed validation, for example
+	// "minimum", or empty when it cannot be determined.
+	Keyword string
+
+	// Value that failed validation.
+	Value interface{}
+}
+
+// keywordValidator records the keyword of a validator in the errors that it
+// reports, in their RulePath, because the validation library only describes
+// them with a message.
+type keywordValidator struct {
+	keyword   string
+	validator jsonschema.Validator
+}
+
+// Validate implements the Validator interface. Errors reported by the
+// validators of nested schemas already have the keyword of those validators.
+func (v keywordValidator) Validate(propPath string, data interface{}, errs *[]jsonschema.ValError) {
+	start := len(*errs)
+	v.validator.Validate(propPath, data, errs)
+	for i := start; i < len(*errs); i++ {
+		if (*errs)[i].RulePath == "" {
+			(*errs)[i].RulePath = v.keyword
+		}
+	}
+}
+
+// recordKeywords wraps the validators of the schema, and of all its nested
+// schemas, so that their errors identify the keyword that failed validation.
+func recordKeywords(root *jsonschema.RootSchema) {
+	// Find every schema first, because the wrapped validators cannot be traversed
+	var schemas []*jsonschema.Schema
+	var walk func(elem jsonschema.JSONPather)
+	walk = func(elem jsonschema.JSONPather) {
+		if s := asSchema(elem); s != nil {
+			schemas = append(schemas, s)
+		}
+		if c, ok := elem.(jsonschema.JSONContainer); ok {
+			for _, child := range c.JSONChildren() {
+				walk(child)
+			}
+		}
+	}
+	walk(&root.Schema)
+
+	for _, s := range schemas {
+		for keyword, v := range s.Validators {
+			if _, ok := v.(keywordValidator); !ok {
+				s.Validators[keyword] = keywordValidator{keyword: keyword, validator: v}
+			}
+		}
+	}
+}
+
+// asSchema returns the schema of a keyword whose value is a schema, such as
+// not, or nil when the element is not a schema.
+func asSchema(elem jsonschema.JSONPather) *jsonschema.Schema {
+	switch v := elem.(type) {
+	case *jsonschema.Schema:
+		return v
+	case *jsonschema.Not:
+		return (*jsonschema.Schema)(v)
+	case *jsonschema.Contains:
+		return (*jsonschema.Schema)(v)
+	case *jsonschema.PropertyNames:
+		return (*jsonschema.Schema)(v)
+	case *jsonschema.If:
+		return &v.Schema
+	case *jsonschema.Then:
+		return (*jsonschema.Schema)(v)
+	case *jsonschema.Else:
+		return (*jsonschema.Schema)(v)
+	case *jsonschema.AdditionalItems:
+		return v.Schema
+	case *jsonschema.AdditionalProperties:
+		return v.Schema
+	default:
+		return nil
+	}
 }
 
 // Validate applies JSON Schema validation to the data passed as a parameter.
 // If validation errors occur, they will be returned in as a slice of ValidationError
 // structs. If any other error occurs, it will be returned as a separate error
+//
+// References to the schema's own definitions are resolved, including recursive
+// references. To resolve references to the definitions of a bundle, dereference
+// the schema with the bundle's Definitions first.
 func (s *Schema) Validate(data interface{}) ([]ValidationError, error) {
+	s, recursive, err := s.dereference()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to resolve schema")
+	}
+	// The definitions were inlined where they are referenced, except for
+	// recursive references, which are resolved by the validator.
+	if !recursive {
+		s.Definitions = nil
+	}
 
 	b, err := json.Marshal(s)
 	if err != nil {
@@ -28,6 +123,7 @@ func (s *Schema) Validate(data interface{}) ([]ValidationError, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to build schema")
 	}
+	recordKeywords(def)
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to process data")
@@ -41,8 +137,10 @@ func (s *Schema) Validate(data interface{}) ([]ValidationError, error) {
 
 		for _, err := range valErrs {
 			valError := ValidationError{
-				Path:  err.PropertyPath,
-				Error: err.Message,
+				Path:    err.PropertyPath,
+				Error:   err.Message,
+				Keyword: err.RulePath,
+				Value:   err.InvalidValue,
 			}
 			valErrors = append(valErrors, valError)
 		}
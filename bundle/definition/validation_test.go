@@ -278,3 +278,85 @@ func TestObjectValidationAdditionalPropertiesAreStrings(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "type should be string", valErrors[0].Error)
 }
+
+func TestValidationKeyword(t *testing.T) {
+	max := 2
+	min := 10
+	testcases := []struct {
+		name    string
+		schema  Schema
+		data    interface{}
+		keyword string
+	}{
+		{"type", Schema{Type: "integer"}, "ten", "type"},
+		{"minimum", Schema{Type: "integer", Minimum: &min}, 5, "minimum"},
+		{"exclusiveMinimum", Schema{Type: "integer", ExclusiveMinimum: &min}, 10, "exclusiveMinimum"},
+		{"maxLength", Schema{Type: "string", MaxLength: &max}, "abc", "maxLength"},
+		{"enum", Schema{Type: "string", Enum: []interface{}{"a", "b"}}, "c", "enum"},
+		{"required", Schema{Type: "object", Required: []string{"port"}}, map[string]interface{}{}, "required"},
+		{"contentEncoding", Schema{Type: "string", ContentEncoding: "base64"}, "not base64!", "contentEncoding"},
+		{"not", Schema{Not: &Schema{Type: "string"}}, "abc", "not"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			valErrs, err := tc.schema.Validate(tc.data)
+			require.NoError(t, err)
+			require.Len(t, valErrs, 1)
+			assert.Equal(t, tc.keyword, valErrs[0].Keyword, valErrs[0].Error)
+			assert.EqualValues(t, tc.data, valErrs[0].Value, "the value should be the decoded JSON value")
+		})
+	}
+}
+
+func TestValidationKeyword_Nested(t *testing.T) {
+	testcases := []struct {
+		name    string
+		schema  string
+		data    interface{}
+		path    string
+		keyword string
+	}{
+		{
+			name:    "items",
+			schema:  `{"type": "object", "properties": {"ports": {"type": "array", "items": {"type": "integer", "maximum": 10}}}}`,
+			data:    map[string]interface{}{"ports": []interface{}{5, 20}},
+			path:    "/ports/1",
+			keyword: "maximum",
+		},
+		{
+			name:    "not",
+			schema:  `{"type": "object", "properties": {"name": {"type": "string", "not": {"enum": ["root"]}}}}`,
+			data:    map[string]interface{}{"name": "root"},
+			path:    "/name",
+			keyword: "not",
+		},
+		{
+			name:    "additionalProperties",
+			schema:  `{"type": "object", "additionalProperties": {"type": "string", "minLength": 3}}`,
+			data:    map[string]interface{}{"name": "ab"},
+			path:    "/name",
+			keyword: "minLength",
+		},
+		{
+			name:    "ref",
+			schema:  `{"type": "object", "properties": {"port": {"$ref": "#/definitions/port"}}, "definitions": {"port": {"type": "integer", "minimum": 10}}}`,
+			data:    map[string]interface{}{"port": 5},
+			path:    "/port",
+			keyword: "minimum",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := new(Schema)
+			require.NoError(t, json.Unmarshal([]byte(tc.schema), s))
+
+			valErrs, err := s.Validate(tc.data)
+			require.NoError(t, err)
+			require.Len(t, valErrs, 1)
+			assert.Equal(t, tc.path, valErrs[0].Path)
+			assert.Equal(t, tc.keyword, valErrs[0].Keyword, valErrs[0].Error)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package definition
+
+import (
+	"encoding/json"
+
+	"github.com/cnabio/cnab-go/utils/yamlconv"
+)
+
+// UnmarshalYAML provides an implementation of a YAML unmarshaler that validates
+// the schema the same way as UnmarshalJSON. The values that are not schemas,
+// such as the default, are unmarshaled with string keys for their maps, the
+// same as they are from JSON, so that they can be validated and marshaled
+// to JSON.
+func (s *Schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(yamlconv.JSONValue(raw))
+	if err != nil {
+		return err
+	}
+	js := NewRootSchema()
+	if err := js.UnmarshalJSON(withoutRefs(data)); err != nil {
+		return err
+	}
+
+	type wrapperType Schema
+	if err := unmarshal((*wrapperType)(s)); err != nil {
+		return err
+	}
+	s.AdditionalItems = yamlconv.JSONValue(s.AdditionalItems)
+	s.AdditionalProperties = yamlconv.JSONValue(s.AdditionalProperties)
+	s.Const = yamlconv.JSONValue(s.Const)
+	s.Default = yamlconv.JSONValue(s.Default)
+	s.Items = yamlconv.JSONValue(s.Items)
+	s.Type = yamlconv.JSONValue(s.Type)
+	for key, value := range s.Dependencies {
+		s.Dependencies[key] = yamlconv.JSONValue(value)
+	}
+	for i, value := range s.Enum {
+		s.Enum[i] = yamlconv.JSONValue(value)
+	}
+	for i, value := range s.Examples {
+		s.Examples[i] = yamlconv.JSONValue(value)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSchema_UnmarshalYAML(t *testing.T) {
+	data := `
+type: object
+properties:
+  port:
+    type: integer
+    default: 8080
+  tags:
+    type: array
+    items:
+      type: string
+      enum: [web, db]
+default:
+  port: 80
+  labels:
+    app: web
+additionalProperties:
+  type: string
+`
+	s := &Schema{}
+	err := yaml.UnmarshalStrict([]byte(data), s)
+	require.NoError(t, err)
+
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, 8080, s.Properties["port"].Default)
+	assert.Equal(t, map[string]interface{}{"port": 80, "labels": map[string]interface{}{"app": "web"}}, s.Default)
+	assert.Equal(t, map[string]interface{}{"type": "string"}, s.AdditionalProperties)
+	assert.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"web", "db"}}, s.Properties["tags"].Items)
+
+	valErrs, err := s.Validate(map[string]interface{}{"port": 80, "tags": []interface{}{"web"}})
+	require.NoError(t, err)
+	assert.Empty(t, valErrs)
+}
+
+func TestSchema_UnmarshalYAML_Invalid(t *testing.T) {
+	s := &Schema{}
+	err := yaml.Unmarshal([]byte("type: 1"), s)
+	assert.Error(t, err, "the schema should be validated")
+
+	err = yaml.UnmarshalStrict([]byte("type: string\nmaxLenght: 10"), s)
+	assert.Error(t, err, "unknown keywords should not be allowed when unmarshaling strictly")
+}
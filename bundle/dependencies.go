@@ -0,0 +1,146 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+)
+
+// DependenciesExtensionKey represents the full key for the Dependencies Extension
+const DependenciesExtensionKey = "io.cnab.dependencies"
+
+// Dependencies describes the set of custom extension metadata associated with the dependencies spec
+// https://github.com/cnabio/cnab-spec/blob/master/500-CNAB-dependencies.md
+type Dependencies struct {
+	// Sequence is a list to order the dependencies, by their name in Requires.
+	// Dependencies that are not listed are ordered by name after them.
+	Sequence []string `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+
+	// Requires is a map of dependencies, keyed by a name that is unique within the bundle.
+	Requires map[string]Dependency `json:"requires,omitempty" yaml:"requires,omitempty"`
+}
+
+// Dependency describes a dependency on another bundle
+type Dependency struct {
+	// Bundle is the location of the bundle, for example a registry reference.
+	Bundle string `json:"bundle" yaml:"bundle"`
+
+	// Version is the range of versions of the bundle that are allowed.
+	Version *DependencyVersion `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// DependencyVersion is a set of allowed versions of a dependency
+type DependencyVersion struct {
+	// Ranges of semantic versions, for example "1.x - 2" or ">=1.2.3". A version
+	// is allowed when it is in any of the ranges.
+	Ranges []string `json:"ranges,omitempty" yaml:"ranges,omitempty"`
+
+	// AllowPrereleases allows prerelease versions, such as 1.2.3-beta.1, when
+	// the version without the prerelease is in a range.
+	AllowPrereleases bool `json:"prereleases,omitempty" yaml:"prereleases,omitempty"`
+}
+
+// ReadDependencies reads the dependencies extension from the Custom section of
+// the bundle. The returned boolean is false when the bundle does not have
+// dependencies.
+func (b Bundle) ReadDependencies() (Dependencies, bool, error) {
+	var deps Dependencies
+
+	data, ok := b.Custom[DependenciesExtensionKey]
+	if !ok {
+		return deps, false, nil
+	}
+
+	dataB, err := json.Marshal(data)
+	if err != nil {
+		return deps, true, errors.Wrapf(err, "could not marshal the %s extension", DependenciesExtensionKey)
+	}
+	err = json.Unmarshal(dataB, &deps)
+	if err != nil {
+		return deps, true, errors.Wrapf(err, "could not unmarshal the %s extension", DependenciesExtensionKey)
+	}
+	return deps, true, nil
+}
+
+// Validate the dependencies.
+func (d Dependencies) Validate() error {
+	for name, dep := range d.Requires {
+		if err := dep.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid dependency %s", name)
+		}
+	}
+
+	seen := make(map[string]bool, len(d.Sequence))
+	for _, name := range d.Sequence {
+		if _, ok := d.Requires[name]; !ok {
+			return fmt.Errorf("dependency %s is in the sequence but it is not required", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("dependency %s is in the sequence more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// Validate the dependency.
+func (d Dependency) Validate() error {
+	if d.Bundle == "" {
+		return errors.New("bundle must be set")
+	}
+	if d.Version != nil {
+		return d.Version.Validate()
+	}
+	return nil
+}
+
+// Validate that the ranges are semantic version ranges.
+func (v DependencyVersion) Validate() error {
+	_, err := v.constraints()
+	return err
+}
+
+// Allows returns true when the version is allowed by the ranges. Every
+// version is allowed when there are no ranges.
+func (v DependencyVersion) Allows(version string) (bool, error) {
+	constraints, err := v.constraints()
+	if err != nil {
+		return false, err
+	}
+
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid version %q", version)
+	}
+	if v.AllowPrereleases && ver.Prerelease() != "" {
+		release, err := ver.SetPrerelease("")
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid version %q", version)
+		}
+		ver = &release
+	}
+
+	if len(constraints) == 0 {
+		return v.AllowPrereleases || ver.Prerelease() == "", nil
+	}
+	for _, c := range constraints {
+		if c.Check(ver) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (v DependencyVersion) constraints() ([]*semver.Constraints, error) {
+	constraints := make([]*semver.Constraints, 0, len(v.Ranges))
+	for _, r := range v.Ranges {
+		c, err := semver.NewConstraint(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version range %q", r)
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
@@ -0,0 +1,176 @@
+// Package dependencies resolves the bundles that a bundle depends upon with the
+// io.cnab.dependencies extension, and the order to install them in.
+package dependencies
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/loader"
+)
+
+// ErrCircularDependency represents bundles that depend upon each other, so they
+// cannot be installed in order.
+var ErrCircularDependency = errors.New("circular dependency")
+
+// Node is a bundle in the dependency graph.
+type Node struct {
+	// Reference to the bundle, from the bundle field of the dependency that
+	// requires it. It is empty for the root bundle.
+	Reference string
+
+	// Bundle that was loaded.
+	Bundle *bundle.Bundle
+
+	// Dependencies of the bundle, keyed by the name that the bundle uses for
+	// them.
+	Dependencies map[string]*Node
+}
+
+// Graph of a bundle and the bundles it depends upon, directly or indirectly.
+type Graph struct {
+	// Root is the bundle that was resolved.
+	Root *Node
+
+	// Nodes are the dependencies, keyed by reference. A bundle that is
+	// required by several bundles is only loaded and installed once.
+	Nodes map[string]*Node
+
+	// InstallOrder lists every bundle after the bundles it depends upon, so the
+	// root bundle is last. Uninstall in the reverse order.
+	InstallOrder []*Node
+}
+
+// Resolver loads the dependencies of bundles.
+type Resolver struct {
+	// Loader loads a dependency from the reference in its bundle field.
+	Loader loader.BundleLoader
+}
+
+// NewResolver creates a Resolver that loads dependencies with the loader.
+func NewResolver(l loader.BundleLoader) *Resolver {
+	return &Resolver{
+		Loader: l,
+	}
+}
+
+// Resolve the dependencies of the root bundle, and the order to install them.
+//
+// Dependencies are visited in the order of the bundle's sequence, and then by
+// name. Each dependency is checked against the allowed version ranges, and
+// ErrCircularDependency is returned when bundles depend upon each other.
+func (r *Resolver) Resolve(root *bundle.Bundle) (*Graph, error) {
+	g := &Graph{
+		Nodes: make(map[string]*Node),
+	}
+	res := resolution{
+		Resolver: r,
+		graph:    g,
+		visiting: make(map[string]bool),
+	}
+
+	g.Root = &Node{Bundle: root}
+	if err := res.visit(g.Root, nil); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// resolution holds the state of resolving a graph.
+type resolution struct {
+	*Resolver
+	graph *Graph
+
+	// visiting are the references of the bundles whose dependencies are
+	// being resolved, which would be a cycle if they are required again.
+	visiting map[string]bool
+}
+
+// visit resolves the dependencies of a node, which are added to the install
+// order before it. The path contains the references that required the node.
+func (r *resolution) visit(n *Node, path []string) error {
+	r.visiting[n.Reference] = true
+	defer delete(r.visiting, n.Reference)
+
+	deps, _, err := n.Bundle.ReadDependencies()
+	if err != nil {
+		return errors.Wrapf(err, "could not read the dependencies of bundle %s", n.Bundle.Name)
+	}
+	if err := deps.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid dependencies in bundle %s", n.Bundle.Name)
+	}
+
+	n.Dependencies = make(map[string]*Node, len(deps.Requires))
+	for _, name := range orderedNames(deps) {
+		dep := deps.Requires[name]
+		ref := dep.Bundle
+
+		if r.visiting[ref] {
+			chain := append(append(path, n.Reference), ref)
+			return errors.Wrapf(ErrCircularDependency, "%s", strings.Join(chain[1:], " -> "))
+		}
+
+		depNode, ok := r.graph.Nodes[ref]
+		if !ok {
+			b, err := r.Loader.Load(ref)
+			if err != nil {
+				return errors.Wrapf(err, "could not load dependency %s of bundle %s from %s", name, n.Bundle.Name, ref)
+			}
+			depNode = &Node{Reference: ref, Bundle: b}
+			r.graph.Nodes[ref] = depNode
+
+			if err := r.visit(depNode, append(path, n.Reference)); err != nil {
+				return err
+			}
+		}
+
+		if err := checkVersion(dep, depNode.Bundle); err != nil {
+			return errors.Wrapf(err, "dependency %s of bundle %s", name, n.Bundle.Name)
+		}
+		n.Dependencies[name] = depNode
+	}
+
+	r.graph.InstallOrder = append(r.graph.InstallOrder, n)
+	return nil
+}
+
+// orderedNames returns the names of the dependencies in the order of the
+// sequence, followed by the remaining dependencies sorted by name.
+func orderedNames(deps bundle.Dependencies) []string {
+	names := make([]string, 0, len(deps.Requires))
+	seen := make(map[string]bool, len(deps.Sequence))
+	for _, name := range deps.Sequence {
+		names = append(names, name)
+		seen[name] = true
+	}
+
+	var remaining []string
+	for name := range deps.Requires {
+		if !seen[name] {
+			remaining = append(remaining, name)
+		}
+	}
+	sort.Strings(remaining)
+	return append(names, remaining...)
+}
+
+// checkVersion checks that the version of the bundle is allowed by the
+// dependency.
+func checkVersion(dep bundle.Dependency, b *bundle.Bundle) error {
+	if dep.Version == nil {
+		return nil
+	}
+
+	ok, err := dep.Version.Allows(b.Version)
+	if err != nil {
+		return errors.Wrapf(err, "could not check the version of %s", dep.Bundle)
+	}
+	if !ok {
+		return fmt.Errorf("version %s of %s is not in the allowed ranges %s", b.Version, dep.Bundle, strings.Join(dep.Version.Ranges, ", "))
+	}
+	return nil
+}
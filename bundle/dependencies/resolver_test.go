@@ -0,0 +1,142 @@
+package dependencies
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/loader"
+)
+
+var _ loader.BundleLoader = testLoader{}
+
+// testLoader loads bundles from memory, keyed by reference.
+type testLoader map[string]*bundle.Bundle
+
+func (l testLoader) Load(source string) (*bundle.Bundle, error) {
+	b, ok := l[source]
+	if !ok {
+		return nil, fmt.Errorf("bundle %s not found", source)
+	}
+	return b, nil
+}
+
+func (l testLoader) LoadData(data []byte) (*bundle.Bundle, error) {
+	return bundle.Unmarshal(data)
+}
+
+func newBundle(name string, version string, deps map[string]interface{}, sequence ...string) *bundle.Bundle {
+	b := &bundle.Bundle{Name: name, Version: version}
+	if deps != nil {
+		ext := map[string]interface{}{"requires": deps}
+		if len(sequence) > 0 {
+			ext["sequence"] = sequence
+		}
+		b.Custom = map[string]interface{}{bundle.DependenciesExtensionKey: ext}
+	}
+	return b
+}
+
+func requires(ref string, ranges ...string) map[string]interface{} {
+	dep := map[string]interface{}{"bundle": ref}
+	if len(ranges) > 0 {
+		dep["version"] = map[string]interface{}{"ranges": ranges}
+	}
+	return dep
+}
+
+func installOrder(g *Graph) []string {
+	var names []string
+	for _, n := range g.InstallOrder {
+		names = append(names, n.Bundle.Name)
+	}
+	return names
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	l := testLoader{
+		"example.com/mysql:5.7": newBundle("mysql", "5.7.1", nil),
+		"example.com/storage:1.2": newBundle("storage", "1.2.0", map[string]interface{}{
+			"db": requires("example.com/mysql:5.7", "5.x"),
+		}),
+		"example.com/cache:1.0": newBundle("cache", "1.0.0", map[string]interface{}{
+			"db": requires("example.com/mysql:5.7"),
+		}),
+	}
+	root := newBundle("wordpress", "0.1.0", map[string]interface{}{
+		"mysql":   requires("example.com/mysql:5.7", ">=5.6"),
+		"storage": requires("example.com/storage:1.2", "1.x - 2"),
+		"cache":   requires("example.com/cache:1.0"),
+	}, "storage")
+
+	g, err := NewResolver(l).Resolve(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"mysql", "storage", "cache", "wordpress"}, installOrder(g),
+		"the sequence should be used first, then the remaining dependencies sorted by name")
+	assert.Equal(t, root, g.Root.Bundle)
+	assert.Len(t, g.Nodes, 3, "a bundle required more than once should only be loaded once")
+	assert.Same(t, g.Nodes["example.com/mysql:5.7"], g.Root.Dependencies["mysql"])
+	assert.Same(t, g.Nodes["example.com/mysql:5.7"], g.Nodes["example.com/storage:1.2"].Dependencies["db"])
+}
+
+func TestResolver_Resolve_NoDependencies(t *testing.T) {
+	root := newBundle("wordpress", "0.1.0", nil)
+
+	g, err := NewResolver(testLoader{}).Resolve(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wordpress"}, installOrder(g))
+	assert.Empty(t, g.Nodes)
+}
+
+func TestResolver_Resolve_Cycle(t *testing.T) {
+	l := testLoader{
+		"example.com/a": newBundle("a", "1.0.0", map[string]interface{}{"b": requires("example.com/b")}),
+		"example.com/b": newBundle("b", "1.0.0", map[string]interface{}{"c": requires("example.com/c")}),
+		"example.com/c": newBundle("c", "1.0.0", map[string]interface{}{"a": requires("example.com/a")}),
+	}
+	root := newBundle("root", "1.0.0", map[string]interface{}{"a": requires("example.com/a")})
+
+	_, err := NewResolver(l).Resolve(root)
+	require.Error(t, err)
+	assert.Equal(t, ErrCircularDependency, errors.Cause(err))
+	assert.EqualError(t, err, "example.com/a -> example.com/b -> example.com/c -> example.com/a: circular dependency")
+}
+
+func TestResolver_Resolve_Version(t *testing.T) {
+	l := testLoader{
+		"example.com/mysql:8":    newBundle("mysql", "8.0.0", nil),
+		"example.com/mysql:beta": newBundle("mysql", "5.8.0-beta.1", nil),
+	}
+
+	root := newBundle("wordpress", "0.1.0", map[string]interface{}{
+		"mysql": requires("example.com/mysql:8", "5.x", "6.x"),
+	})
+	_, err := NewResolver(l).Resolve(root)
+	require.EqualError(t, err, "dependency mysql of bundle wordpress: version 8.0.0 of example.com/mysql:8 is not in the allowed ranges 5.x, 6.x")
+
+	root = newBundle("wordpress", "0.1.0", map[string]interface{}{
+		"mysql": requires("example.com/mysql:beta", "5.x"),
+	})
+	_, err = NewResolver(l).Resolve(root)
+	require.Error(t, err, "prereleases should not be allowed by default")
+
+	beta := requires("example.com/mysql:beta")
+	beta["version"] = map[string]interface{}{"ranges": []string{"5.x"}, "prereleases": true}
+	root = newBundle("wordpress", "0.1.0", map[string]interface{}{"mysql": beta})
+	_, err = NewResolver(l).Resolve(root)
+	require.NoError(t, err)
+}
+
+func TestResolver_Resolve_LoadError(t *testing.T) {
+	root := newBundle("wordpress", "0.1.0", map[string]interface{}{
+		"mysql": requires("example.com/mysql:5.7"),
+	})
+
+	_, err := NewResolver(testLoader{}).Resolve(root)
+	require.EqualError(t, err, "could not load dependency mysql of bundle wordpress from example.com/mysql:5.7: bundle example.com/mysql:5.7 not found")
+}
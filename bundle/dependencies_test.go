@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDependencies(t *testing.T) {
+	b := Bundle{
+		Custom: map[string]interface{}{
+			DependenciesExtensionKey: map[string]interface{}{
+				"sequence": []interface{}{"storage"},
+				"requires": map[string]interface{}{
+					"storage": map[string]interface{}{
+						"bundle": "somecloud/blob-storage",
+						"version": map[string]interface{}{
+							"prereleases": true,
+							"ranges":      []interface{}{"1.x - 2", "2.1 - 3.x"},
+						},
+					},
+					"mysql": map[string]interface{}{
+						"bundle": "somecloud/mysql:5.7",
+					},
+				},
+			},
+		},
+	}
+
+	deps, ok, err := b.ReadDependencies()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, Dependencies{
+		Sequence: []string{"storage"},
+		Requires: map[string]Dependency{
+			"storage": {
+				Bundle:  "somecloud/blob-storage",
+				Version: &DependencyVersion{Ranges: []string{"1.x - 2", "2.1 - 3.x"}, AllowPrereleases: true},
+			},
+			"mysql": {Bundle: "somecloud/mysql:5.7"},
+		},
+	}, deps)
+	assert.NoError(t, deps.Validate())
+
+	_, ok, err = Bundle{}.ReadDependencies()
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDependencies_Validate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		deps    Dependencies
+		wantErr string
+	}{
+		{
+			name:    "missing bundle",
+			deps:    Dependencies{Requires: map[string]Dependency{"mysql": {}}},
+			wantErr: "invalid dependency mysql: bundle must be set",
+		},
+		{
+			name: "invalid range",
+			deps: Dependencies{Requires: map[string]Dependency{
+				"mysql": {Bundle: "mysql", Version: &DependencyVersion{Ranges: []string{"not a range"}}},
+			}},
+			wantErr: `invalid dependency mysql: invalid version range "not a range"`,
+		},
+		{
+			name:    "unknown sequence",
+			deps:    Dependencies{Sequence: []string{"redis"}, Requires: map[string]Dependency{"mysql": {Bundle: "mysql"}}},
+			wantErr: "dependency redis is in the sequence but it is not required",
+		},
+		{
+			name:    "duplicate sequence",
+			deps:    Dependencies{Sequence: []string{"mysql", "mysql"}, Requires: map[string]Dependency{"mysql": {Bundle: "mysql"}}},
+			wantErr: "dependency mysql is in the sequence more than once",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.deps.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestDependencyVersion_Allows(t *testing.T) {
+	testcases := []struct {
+		version string
+		ranges  []string
+		pre     bool
+		want    bool
+	}{
+		{version: "1.5.0", ranges: []string{"1.x - 2"}, want: true},
+		{version: "3.0.0", ranges: []string{"1.x - 2"}, want: false},
+		{version: "3.0.0", ranges: []string{"1.x - 2", ">=3"}, want: true},
+		{version: "1.5.0-beta.1", ranges: []string{"1.x"}, want: false},
+		{version: "1.5.0-beta.1", ranges: []string{"1.x"}, pre: true, want: true},
+		{version: "1.5.0", want: true},
+		{version: "1.5.0-beta.1", want: false},
+	}
+
+	for _, tc := range testcases {
+		v := DependencyVersion{Ranges: tc.ranges, AllowPrereleases: tc.pre}
+		got, err := v.Allows(tc.version)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "version %s in %v (prereleases: %t)", tc.version, tc.ranges, tc.pre)
+	}
+
+	_, err := DependencyVersion{}.Allows("latest")
+	require.Error(t, err)
+}
+
+func TestValidateDependenciesExtension(t *testing.T) {
+	b := Bundle{
+		SchemaVersion:    "v1.0.0",
+		Version:          "0.1.0",
+		InvocationImages: []InvocationImage{{BaseImage: BaseImage{Image: "example.com/wordpress:0.1.0", ImageType: "docker"}}},
+		Custom: map[string]interface{}{
+			DependenciesExtensionKey: map[string]interface{}{
+				"requires": map[string]interface{}{
+					"mysql": map[string]interface{}{},
+				},
+			},
+		},
+	}
+
+	err := b.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid io.cnab.dependencies extension: invalid dependency mysql: bundle must be set")
+}
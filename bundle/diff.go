@@ -0,0 +1,533 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+// ChangeType is how an item changed between two versions of a bundle.
+type ChangeType string
+
+const (
+	// ChangeAdded is an item that is only in the new bundle.
+	ChangeAdded ChangeType = "added"
+
+	// ChangeRemoved is an item that is only in the old bundle.
+	ChangeRemoved ChangeType = "removed"
+
+	// ChangeModified is an item that is in both bundles, but is different.
+	ChangeModified ChangeType = "modified"
+)
+
+// ChangeKind is the kind of item in a bundle that changed.
+type ChangeKind string
+
+// The kinds of items that are compared, in the order they are reported.
+const (
+	KindParameter       ChangeKind = "parameter"
+	KindCredential      ChangeKind = "credential"
+	KindOutput          ChangeKind = "output"
+	KindDefinition      ChangeKind = "definition"
+	KindInvocationImage ChangeKind = "invocationImage"
+	KindImage           ChangeKind = "image"
+	KindAction          ChangeKind = "action"
+)
+
+var kindOrder = map[ChangeKind]int{
+	KindParameter:       0,
+	KindCredential:      1,
+	KindOutput:          2,
+	KindDefinition:      3,
+	KindInvocationImage: 4,
+	KindImage:           5,
+	KindAction:          6,
+}
+
+// Change is a difference between two versions of a bundle.
+type Change struct {
+	// Kind of item that changed.
+	Kind ChangeKind
+
+	// Name of the item, or the index of an invocation image.
+	Name string
+
+	// Type of change.
+	Type ChangeType
+
+	// Field that was modified, for example "required", or the JSON pointer
+	// to a keyword in a definition, such as "/properties/port/maximum". It is
+	// empty when the item was added or removed, or when a definition changed
+	// in a way that is not described by a keyword.
+	Field string
+
+	// Old value of the item or field, nil when it was added.
+	Old interface{}
+
+	// New value of the item or field, nil when it was removed.
+	New interface{}
+
+	// Breaking is true when the change may cause an upgrade to fail, for
+	// example a new required parameter without a default, or a definition
+	// that no longer allows values that it did before.
+	Breaking bool
+}
+
+// String describes the change, for example
+// "parameter port: maximum modified from 10 to 5 (breaking)".
+func (c Change) String() string {
+	var msg string
+	switch {
+	case c.Field == "":
+		msg = fmt.Sprintf("%s %s: %s", c.Kind, c.Name, c.Type)
+	case c.Type == ChangeModified:
+		msg = fmt.Sprintf("%s %s: %s %s from %s to %s", c.Kind, c.Name, strings.TrimPrefix(c.Field, "/"), c.Type, formatValue(c.Old), formatValue(c.New))
+	default:
+		msg = fmt.Sprintf("%s %s: %s %s", c.Kind, c.Name, strings.TrimPrefix(c.Field, "/"), c.Type)
+	}
+	if c.Breaking {
+		msg += " (breaking)"
+	}
+	return msg
+}
+
+// Diff is the list of changes between two versions of a bundle.
+type Diff []Change
+
+// Breaking returns the breaking changes.
+func (d Diff) Breaking() Diff {
+	var breaking Diff
+	for _, c := range d {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// HasBreakingChanges returns true when any of the changes are breaking.
+func (d Diff) HasBreakingChanges() bool {
+	return len(d.Breaking()) > 0
+}
+
+// Compare an old and a new version of a bundle, and return the changes to the
+// parameters, credentials, outputs, definitions, images and actions, sorted
+// by kind, name and field.
+func Compare(old Bundle, updated Bundle) Diff {
+	d := &differ{old: old, updated: updated}
+	d.compareParameters()
+	d.compareCredentials()
+	d.compareOutputs()
+	d.compareDefinitions()
+	d.compareInvocationImages()
+	d.compareImages()
+	d.compareActions()
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Field < b.Field
+	})
+	return d.changes
+}
+
+type differ struct {
+	old     Bundle
+	updated Bundle
+	changes Diff
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// modified records the change to a field of an item, when it changed.
+func (d *differ) modified(kind ChangeKind, name string, field string, old interface{}, updated interface{}, breaking bool) {
+	if reflect.DeepEqual(old, updated) {
+		return
+	}
+	d.add(Change{Kind: kind, Name: name, Type: ChangeModified, Field: field, Old: old, New: updated, Breaking: breaking})
+}
+
+func (d *differ) compareParameters() {
+	for _, name := range unionKeys(d.old.Parameters, d.updated.Parameters) {
+		oldParam, inOld := d.old.Parameters[name]
+		newParam, inNew := d.updated.Parameters[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindParameter, Name: name, Type: ChangeAdded, New: newParam,
+				Breaking: newParam.Required && !hasDefault(d.updated, newParam.Definition)})
+		case !inNew:
+			d.add(Change{Kind: KindParameter, Name: name, Type: ChangeRemoved, Old: oldParam})
+		default:
+			d.modified(KindParameter, name, "required", oldParam.Required, newParam.Required,
+				newParam.Required && !hasDefault(d.updated, newParam.Definition))
+			d.modified(KindParameter, name, "applyTo", oldParam.ApplyTo, newParam.ApplyTo, false)
+			d.modified(KindParameter, name, "destination", oldParam.Destination, newParam.Destination, false)
+
+			// The definition may be renamed without changing the schema, so
+			// it is only breaking when the schema is.
+			if oldParam.Definition != newParam.Definition {
+				changes := compareSchemas(effectiveSchema(d.old, oldParam.Definition), effectiveSchema(d.updated, newParam.Definition), "")
+				d.modified(KindParameter, name, "definition", oldParam.Definition, newParam.Definition, isBreaking(changes))
+			}
+		}
+	}
+}
+
+func (d *differ) compareCredentials() {
+	for _, name := range unionKeys(d.old.Credentials, d.updated.Credentials) {
+		oldCred, inOld := d.old.Credentials[name]
+		newCred, inNew := d.updated.Credentials[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindCredential, Name: name, Type: ChangeAdded, New: newCred, Breaking: newCred.Required})
+		case !inNew:
+			d.add(Change{Kind: KindCredential, Name: name, Type: ChangeRemoved, Old: oldCred})
+		default:
+			d.modified(KindCredential, name, "required", oldCred.Required, newCred.Required, newCred.Required)
+			d.modified(KindCredential, name, "path", oldCred.Path, newCred.Path, false)
+			d.modified(KindCredential, name, "env", oldCred.EnvironmentVariable, newCred.EnvironmentVariable, false)
+		}
+	}
+}
+
+func (d *differ) compareOutputs() {
+	for _, name := range unionKeys(d.old.Outputs, d.updated.Outputs) {
+		oldOutput, inOld := d.old.Outputs[name]
+		newOutput, inNew := d.updated.Outputs[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindOutput, Name: name, Type: ChangeAdded, New: newOutput})
+		case !inNew:
+			// Parameter sources and dependent bundles may use the output.
+			d.add(Change{Kind: KindOutput, Name: name, Type: ChangeRemoved, Old: oldOutput, Breaking: true})
+		default:
+			d.modified(KindOutput, name, "definition", oldOutput.Definition, newOutput.Definition, false)
+			d.modified(KindOutput, name, "applyTo", oldOutput.ApplyTo, newOutput.ApplyTo, false)
+			d.modified(KindOutput, name, "path", oldOutput.Path, newOutput.Path, false)
+		}
+	}
+}
+
+func (d *differ) compareDefinitions() {
+	for _, name := range unionKeys(d.old.Definitions, d.updated.Definitions) {
+		oldDef, inOld := d.old.Definitions[name]
+		newDef, inNew := d.updated.Definitions[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindDefinition, Name: name, Type: ChangeAdded, New: newDef})
+		case !inNew:
+			d.add(Change{Kind: KindDefinition, Name: name, Type: ChangeRemoved, Old: oldDef})
+		default:
+			for _, c := range compareSchemas(effectiveSchema(d.old, name), effectiveSchema(d.updated, name), "") {
+				c.Kind = KindDefinition
+				c.Name = name
+				d.add(c)
+			}
+		}
+	}
+}
+
+func (d *differ) compareInvocationImages() {
+	count := len(d.old.InvocationImages)
+	if len(d.updated.InvocationImages) > count {
+		count = len(d.updated.InvocationImages)
+	}
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%d", i)
+		switch {
+		case i >= len(d.old.InvocationImages):
+			d.add(Change{Kind: KindInvocationImage, Name: name, Type: ChangeAdded, New: d.updated.InvocationImages[i]})
+		case i >= len(d.updated.InvocationImages):
+			d.add(Change{Kind: KindInvocationImage, Name: name, Type: ChangeRemoved, Old: d.old.InvocationImages[i]})
+		default:
+			d.compareBaseImages(KindInvocationImage, name, d.old.InvocationImages[i].BaseImage, d.updated.InvocationImages[i].BaseImage)
+		}
+	}
+}
+
+func (d *differ) compareImages() {
+	for _, name := range unionKeys(d.old.Images, d.updated.Images) {
+		oldImg, inOld := d.old.Images[name]
+		newImg, inNew := d.updated.Images[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindImage, Name: name, Type: ChangeAdded, New: newImg})
+		case !inNew:
+			d.add(Change{Kind: KindImage, Name: name, Type: ChangeRemoved, Old: oldImg})
+		default:
+			d.compareBaseImages(KindImage, name, oldImg.BaseImage, newImg.BaseImage)
+		}
+	}
+}
+
+func (d *differ) compareBaseImages(kind ChangeKind, name string, old BaseImage, updated BaseImage) {
+	d.modified(kind, name, "image", old.Image, updated.Image, false)
+	d.modified(kind, name, "imageType", old.ImageType, updated.ImageType, false)
+	d.modified(kind, name, "contentDigest", old.Digest, updated.Digest, false)
+}
+
+func (d *differ) compareActions() {
+	for _, name := range unionKeys(d.old.Actions, d.updated.Actions) {
+		oldAction, inOld := d.old.Actions[name]
+		newAction, inNew := d.updated.Actions[name]
+		switch {
+		case !inOld:
+			d.add(Change{Kind: KindAction, Name: name, Type: ChangeAdded, New: newAction})
+		case !inNew:
+			d.add(Change{Kind: KindAction, Name: name, Type: ChangeRemoved, Old: oldAction, Breaking: true})
+		default:
+			d.modified(KindAction, name, "modifies", oldAction.Modifies, newAction.Modifies, false)
+			d.modified(KindAction, name, "stateless", oldAction.Stateless, newAction.Stateless, false)
+		}
+	}
+}
+
+// compareSchemas returns the changes to the keywords of a schema, and whether
+// they stop values that were valid from being valid. The path is the JSON
+// pointer to the schema.
+func compareSchemas(old *definition.Schema, updated *definition.Schema, path string) Diff {
+	if old == nil || updated == nil {
+		if old == updated {
+			return nil
+		}
+		return Diff{{Type: ChangeModified, Field: path, Old: old, New: updated, Breaking: updated != nil}}
+	}
+
+	var changes Diff
+	modified := func(keyword string, oldVal interface{}, newVal interface{}, breaking bool) {
+		if !reflect.DeepEqual(oldVal, newVal) {
+			changes = append(changes, Change{Type: ChangeModified, Field: path + "/" + keyword, Old: oldVal, New: newVal, Breaking: breaking})
+		}
+	}
+
+	oldTypes, newTypes := schemaTypes(old), schemaTypes(updated)
+	modified("type", old.Type, updated.Type, !typesAllowed(oldTypes, newTypes))
+	modified("enum", old.Enum, updated.Enum, len(updated.Enum) > 0 && !valuesIn(old.Enum, updated.Enum))
+	modified("const", old.Const, updated.Const, updated.Const != nil)
+	modified("format", old.Format, updated.Format, updated.Format != "")
+	modified("default", old.Default, updated.Default, false)
+	modified("writeOnly", old.WriteOnly, updated.WriteOnly, false)
+	modified("contentEncoding", old.ContentEncoding, updated.ContentEncoding, true)
+
+	// Lower bounds break values when they are raised, and upper bounds when
+	// they are lowered.
+	modified("minimum", intValue(old.Minimum), intValue(updated.Minimum), raised(old.Minimum, updated.Minimum))
+	modified("exclusiveMinimum", intValue(old.ExclusiveMinimum), intValue(updated.ExclusiveMinimum), raised(old.ExclusiveMinimum, updated.ExclusiveMinimum))
+	modified("minLength", intValue(old.MinLength), intValue(updated.MinLength), raised(old.MinLength, updated.MinLength))
+	modified("minItems", intValue(old.MinItems), intValue(updated.MinItems), raised(old.MinItems, updated.MinItems))
+	modified("minProperties", intValue(old.MinProperties), intValue(updated.MinProperties), raised(old.MinProperties, updated.MinProperties))
+	modified("maximum", intValue(old.Maximum), intValue(updated.Maximum), lowered(old.Maximum, updated.Maximum))
+	modified("exclusiveMaximum", intValue(old.ExclusiveMaximum), intValue(updated.ExclusiveMaximum), lowered(old.ExclusiveMaximum, updated.ExclusiveMaximum))
+	modified("maxLength", intValue(old.MaxLength), intValue(updated.MaxLength), lowered(old.MaxLength, updated.MaxLength))
+	modified("multipleOf", intValue(old.MultipleOf), intValue(updated.MultipleOf), updated.MultipleOf != nil)
+
+	modified("required", old.Required, updated.Required, !stringsIn(updated.Required, old.Required))
+	modified("additionalProperties", old.AdditionalProperties, updated.AdditionalProperties, updated.AdditionalProperties == false)
+
+	for _, name := range unionKeys(old.Properties, updated.Properties) {
+		oldProp, newProp := old.Properties[name], updated.Properties[name]
+		propPath := path + "/properties/" + name
+		switch {
+		case oldProp == nil:
+			changes = append(changes, Change{Type: ChangeAdded, Field: propPath, New: newProp})
+		case newProp == nil:
+			changes = append(changes, Change{Type: ChangeRemoved, Field: propPath, Old: oldProp, Breaking: updated.AdditionalProperties == false})
+		default:
+			changes = append(changes, compareSchemas(oldProp, newProp, propPath)...)
+		}
+	}
+
+	oldItems, _ := toItemSchema(old.Items)
+	newItems, _ := toItemSchema(updated.Items)
+	changes = append(changes, compareSchemas(oldItems, newItems, path+"/items")...)
+
+	// Report changes to the keywords that are not compared, so that a
+	// change is never missed.
+	if len(changes) == 0 && !schemasEqual(old, updated) {
+		changes = append(changes, Change{Type: ChangeModified, Field: path, Old: old, New: updated})
+	}
+	return changes
+}
+
+// effectiveSchema returns the dereferenced definition from the bundle, or the
+// definition as-is when its references cannot be resolved.
+func effectiveSchema(b Bundle, name string) *definition.Schema {
+	s, ok := b.Definitions[name]
+	if !ok {
+		return nil
+	}
+	if deref, err := b.Definitions.Dereference(s); err == nil {
+		return deref
+	}
+	return s
+}
+
+func hasDefault(b Bundle, definitionName string) bool {
+	s := effectiveSchema(b, definitionName)
+	return s != nil && s.Default != nil
+}
+
+func isBreaking(changes Diff) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypes returns the types allowed by a schema, or nil when any type is
+// allowed.
+func schemaTypes(s *definition.Schema) []string {
+	if t, ok := s.Type.(string); ok {
+		return []string{t}
+	}
+	types, _, err := s.GetTypes()
+	if err != nil {
+		return nil
+	}
+	return types
+}
+
+// typesAllowed returns true when every old type is allowed by the new types.
+func typesAllowed(oldTypes []string, newTypes []string) bool {
+	if len(newTypes) == 0 {
+		return true
+	}
+	if len(oldTypes) == 0 {
+		return false
+	}
+	for _, t := range oldTypes {
+		if !stringsIn([]string{t}, newTypes) && !(t == "integer" && stringsIn([]string{"number"}, newTypes)) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesIn returns true when every value is in the allowed values. There are
+// unknown values when values is empty, so it returns false.
+func valuesIn(values []interface{}, allowed []interface{}) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		found := false
+		for _, a := range allowed {
+			if reflect.DeepEqual(v, a) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// stringsIn returns true when every value is in the allowed values.
+func stringsIn(values []string, allowed []string) bool {
+	for _, v := range values {
+		found := false
+		for _, a := range allowed {
+			if v == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func intValue(i *int) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+// raised returns true when a lower bound is added or increased.
+func raised(old *int, updated *int) bool {
+	return updated != nil && (old == nil || *updated > *old)
+}
+
+// lowered returns true when an upper bound is added or decreased.
+func lowered(old *int, updated *int) bool {
+	return updated != nil && (old == nil || *updated < *old)
+}
+
+// toItemSchema returns the schema of the items of an array, when it is a
+// single schema.
+func toItemSchema(items interface{}) (*definition.Schema, bool) {
+	switch i := items.(type) {
+	case *definition.Schema:
+		return i, true
+	case map[string]interface{}:
+		data, err := json.Marshal(i)
+		if err != nil {
+			return nil, false
+		}
+		s := &definition.Schema{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, false
+		}
+		return s, true
+	default:
+		return nil, false
+	}
+}
+
+func schemasEqual(a *definition.Schema, b *definition.Schema) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aData) == string(bData)
+}
+
+// unionKeys returns the keys of two maps, sorted.
+func unionKeys(a interface{}, b interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []interface{}{a, b} {
+		v := reflect.ValueOf(m)
+		if v.Kind() != reflect.Map {
+			continue
+		}
+		for _, k := range v.MapKeys() {
+			key := k.String()
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
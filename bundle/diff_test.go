@@ -0,0 +1,149 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func diffBundle() Bundle {
+	maxReplicas := 10
+	return Bundle{
+		Name:    "wordpress",
+		Version: "1.0.0",
+		InvocationImages: []InvocationImage{
+			{BaseImage: BaseImage{Image: "example.com/wordpress:1.0.0", ImageType: "docker", Digest: "sha256:aaa"}},
+		},
+		Images: map[string]Image{
+			"web": {BaseImage: BaseImage{Image: "example.com/web:1.0.0", ImageType: "docker", Digest: "sha256:bbb"}},
+		},
+		Definitions: map[string]*definition.Schema{
+			"port":     {Type: "integer", Default: 80},
+			"replicas": {Type: "integer", Maximum: &maxReplicas},
+			"color":    {Type: "string", Enum: []interface{}{"red", "blue"}},
+			"config": {
+				Type: "object",
+				Properties: map[string]*definition.Schema{
+					"debug": {Type: "boolean"},
+				},
+			},
+		},
+		Parameters: map[string]Parameter{
+			"port":     {Definition: "port", Destination: &Location{EnvironmentVariable: "PORT"}},
+			"replicas": {Definition: "replicas", Destination: &Location{EnvironmentVariable: "REPLICAS"}},
+			"color":    {Definition: "color", Destination: &Location{EnvironmentVariable: "COLOR"}},
+		},
+		Credentials: map[string]Credential{
+			"kubeconfig": {Location: Location{Path: "/root/.kube/config"}, Required: true},
+		},
+		Outputs: map[string]Output{
+			"url": {Definition: "port", Path: "/cnab/app/outputs/url"},
+		},
+		Actions: map[string]Action{
+			"logs": {Modifies: false},
+		},
+	}
+}
+
+func TestCompare_Equal(t *testing.T) {
+	d := Compare(diffBundle(), diffBundle())
+	assert.Empty(t, d)
+	assert.False(t, d.HasBreakingChanges())
+}
+
+func TestCompare(t *testing.T) {
+	old := diffBundle()
+	updated := diffBundle()
+
+	lowerMax := 5
+	updated.Definitions["replicas"] = &definition.Schema{Type: "integer", Maximum: &lowerMax}
+	updated.Definitions["color"] = &definition.Schema{Type: "string", Enum: []interface{}{"red", "blue", "green"}}
+	updated.Definitions["port"] = &definition.Schema{Type: "integer", Default: 8080}
+	updated.Definitions["name"] = &definition.Schema{Type: "string"}
+	updated.Definitions["config"] = &definition.Schema{
+		Type: "object",
+		Properties: map[string]*definition.Schema{
+			"debug": {Type: "string"},
+		},
+	}
+	updated.Parameters["name"] = Parameter{Definition: "name", Required: true, Destination: &Location{EnvironmentVariable: "NAME"}}
+	delete(updated.Parameters, "color")
+	updated.Credentials["token"] = Credential{Location: Location{EnvironmentVariable: "TOKEN"}}
+	delete(updated.Outputs, "url")
+	updated.InvocationImages[0].Digest = "sha256:ccc"
+	updated.Images["web"] = Image{BaseImage: BaseImage{Image: "example.com/web:1.1.0", ImageType: "docker", Digest: "sha256:ddd"}}
+	updated.Actions["logs"] = Action{Modifies: true}
+	updated.Actions["backup"] = Action{Modifies: true}
+
+	d := Compare(old, updated)
+
+	var got []string
+	for _, c := range d {
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"parameter color: removed",
+		"parameter name: added (breaking)",
+		"credential token: added",
+		"output url: removed (breaking)",
+		`definition color: enum modified from ["red","blue"] to ["red","blue","green"]`,
+		`definition config: properties/debug/type modified from "boolean" to "string" (breaking)`,
+		"definition name: added",
+		"definition port: default modified from 80 to 8080",
+		"definition replicas: maximum modified from 10 to 5 (breaking)",
+		`invocationImage 0: contentDigest modified from "sha256:aaa" to "sha256:ccc"`,
+		`image web: contentDigest modified from "sha256:bbb" to "sha256:ddd"`,
+		`image web: image modified from "example.com/web:1.0.0" to "example.com/web:1.1.0"`,
+		"action backup: added",
+		"action logs: modifies modified from false to true",
+	}, got)
+
+	assert.True(t, d.HasBreakingChanges())
+	assert.Len(t, d.Breaking(), 4)
+
+	replicas := d[8]
+	assert.Equal(t, Change{
+		Kind:     KindDefinition,
+		Name:     "replicas",
+		Type:     ChangeModified,
+		Field:    "/maximum",
+		Old:      10,
+		New:      5,
+		Breaking: true,
+	}, replicas)
+}
+
+func TestCompare_RequiredParameterWithDefault(t *testing.T) {
+	old := diffBundle()
+	updated := diffBundle()
+	updated.Parameters["port"] = Parameter{Definition: "port", Required: true, Destination: &Location{EnvironmentVariable: "PORT"}}
+	updated.Parameters["host"] = Parameter{Definition: "port", Required: true, Destination: &Location{EnvironmentVariable: "HOST"}}
+
+	d := Compare(old, updated)
+	require.Len(t, d, 2)
+	assert.False(t, d.HasBreakingChanges(), "a required parameter with a default should not break existing installations")
+}
+
+func TestCompare_ParameterDefinition(t *testing.T) {
+	old := diffBundle()
+	updated := diffBundle()
+	updated.Definitions["count"] = &definition.Schema{Type: "number"}
+	updated.Parameters["replicas"] = Parameter{Definition: "count", Destination: &Location{EnvironmentVariable: "REPLICAS"}}
+	updated.Parameters["port"] = Parameter{Definition: "color", Destination: &Location{EnvironmentVariable: "PORT"}}
+
+	d := Compare(old, updated)
+	var params Diff
+	for _, c := range d {
+		if c.Kind == KindParameter {
+			params = append(params, c)
+		}
+	}
+	require.Len(t, params, 2)
+	assert.Equal(t, "port", params[0].Name)
+	assert.True(t, params[0].Breaking, "changing the type from integer to string should be breaking")
+	assert.Equal(t, "replicas", params[1].Name)
+	assert.False(t, params[1].Breaking, "number allows every integer, and the maximum was removed")
+}
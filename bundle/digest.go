@@ -0,0 +1,145 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/pkg/errors"
+)
+
+// DigestAlgorithm is the algorithm used for the digest of a bundle.
+const DigestAlgorithm = "sha256"
+
+// ErrDigestMismatch represents a bundle whose digest is not the expected digest.
+var ErrDigestMismatch = errors.New("bundle digest does not match")
+
+// MarshalCanonical returns the canonical JSON representation of the bundle,
+// which is written by WriteFile and WriteTo, signed by the signature package,
+// and used to compute its digest. The representation of a bundle does not
+// change when it is unmarshaled and marshaled again.
+//
+// The keys of objects are sorted and there is no whitespace. Numbers are
+// written in their shortest form, as in the JSON Canonicalization Scheme
+// (RFC 8785), so that bundles with non-integer numbers, such as a default of
+// 1.5, are supported.
+func (b Bundle) MarshalCanonical() ([]byte, error) {
+	return marshalCanonical(b)
+}
+
+// Digest returns the digest of the canonical JSON representation of the
+// bundle, for example sha256:6ca13d52ca70c883e0f0bb101e425a89e8624de51db2d2392593af6a84118090.
+// The digest identifies the exact bundle, regardless of how the bundle file
+// was formatted.
+func (b Bundle) Digest() (string, error) {
+	data, err := b.MarshalCanonical()
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal the bundle")
+	}
+	return fmt.Sprintf("%s:%x", DigestAlgorithm, sha256.Sum256(data)), nil
+}
+
+// VerifyDigest checks that the digest of the bundle is the expected digest,
+// and returns ErrDigestMismatch when it is not.
+func (b Bundle) VerifyDigest(expected string) error {
+	if !strings.HasPrefix(expected, DigestAlgorithm+":") {
+		return fmt.Errorf("unsupported bundle digest %q, the algorithm must be %s", expected, DigestAlgorithm)
+	}
+
+	digest, err := b.Digest()
+	if err != nil {
+		return err
+	}
+	if digest != expected {
+		return errors.Wrapf(ErrDigestMismatch, "expected %s but the bundle is %s", expected, digest)
+	}
+	return nil
+}
+
+var integerLiteral = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+
+// marshalCanonical encodes the value as canonical JSON. The canonical encoder
+// only supports integers, so the value is first converted into maps, lists
+// and numbers, with the numbers in their canonical form.
+func marshalCanonical(v interface{}) ([]byte, error) {
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc, err = canonicalNumbers(doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalCanonical(doc)
+}
+
+// canonicalNumbers replaces the numbers in the decoded value with their
+// canonical form.
+func canonicalNumbers(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			converted, err := canonicalNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = converted
+		}
+	case []interface{}:
+		for i, value := range t {
+			converted, err := canonicalNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = converted
+		}
+	case json.Number:
+		return canonicalNumber(string(t))
+	}
+	return v, nil
+}
+
+// canonicalNumber formats a number like ECMAScript does, which is how numbers
+// are written by the JSON Canonicalization Scheme. Integers are kept as they
+// were written, so that integers that do not fit in a float64 are not rounded.
+func canonicalNumber(n string) (json.Number, error) {
+	if integerLiteral.MatchString(n) {
+		if n == "-0" {
+			return "0", nil
+		}
+		return json.Number(n), nil
+	}
+
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid number %s", n)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// Write 1e-07 as 1e-7
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return json.Number(s), nil
+}
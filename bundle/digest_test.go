@@ -0,0 +1,116 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func digestBundle() Bundle {
+	return Bundle{
+		SchemaVersion: "v1.0.0",
+		Name:          "wordpress",
+		Version:       "1.0.0",
+		InvocationImages: []InvocationImage{
+			{BaseImage: BaseImage{Image: "example.com/wordpress:1.0.0", ImageType: "docker"}},
+		},
+		Definitions: map[string]*definition.Schema{
+			"port": {Type: "integer", Default: 80},
+		},
+		Parameters: map[string]Parameter{
+			"port": {Definition: "port", Destination: &Location{EnvironmentVariable: "PORT"}},
+		},
+	}
+}
+
+func TestBundle_Digest(t *testing.T) {
+	b := digestBundle()
+
+	digest, err := b.Digest()
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", digest)
+
+	// The digest does not change when the bundle is written and read again
+	var buf bytes.Buffer
+	_, err = b.WriteTo(&buf)
+	require.NoError(t, err)
+	reloaded, err := Unmarshal(buf.Bytes())
+	require.NoError(t, err)
+
+	reloadedDigest, err := reloaded.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, reloadedDigest)
+
+	// The formatting of the bundle file does not change the digest
+	formatted, err := Unmarshal([]byte(`{
+		"schemaVersion": "v1.0.0",
+		"version": "1.0.0",
+		"name": "wordpress",
+		"invocationImages": [ { "image": "example.com/wordpress:1.0.0", "imageType": "docker" } ],
+		"parameters": { "port": { "destination": { "env": "PORT" }, "definition": "port" } },
+		"definitions": { "port": { "default": 80, "type": "integer" } }
+	}`))
+	require.NoError(t, err)
+	formattedDigest, err := formatted.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, formattedDigest)
+
+	b.Version = "1.0.1"
+	changedDigest, err := b.Digest()
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changedDigest)
+}
+
+func TestBundle_VerifyDigest(t *testing.T) {
+	b := digestBundle()
+	digest, err := b.Digest()
+	require.NoError(t, err)
+
+	t.Run("match", func(t *testing.T) {
+		assert.NoError(t, b.VerifyDigest(digest))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		changed := digestBundle()
+		changed.Version = "1.0.1"
+		err := changed.VerifyDigest(digest)
+		require.Error(t, err)
+		assert.Equal(t, ErrDigestMismatch, errors.Cause(err))
+		assert.Contains(t, err.Error(), "expected "+digest)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		err := b.VerifyDigest("md5:abc")
+		assert.EqualError(t, err, `unsupported bundle digest "md5:abc", the algorithm must be sha256`)
+	})
+}
+
+func TestBundle_MarshalCanonical_Numbers(t *testing.T) {
+	b := digestBundle()
+	b.Definitions["ratio"] = &definition.Schema{Type: "number", Default: 1.5}
+	b.Custom = map[string]interface{}{
+		"small": 0.0000001,
+		"large": 1e21,
+		"whole": 1000000.0,
+		"zero":  -0.0,
+	}
+
+	data, err := b.MarshalCanonical()
+	require.NoError(t, err, "non-integer numbers should be supported")
+	assert.Contains(t, string(data), `"default":1.5`)
+	assert.Contains(t, string(data), `"custom":{"large":1e+21,"small":1e-7,"whole":1000000,"zero":0}`)
+
+	digest, err := b.Digest()
+	require.NoError(t, err)
+
+	reloaded, err := Unmarshal(data)
+	require.NoError(t, err)
+	reloadedDigest, err := reloaded.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, reloadedDigest, "the digest should not change when the bundle is read again")
+}
@@ -0,0 +1,338 @@
+// Package lint finds mistakes in bundles that are valid according to
+// bundle.Validate, but that would fail, or behave unexpectedly, at runtime.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	// SeverityError is a mistake that causes the bundle to fail.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is likely a mistake, but the bundle may still work.
+	SeverityWarning Severity = "warning"
+
+	// SeverityInfo is a suggestion to improve the bundle.
+	SeverityInfo Severity = "info"
+)
+
+// Rule IDs, which identify the check that reported a finding.
+const (
+	// RuleDefinitionNotFound reports parameters and outputs that reference
+	// a definition that does not exist.
+	RuleDefinitionNotFound = "definition-not-found"
+
+	// RuleInvalidRef reports definitions with a $ref that cannot be resolved.
+	RuleInvalidRef = "invalid-ref"
+
+	// RuleUnknownAction reports ApplyTo entries that name an action that is
+	// neither a core action nor defined by the bundle.
+	RuleUnknownAction = "unknown-action"
+
+	// RuleInvalidDefault reports defaults that fail their own definition.
+	RuleInvalidDefault = "invalid-default"
+
+	// RuleDuplicateDestination reports parameters and credentials that are
+	// written to the same environment variable or path.
+	RuleDuplicateDestination = "duplicate-destination"
+
+	// RuleOutputPath reports outputs whose path is not in /cnab/app/outputs.
+	RuleOutputPath = "output-path"
+
+	// RuleRequiredWithDefault reports required parameters with a default,
+	// which is never used.
+	RuleRequiredWithDefault = "required-with-default"
+
+	// RuleUnusedDefinition reports definitions that are not used by a
+	// parameter or output.
+	RuleUnusedDefinition = "unused-definition"
+)
+
+// OutputsDir is the directory in the invocation image that outputs are
+// written to.
+const OutputsDir = "/cnab/app/outputs"
+
+// Finding is a problem found in a bundle.
+type Finding struct {
+	// RuleID identifies the check that reported the finding.
+	RuleID string
+
+	// Severity of the finding.
+	Severity Severity
+
+	// Path is a JSON pointer to the part of the bundle with the problem,
+	// for example /parameters/port.
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// String describes the finding, for example
+// "error: /parameters/port: the definition "port" does not exist (definition-not-found)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, f.Path, f.Message, f.RuleID)
+}
+
+// Findings are the problems found in a bundle.
+type Findings []Finding
+
+// HasErrors returns true when any of the findings are errors.
+func (fs Findings) HasErrors() bool {
+	for _, f := range fs {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// BySeverity returns the findings with the severity.
+func (fs Findings) BySeverity(severity Severity) Findings {
+	var result Findings
+	for _, f := range fs {
+		if f.Severity == severity {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Lint the bundle, and return the findings sorted by path and rule ID. The
+// bundle should be validated with bundle.Validate first.
+func Lint(b bundle.Bundle) Findings {
+	l := &linter{bun: b}
+	l.lintParameters()
+	l.lintOutputs()
+	l.lintDefinitions()
+	l.lintDestinations()
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.RuleID < b.RuleID
+	})
+	return l.findings
+}
+
+type linter struct {
+	bun      bundle.Bundle
+	findings Findings
+}
+
+func (l *linter) report(ruleID string, severity Severity, location string, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		RuleID:   ruleID,
+		Severity: severity,
+		Path:     location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintParameters() {
+	for name, param := range l.bun.Parameters {
+		p := pointer("parameters", name)
+		def, ok := l.bun.Definitions[param.Definition]
+		if !ok {
+			l.report(RuleDefinitionNotFound, SeverityError, p+"/definition", "the definition %q does not exist", param.Definition)
+		} else if param.Required && def.Default != nil {
+			l.report(RuleRequiredWithDefault, SeverityWarning, p, "the parameter is required, so the default of definition %q is never used", param.Definition)
+		}
+		l.lintApplyTo(p, param.ApplyTo)
+	}
+}
+
+func (l *linter) lintOutputs() {
+	for name, output := range l.bun.Outputs {
+		p := pointer("outputs", name)
+		if _, ok := l.bun.Definitions[output.Definition]; !ok {
+			l.report(RuleDefinitionNotFound, SeverityError, p+"/definition", "the definition %q does not exist", output.Definition)
+		}
+		if !isOutputPath(output.Path) {
+			l.report(RuleOutputPath, SeverityError, p+"/path", "the path %q must be in %s", output.Path, OutputsDir)
+		}
+		l.lintApplyTo(p, output.ApplyTo)
+	}
+}
+
+func (l *linter) lintApplyTo(p string, applyTo []string) {
+	for i, action := range applyTo {
+		if !l.isAction(action) {
+			l.report(RuleUnknownAction, SeverityError, fmt.Sprintf("%s/applyTo/%d", p, i), "the action %q is not defined", action)
+		}
+	}
+}
+
+func (l *linter) isAction(action string) bool {
+	if bundle.IsCoreAction(action) {
+		return true
+	}
+	_, ok := l.bun.Actions[action]
+	return ok
+}
+
+func (l *linter) lintDefinitions() {
+	used := make(map[string]bool)
+	for _, param := range l.bun.Parameters {
+		used[param.Definition] = true
+	}
+	for _, output := range l.bun.Outputs {
+		used[output.Definition] = true
+	}
+
+	for name, def := range l.bun.Definitions {
+		p := pointer("definitions", name)
+		if !used[name] && !l.isReferenced(name) {
+			l.report(RuleUnusedDefinition, SeverityInfo, p, "the definition is not used by a parameter or output")
+		}
+
+		s, err := l.bun.Definitions.Dereference(def)
+		if err != nil {
+			l.report(RuleInvalidRef, SeverityError, p, "%v", err)
+			continue
+		}
+		if s.Default == nil {
+			continue
+		}
+		valErrs, err := s.Validate(s.Default)
+		if err != nil {
+			l.report(RuleInvalidDefault, SeverityError, p+"/default", "the default could not be validated: %v", err)
+			continue
+		}
+		for _, valErr := range valErrs {
+			l.report(RuleInvalidDefault, SeverityError, p+"/default"+strings.TrimSuffix(valErr.Path, "/"), "the default does not match the definition: %s", valErr.Error)
+		}
+	}
+}
+
+// isReferenced returns true when another definition has a $ref to the
+// definition, or to one of its nested definitions.
+func (l *linter) isReferenced(name string) bool {
+	ref := "#" + pointer("definitions", name)
+	for other, def := range l.bun.Definitions {
+		if other != name && hasRef(def, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRef returns true when the schema has a $ref to the target, or to a
+// location within it.
+func hasRef(s *definition.Schema, target string) bool {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return false
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return findRef(doc, target)
+}
+
+func findRef(node interface{}, target string) bool {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok && (ref == target || strings.HasPrefix(ref, target+"/")) {
+			return true
+		}
+		for _, v := range n {
+			if findRef(v, target) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if findRef(v, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// destination is where the value of a parameter or credential is written.
+type destination struct {
+	kind string
+	name string
+	path string
+}
+
+func (l *linter) lintDestinations() {
+	envs := make(map[string][]destination)
+	paths := make(map[string][]destination)
+
+	for name, param := range l.bun.Parameters {
+		if param.Destination == nil {
+			continue
+		}
+		d := destination{kind: "parameter", name: name, path: pointer("parameters", name) + "/destination"}
+		if param.Destination.EnvironmentVariable != "" {
+			envs[param.Destination.EnvironmentVariable] = append(envs[param.Destination.EnvironmentVariable], d)
+		}
+		if param.Destination.Path != "" {
+			p := path.Clean(param.Destination.Path)
+			paths[p] = append(paths[p], d)
+		}
+	}
+	for name, cred := range l.bun.Credentials {
+		d := destination{kind: "credential", name: name, path: pointer("credentials", name)}
+		if cred.EnvironmentVariable != "" {
+			envs[cred.EnvironmentVariable] = append(envs[cred.EnvironmentVariable], d)
+		}
+		if cred.Path != "" {
+			p := path.Clean(cred.Path)
+			paths[p] = append(paths[p], d)
+		}
+	}
+
+	l.reportDuplicates("environment variable", envs)
+	l.reportDuplicates("path", paths)
+}
+
+// reportDuplicates reports each destination that is shared with another
+// parameter or credential.
+func (l *linter) reportDuplicates(what string, destinations map[string][]destination) {
+	for target, ds := range destinations {
+		if len(ds) < 2 {
+			continue
+		}
+		for _, d := range ds {
+			var others []string
+			for _, other := range ds {
+				if other != d {
+					others = append(others, other.kind+" "+other.name)
+				}
+			}
+			sort.Strings(others)
+			l.report(RuleDuplicateDestination, SeverityError, d.path, "the %s %s is also written by %s", what, target, strings.Join(others, ", "))
+		}
+	}
+}
+
+func isOutputPath(p string) bool {
+	if p == "" {
+		return false
+	}
+	return strings.HasPrefix(path.Clean(p), OutputsDir+"/")
+}
+
+// pointer returns a JSON pointer to an item in a section of the bundle.
+func pointer(section string, name string) string {
+	name = strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	return "/" + section + "/" + name
+}
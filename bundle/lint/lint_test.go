@@ -0,0 +1,78 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func validBundle() bundle.Bundle {
+	return bundle.Bundle{
+		Name:    "wordpress",
+		Version: "0.1.0",
+		Definitions: map[string]*definition.Schema{
+			"port":     {Type: "integer", Default: 80},
+			"password": {Type: "string"},
+			"url":      {Type: "string"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"port":     {Definition: "port", Destination: &bundle.Location{EnvironmentVariable: "PORT"}},
+			"password": {Definition: "password", Required: true, Destination: &bundle.Location{Path: "/cnab/app/password"}},
+		},
+		Credentials: map[string]bundle.Credential{
+			"kubeconfig": {Location: bundle.Location{Path: "/root/.kube/config"}},
+		},
+		Outputs: map[string]bundle.Output{
+			"url": {Definition: "url", Path: "/cnab/app/outputs/url", ApplyTo: []string{"install", "status"}},
+		},
+		Actions: map[string]bundle.Action{
+			"status": {},
+		},
+	}
+}
+
+func TestLint_Valid(t *testing.T) {
+	findings := Lint(validBundle())
+	assert.Empty(t, findings)
+	assert.False(t, findings.HasErrors())
+}
+
+func TestLint(t *testing.T) {
+	b := validBundle()
+	maxPort := 1024
+	b.Definitions["port"] = &definition.Schema{Type: "integer", Default: 8080, Maximum: &maxPort}
+	b.Definitions["unused"] = &definition.Schema{Type: "string"}
+	b.Definitions["cycle"] = &definition.Schema{Ref: "#/definitions/cycle"}
+	b.Definitions["base"] = &definition.Schema{Type: "string"}
+	b.Definitions["host"] = &definition.Schema{Ref: "#/definitions/base"}
+	b.Parameters["host"] = bundle.Parameter{Definition: "host", ApplyTo: []string{"upgrade", "backup"}, Destination: &bundle.Location{EnvironmentVariable: "PORT"}}
+	b.Parameters["token"] = bundle.Parameter{Definition: "missing", Destination: &bundle.Location{Path: "/root/.kube/config"}}
+	b.Parameters["replicas"] = bundle.Parameter{Definition: "port", Required: true}
+	b.Outputs["log"] = bundle.Output{Definition: "cycle", Path: "/tmp/log"}
+
+	var got []string
+	findings := Lint(b)
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		"error: /credentials/kubeconfig: the path /root/.kube/config is also written by parameter token (duplicate-destination)",
+		"error: /definitions/cycle: #/definitions/cycle -> #/definitions/cycle: circular $ref (invalid-ref)",
+		"error: /definitions/port/default: the default does not match the definition: must be less than or equal to 1024.000000 (invalid-default)",
+		"info: /definitions/unused: the definition is not used by a parameter or output (unused-definition)",
+		"error: /outputs/log/path: the path \"/tmp/log\" must be in /cnab/app/outputs (output-path)",
+		"error: /parameters/host/applyTo/1: the action \"backup\" is not defined (unknown-action)",
+		"error: /parameters/host/destination: the environment variable PORT is also written by parameter port (duplicate-destination)",
+		"error: /parameters/port/destination: the environment variable PORT is also written by parameter host (duplicate-destination)",
+		"warning: /parameters/replicas: the parameter is required, so the default of definition \"port\" is never used (required-with-default)",
+		"error: /parameters/token/definition: the definition \"missing\" does not exist (definition-not-found)",
+		"error: /parameters/token/destination: the path /root/.kube/config is also written by credential kubeconfig (duplicate-destination)",
+	}, got)
+
+	assert.True(t, findings.HasErrors())
+	assert.Len(t, findings.BySeverity(SeverityWarning), 1)
+	assert.Len(t, findings.BySeverity(SeverityInfo), 1)
+}
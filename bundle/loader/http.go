@@ -0,0 +1,294 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle"
+)
+
+const (
+	// DefaultHTTPTimeout is the time allowed to download a bundle, when
+	// HTTPLoader.Timeout is not set.
+	DefaultHTTPTimeout = 30 * time.Second
+
+	// DefaultMaxBundleSize is the maximum size of a bundle file in bytes, when
+	// HTTPLoader.MaxSize is not set.
+	DefaultMaxBundleSize int64 = 10 * 1024 * 1024
+)
+
+var sha256Hex = regexp.MustCompile("^[a-f0-9]{64}$")
+
+var _ BundleLoader = &HTTPLoader{}
+
+// HTTPLoader loads bundle files (bundle.json) over HTTP and HTTPS. The zero
+// value is ready to use.
+type HTTPLoader struct {
+	// Client used to download bundles. When it is not set, a client with the
+	// Timeout is used.
+	Client *http.Client
+
+	// Timeout for downloading a bundle, including reading the response.
+	// Defaults to DefaultHTTPTimeout.
+	Timeout time.Duration
+
+	// Headers added to each request, for example an Authorization header.
+	Headers http.Header
+
+	// MaxSize is the maximum size of a bundle file in bytes. Defaults to
+	// DefaultMaxBundleSize.
+	MaxSize int64
+
+	// CacheDir is the directory where downloaded bundles are cached, by their
+	// sha256 checksum and the ETag from the server. Bundles are not cached
+	// when it is not set.
+	CacheDir string
+}
+
+// NewHTTPLoader creates a loader for bundle files served over HTTP.
+func NewHTTPLoader() *HTTPLoader {
+	return &HTTPLoader{}
+}
+
+// Load downloads the bundle file from the URL, and loads it.
+func (l *HTTPLoader) Load(source string) (*bundle.Bundle, error) {
+	return l.LoadWithChecksum(source, "")
+}
+
+// LoadWithChecksum downloads the bundle file from the URL, checks that its
+// sha256 checksum matches, for example
+// sha256:6ca13d52ca70c883e0f0bb101e425a89e8624de51db2d2392593af6a84118090,
+// and loads it. When the checksum is empty it is not checked.
+func (l *HTTPLoader) LoadWithChecksum(source string, checksum string) (*bundle.Bundle, error) {
+	data, err := l.Fetch(source, checksum)
+	if err != nil {
+		return nil, err
+	}
+	return l.LoadData(data)
+}
+
+// LoadData loads a JSON or YAML bundle file, see Loader.LoadData.
+func (l *HTTPLoader) LoadData(data []byte) (*bundle.Bundle, error) {
+	return NewLoader().LoadData(data)
+}
+
+// Fetch downloads the bundle file from the URL, and returns its contents.
+// When the checksum is not empty, the file must have that sha256 checksum.
+//
+// When the CacheDir is set, a cached file with the checksum is returned
+// without downloading it, and otherwise the ETag of the cached file for the
+// URL is sent, so that the server only returns the file when it has changed.
+func (l *HTTPLoader) Fetch(source string, checksum string) ([]byte, error) {
+	expected, err := parseChecksum(checksum)
+	if err != nil {
+		return nil, err
+	}
+	if expected != "" {
+		if data, ok := l.readCache(expected); ok {
+			return data, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid bundle URL %s", source)
+	}
+	for key, values := range l.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	entry, cachedData, cached := l.readCacheEntry(source)
+	if cached {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := l.client().Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot download bundle %s", source)
+	}
+	defer resp.Body.Close()
+
+	var data []byte
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		data = cachedData
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if data, err = l.readBody(source, resp); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot download bundle %s: %s", source, resp.Status)
+	}
+
+	digest := sha256Sum(data)
+	if expected != "" && digest != expected {
+		return nil, fmt.Errorf("the bundle %s has the checksum sha256:%s instead of sha256:%s", source, digest, expected)
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		if err := l.writeCache(source, resp.Header.Get("ETag"), digest, data); err != nil {
+			return nil, errors.Wrapf(err, "cannot cache bundle %s", source)
+		}
+	}
+	return data, nil
+}
+
+func (l *HTTPLoader) client() *http.Client {
+	if l.Client != nil {
+		return l.Client
+	}
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+func (l *HTTPLoader) maxSize() int64 {
+	if l.MaxSize <= 0 {
+		return DefaultMaxBundleSize
+	}
+	return l.MaxSize
+}
+
+// readBody reads the response, failing when it is larger than the MaxSize.
+func (l *HTTPLoader) readBody(source string, resp *http.Response) ([]byte, error) {
+	max := l.maxSize()
+	tooLarge := fmt.Errorf("the bundle %s is larger than the maximum size of %d bytes", source, max)
+	if resp.ContentLength > max {
+		return nil, tooLarge
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot download bundle %s", source)
+	}
+	if int64(len(data)) > max {
+		return nil, tooLarge
+	}
+	return data, nil
+}
+
+// parseChecksum returns the hex encoded sha256 checksum, from a checksum with
+// or without the sha256: prefix.
+func parseChecksum(checksum string) (string, error) {
+	if checksum == "" {
+		return "", nil
+	}
+	sum := strings.TrimPrefix(checksum, bundle.DigestAlgorithm+":")
+	if !sha256Hex.MatchString(sum) {
+		return "", fmt.Errorf("invalid checksum %q, it must be a hex encoded sha256 checksum", checksum)
+	}
+	return sum, nil
+}
+
+func sha256Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheEntry records the ETag and checksum of the file that was downloaded
+// from a URL.
+type cacheEntry struct {
+	URL    string `json:"url"`
+	ETag   string `json:"etag"`
+	Digest string `json:"digest"`
+}
+
+// blobPath returns the path of the cached file with the checksum. The cache
+// has the files by their checksum in CacheDir/sha256, and an entry for each
+// URL with an ETag in CacheDir/etags.
+func (l *HTTPLoader) blobPath(digest string) string {
+	return filepath.Join(l.CacheDir, bundle.DigestAlgorithm, digest)
+}
+
+func (l *HTTPLoader) entryPath(source string) string {
+	return filepath.Join(l.CacheDir, "etags", sha256Sum([]byte(source))+".json")
+}
+
+// readCache returns the cached file with the checksum, unless it is missing
+// or has been modified.
+func (l *HTTPLoader) readCache(digest string) ([]byte, bool) {
+	if l.CacheDir == "" {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(l.blobPath(digest))
+	if err != nil || sha256Sum(data) != digest {
+		return nil, false
+	}
+	return data, true
+}
+
+// readCacheEntry returns the cache entry for the URL and the cached file, when
+// the entry has an ETag and the file is cached.
+func (l *HTTPLoader) readCacheEntry(source string) (cacheEntry, []byte, bool) {
+	var entry cacheEntry
+	if l.CacheDir == "" {
+		return entry, nil, false
+	}
+	data, err := ioutil.ReadFile(l.entryPath(source))
+	if err != nil {
+		return entry, nil, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != source || entry.ETag == "" {
+		return entry, nil, false
+	}
+	data, ok := l.readCache(entry.Digest)
+	return entry, data, ok
+}
+
+// writeCache caches the file, and the ETag for the URL when there is one.
+func (l *HTTPLoader) writeCache(source string, etag string, digest string, data []byte) error {
+	if l.CacheDir == "" {
+		return nil
+	}
+	if err := writeFileAtomic(l.blobPath(digest), data); err != nil {
+		return err
+	}
+
+	if etag == "" {
+		err := os.Remove(l.entryPath(source))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	entry, err := json.Marshal(cacheEntry{URL: source, ETag: etag, Digest: digest})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.entryPath(source), entry)
+}
+
+// writeFileAtomic writes the file through a temporary file, so that a
+// partially written file is never read from the cache.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,177 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bundleServer serves the minimal bundle with an ETag, and counts the
+// requests and the responses with the bundle.
+type bundleServer struct {
+	*httptest.Server
+	data      []byte
+	etag      string
+	requests  int
+	downloads int
+	headers   http.Header
+}
+
+func newBundleServer(t *testing.T) *bundleServer {
+	data, err := ioutil.ReadFile(testFooJSON)
+	require.NoError(t, err)
+
+	s := &bundleServer{data: data, etag: `"v1"`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests++
+		s.headers = r.Header
+		if s.etag != "" {
+			if r.Header.Get("If-None-Match") == s.etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", s.etag)
+		}
+		s.downloads++
+		w.Write(s.data)
+	}))
+	return s
+}
+
+func checksum(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func TestHTTPLoader_Load(t *testing.T) {
+	s := newBundleServer(t)
+	defer s.Close()
+
+	l := NewHTTPLoader()
+	l.Headers = http.Header{"Authorization": []string{"Bearer mytoken"}}
+
+	bun, err := l.Load(s.URL + "/bundle.json")
+	require.NoError(t, err)
+	assert.Equal(t, "mybun", bun.Name)
+	assert.Equal(t, "Bearer mytoken", s.headers.Get("Authorization"))
+}
+
+func TestHTTPLoader_LoadWithChecksum(t *testing.T) {
+	s := newBundleServer(t)
+	defer s.Close()
+
+	l := NewHTTPLoader()
+
+	bun, err := l.LoadWithChecksum(s.URL, checksum(s.data))
+	require.NoError(t, err)
+	assert.Equal(t, "mybun", bun.Name)
+
+	wrong := checksum([]byte("something else"))
+	_, err = l.LoadWithChecksum(s.URL, wrong)
+	assert.EqualError(t, err, fmt.Sprintf("the bundle %s has the checksum %s instead of %s", s.URL, checksum(s.data), wrong))
+
+	_, err = l.LoadWithChecksum(s.URL, "md5:abc")
+	assert.EqualError(t, err, `invalid checksum "md5:abc", it must be a hex encoded sha256 checksum`)
+}
+
+func TestHTTPLoader_StatusCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"name": "not found"}`, http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	_, err := NewHTTPLoader().Load(ts.URL)
+	assert.EqualError(t, err, fmt.Sprintf("cannot download bundle %s: 404 Not Found", ts.URL))
+}
+
+func TestHTTPLoader_MaxSize(t *testing.T) {
+	s := newBundleServer(t)
+	defer s.Close()
+
+	l := &HTTPLoader{MaxSize: 10}
+	_, err := l.Load(s.URL)
+	assert.EqualError(t, err, fmt.Sprintf("the bundle %s is larger than the maximum size of 10 bytes", s.URL))
+
+	// Without a Content-Length, the size is checked while reading
+	chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(s.data[:5])
+		w.(http.Flusher).Flush()
+		w.Write(s.data[5:])
+	}))
+	defer chunked.Close()
+	_, err = l.Load(chunked.URL)
+	assert.EqualError(t, err, fmt.Sprintf("the bundle %s is larger than the maximum size of 10 bytes", chunked.URL))
+}
+
+func TestHTTPLoader_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	l := &HTTPLoader{Timeout: 50 * time.Millisecond}
+	_, err := l.Load(ts.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot download bundle "+ts.URL)
+}
+
+func TestHTTPLoader_Cache(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	s := newBundleServer(t)
+	defer s.Close()
+
+	l := &HTTPLoader{CacheDir: cacheDir}
+
+	_, err = l.Load(s.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.downloads)
+	assert.FileExists(t, filepath.Join(cacheDir, "sha256", checksum(s.data)[len("sha256:"):]))
+
+	t.Run("not modified", func(t *testing.T) {
+		bun, err := l.Load(s.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "mybun", bun.Name)
+		assert.Equal(t, 1, s.downloads, "the cached bundle should be used when the ETag matches")
+		assert.Equal(t, `"v1"`, s.headers.Get("If-None-Match"))
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		requests := s.requests
+		_, err := l.LoadWithChecksum(s.URL, checksum(s.data))
+		require.NoError(t, err)
+		assert.Equal(t, requests, s.requests, "the cached bundle with the checksum should be used without a request")
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		s.data = []byte(`{"name":"mybun","version":"v2.0.0","schemaVersion":"v1.0.0","invocationImages":[]}`)
+		s.etag = `"v2"`
+
+		bun, err := l.Load(s.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", bun.Version)
+		assert.Equal(t, 2, s.downloads)
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		path := filepath.Join(cacheDir, "sha256", checksum(s.data)[len("sha256:"):])
+		require.NoError(t, ioutil.WriteFile(path, []byte("corrupted"), 0644))
+
+		bun, err := l.Load(s.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", bun.Version, "a modified cache file should be downloaded again")
+		assert.Equal(t, 3, s.downloads)
+	})
+}
@@ -1,12 +1,15 @@
 package loader
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+
 	"github.com/cnabio/cnab-go/bundle"
 )
 
@@ -43,15 +46,28 @@ func (l *Loader) Load(filename string) (*bundle.Bundle, error) {
 
 // LoadData loads a Bundle from the given data.
 //
-// This loads a JSON bundle file into a *bundle.Bundle.
+// This loads a JSON or YAML bundle file into a *bundle.Bundle. Data that
+// starts with "{" is loaded as JSON, and any other data is loaded as YAML.
 func (l *Loader) LoadData(data []byte) (*bundle.Bundle, error) {
-	return bundle.Unmarshal(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		b, err := bundle.Unmarshal(data)
+		if err != nil {
+			return b, errors.Wrap(err, "cannot load the bundle as JSON")
+		}
+		return b, nil
+	}
+
+	b := &bundle.Bundle{}
+	if err := yaml.Unmarshal(data, b); err != nil {
+		return b, errors.Wrap(err, "cannot load the bundle as YAML")
+	}
+	return b, nil
 }
 
 // loadData is a utility method that loads a file either off of the FS (if it exists) or via a remote HTTP GET.
 //
 // If bundleFile exists on disk, this will return that file. Otherwise, it will attempt to parse the
-// file name as a URL and request it as an HTTP GET request.
+// file name as a URL and download it with an HTTPLoader.
 func loadData(bundleFile string) ([]byte, error) {
 	if isLocalReference(bundleFile) {
 		return ioutil.ReadFile(bundleFile)
@@ -66,13 +82,7 @@ func loadData(bundleFile string) ([]byte, error) {
 		return []byte{}, fmt.Errorf("bundle %q not found", bundleFile)
 	}
 
-	response, err := http.Get(bundleFile)
-	if err != nil {
-		return []byte{}, fmt.Errorf("cannot download bundle file: %v", err)
-	}
-	defer response.Body.Close()
-
-	return ioutil.ReadAll(response.Body)
+	return NewHTTPLoader().Fetch(bundleFile, "")
 }
 
 func isLocalReference(file string) bool {
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testFooJSON = filepath.Join("..", "testdata", "minimal.json")
@@ -48,3 +49,79 @@ func TestLoader_Remote(t *testing.T) {
 	is.Equal("mybun", bundle.Name)
 	is.Equal("v1.0.0", bundle.Version)
 }
+
+func TestLoader_YAML(t *testing.T) {
+	l := NewLoader()
+	bun, err := l.Load(filepath.Join("..", "..", "testdata", "bundles", "bundle.yaml"))
+	require.NoError(t, err, "cannot load bundle")
+
+	assert.Equal(t, "testBundle", bun.Name)
+	assert.Equal(t, "1.0", bun.Version)
+
+	// The YAML bundle is converted to the same canonical JSON as the JSON bundle
+	var buf bytes.Buffer
+	_, err = bun.WriteTo(&buf)
+	require.NoError(t, err)
+	wantJSON, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", "bundles", "canonical-bundle.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(wantJSON), buf.String())
+}
+
+func TestLoader_LoadData_YAML(t *testing.T) {
+	data := `
+schemaVersion: v1.0.0
+name: mybun
+version: 1.0.0
+invocationImages:
+- imageType: docker
+  image: cnabio/mybunii:def456
+definitions:
+  config:
+    type: object
+    default:
+      replicas: 3
+      tags: [web]
+parameters:
+  config:
+    definition: config
+    destination:
+      env: CONFIG
+custom:
+  com.example.backup:
+    schedule:
+      daily: true
+`
+	l := NewLoader()
+	bun, err := l.LoadData([]byte(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"replicas": 3, "tags": []interface{}{"web"}}, bun.Definitions["config"].Default)
+	assert.Equal(t, map[string]interface{}{"schedule": map[string]interface{}{"daily": true}}, bun.Custom["com.example.backup"])
+
+	// The default is valid, and the bundle can be marshaled to JSON
+	valErrs, err := bun.Definitions["config"].Validate(bun.Definitions["config"].Default)
+	require.NoError(t, err)
+	assert.Empty(t, valErrs)
+	_, err = bun.MarshalCanonical()
+	require.NoError(t, err)
+}
+
+func TestLoader_LoadData_InvalidYAML(t *testing.T) {
+	l := NewLoader()
+
+	_, err := l.LoadData([]byte("name: [mybun"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot load the bundle as YAML")
+
+	_, err = l.LoadData([]byte("definitions:\n  port:\n    type: 1\n"))
+	require.Error(t, err, "the definition should be validated")
+}
+
+func TestLoader_LoadData_InvalidJSON(t *testing.T) {
+	l := NewLoader()
+
+	_, err := l.LoadData([]byte(`{"name": "mybun", "version": `))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot load the bundle as JSON")
+	assert.Contains(t, err.Error(), "unexpected end of JSON input")
+}
@@ -0,0 +1,439 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle"
+)
+
+const (
+	// ConfigMediaType is the media type of the bundle.json, which is the
+	// config of the bundle's config manifest.
+	ConfigMediaType types.MediaType = "application/vnd.cnab.config.v1+json"
+
+	// ArtifactType identifies an index as a CNAB bundle, in the
+	// ArtifactTypeAnnotation of the index.
+	ArtifactType = "application/vnd.cnab.manifest.v1"
+
+	// ArtifactTypeAnnotation is the annotation of the index with its
+	// artifact type.
+	ArtifactTypeAnnotation = "org.opencontainers.artifactType"
+
+	// RuntimeVersionAnnotation is the annotation of the index with the
+	// schema version of the bundle.
+	RuntimeVersionAnnotation = "io.cnab.runtime_version"
+
+	// ManifestTypeAnnotation is the annotation of each manifest in the index
+	// with its role in the bundle.
+	ManifestTypeAnnotation = "io.cnab.manifest.type"
+
+	// ManifestTypeConfig is the ManifestTypeAnnotation of the manifest with
+	// the bundle.json.
+	ManifestTypeConfig = "config"
+
+	// ManifestTypeInvocation is the ManifestTypeAnnotation of the manifest of
+	// an invocation image.
+	ManifestTypeInvocation = "invocation"
+
+	// ManifestTypeComponent is the ManifestTypeAnnotation of the manifest of
+	// a component image.
+	ManifestTypeComponent = "component"
+
+	// ComponentNameAnnotation is the annotation of the manifest of a
+	// component image with the name of the image in the bundle.
+	ComponentNameAnnotation = "io.cnab.component.name"
+)
+
+var _ BundleLoader = &RegistryLoader{}
+
+// RegistryLoader loads bundles from OCI registries, and pushes bundles to
+// them, following the CNAB Registry specification: the bundle is an index,
+// whose config manifest has the bundle.json as its config, and which also
+// references the manifests of the invocation and component images.
+type RegistryLoader struct {
+	// Options used to access the registry. When there are no options, the
+	// credentials are read from the docker configuration.
+	Options []remote.Option
+}
+
+// NewRegistryLoader creates a loader for bundles in OCI registries.
+func NewRegistryLoader(options ...remote.Option) *RegistryLoader {
+	return &RegistryLoader{Options: options}
+}
+
+func (l *RegistryLoader) options() []remote.Option {
+	if len(l.Options) == 0 {
+		return []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+	}
+	return l.Options
+}
+
+// Load loads the bundle with the OCI reference, for example
+// example.com/org/wordpress:v1.0.0.
+func (l *RegistryLoader) Load(ref string) (*bundle.Bundle, error) {
+	b, _, err := l.Pull(ref)
+	return b, err
+}
+
+// LoadData loads a JSON or YAML bundle file, see Loader.LoadData.
+func (l *RegistryLoader) LoadData(data []byte) (*bundle.Bundle, error) {
+	return NewLoader().LoadData(data)
+}
+
+// Pull fetches the bundle with the OCI reference, which is a tag, for example
+// example.com/org/wordpress:v1.0.0, or a digest, for example
+// example.com/org/wordpress@sha256:6ca13d52ca70c883e0f0bb101e425a89e8624de51db2d2392593af6a84118090.
+// It returns the bundle and the digest of its index, which identifies the
+// bundle when the tag is moved.
+func (l *RegistryLoader) Pull(ref string) (*bundle.Bundle, string, error) {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid bundle reference %s", ref)
+	}
+
+	desc, err := remote.Get(r, l.options()...)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot fetch bundle %s", ref)
+	}
+
+	var img v1.Image
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "cannot fetch bundle %s", ref)
+		}
+		img, err = configImageFromIndex(index)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid bundle %s", ref)
+		}
+	case types.OCIManifestSchema1, types.DockerManifestSchema2:
+		img, err = desc.Image()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "cannot fetch bundle %s", ref)
+		}
+	default:
+		return nil, "", fmt.Errorf("invalid bundle %s: unsupported media type %s", ref, desc.MediaType)
+	}
+
+	data, err := readConfig(img)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid bundle %s", ref)
+	}
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid bundle %s", ref)
+	}
+	return b, desc.Digest.String(), nil
+}
+
+// configImageFromIndex returns the config manifest of a bundle's index.
+func configImageFromIndex(index v1.ImageIndex) (v1.Image, error) {
+	m, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range m.Manifests {
+		if d.Annotations[ManifestTypeAnnotation] == ManifestTypeConfig {
+			return index.Image(d.Digest)
+		}
+	}
+	return nil, errors.New("the index does not have a config manifest")
+}
+
+// readConfig returns the bundle.json, which is the config of the manifest,
+// after checking its media type and digest.
+func readConfig(img v1.Image) ([]byte, error) {
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if m.Config.MediaType != ConfigMediaType {
+		return nil, fmt.Errorf("the config of the manifest has the media type %s instead of %s", m.Config.MediaType, ConfigMediaType)
+	}
+	data, err := img.RawConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	digest, _, err := v1.SHA256(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if digest != m.Config.Digest {
+		return nil, fmt.Errorf("the bundle.json has the digest %s instead of %s", digest, m.Config.Digest)
+	}
+	return data, nil
+}
+
+// Push pushes the bundle to the OCI reference, for example
+// example.com/org/wordpress:v1.0.0, and returns the digest of its index.
+//
+// The invocation and component images are resolved in their registries, and
+// their manifests are copied to the repository of the bundle when they are
+// not there yet, so that the index can reference them. When an image has a
+// contentDigest, the image with that digest is pushed instead of its tag.
+func (l *RegistryLoader) Push(b *bundle.Bundle, ref string) (string, error) {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid bundle reference %s", ref)
+	}
+	if err := b.Validate(); err != nil {
+		return "", errors.Wrap(err, "cannot push an invalid bundle")
+	}
+
+	index, err := newBundleIndex(b)
+	if err != nil {
+		return "", err
+	}
+	for i, ii := range b.InvocationImages {
+		annotations := map[string]string{ManifestTypeAnnotation: ManifestTypeInvocation}
+		if err := l.addImage(index, ii.BaseImage, annotations); err != nil {
+			return "", errors.Wrapf(err, "cannot push invocation image %d of bundle %s", i, ref)
+		}
+	}
+	for _, imgName := range sortedImageNames(b.Images) {
+		annotations := map[string]string{
+			ManifestTypeAnnotation:  ManifestTypeComponent,
+			ComponentNameAnnotation: imgName,
+		}
+		if err := l.addImage(index, b.Images[imgName].BaseImage, annotations); err != nil {
+			return "", errors.Wrapf(err, "cannot push image %s of bundle %s", imgName, ref)
+		}
+	}
+	if err := index.marshal(); err != nil {
+		return "", err
+	}
+
+	if err := remote.WriteIndex(r, index, l.options()...); err != nil {
+		return "", errors.Wrapf(err, "cannot push bundle %s", ref)
+	}
+	digest, err := index.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+// configImage is the config manifest of a bundle, which has the bundle.json
+// as its config, and no layers.
+type configImage struct {
+	config   []byte
+	manifest []byte
+}
+
+var _ partial.CompressedImageCore = &configImage{}
+
+func newConfigImage(b *bundle.Bundle) (*configImage, error) {
+	config, err := b.MarshalCanonical()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal the bundle")
+	}
+	digest, size, err := v1.SHA256(bytes.NewReader(config))
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		Config: v1.Descriptor{
+			MediaType: ConfigMediaType,
+			Size:      size,
+			Digest:    digest,
+		},
+		Layers: []v1.Descriptor{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &configImage{config: config, manifest: manifest}, nil
+}
+
+func (i *configImage) RawConfigFile() ([]byte, error) {
+	return i.config, nil
+}
+
+func (i *configImage) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+
+func (i *configImage) RawManifest() ([]byte, error) {
+	return i.manifest, nil
+}
+
+func (i *configImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	return nil, fmt.Errorf("the bundle config manifest does not have layer %s", h)
+}
+
+// addImage resolves an image of the bundle in its registry, and adds its
+// manifest to the index.
+func (l *RegistryLoader) addImage(index *bundleIndex, img bundle.BaseImage, annotations map[string]string) error {
+	switch img.ImageType {
+	case "", "docker", "oci":
+	default:
+		return fmt.Errorf("unsupported image type %s, only docker and oci images can be pushed to a registry", img.ImageType)
+	}
+
+	r, err := name.ParseReference(img.Image)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image reference %s", img.Image)
+	}
+	if img.Digest != "" {
+		r = r.Context().Digest(img.Digest)
+	}
+	ref := r.String()
+	desc, err := remote.Get(r, l.options()...)
+	if err != nil {
+		return errors.Wrapf(err, "cannot fetch image %s", ref)
+	}
+
+	manifest := v1.Descriptor{
+		MediaType:   desc.MediaType,
+		Size:        desc.Size,
+		Digest:      desc.Digest,
+		Annotations: annotations,
+	}
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		ii, err := desc.ImageIndex()
+		if err != nil {
+			return errors.Wrapf(err, "cannot fetch image %s", ref)
+		}
+		index.indexes[desc.Digest] = ii
+	case types.OCIManifestSchema1, types.DockerManifestSchema2:
+		i, err := desc.Image()
+		if err != nil {
+			return errors.Wrapf(err, "cannot fetch image %s", ref)
+		}
+		index.images[desc.Digest] = i
+	default:
+		return fmt.Errorf("the image %s has the unsupported media type %s", ref, desc.MediaType)
+	}
+	index.index.Manifests = append(index.index.Manifests, manifest)
+	return nil
+}
+
+func sortedImageNames(images map[string]bundle.Image) []string {
+	names := make([]string, 0, len(images))
+	for imgName := range images {
+		names = append(names, imgName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bundleIndex is the index of a bundle, which references its config manifest
+// and the manifests of its images.
+type bundleIndex struct {
+	index    v1.IndexManifest
+	manifest []byte
+
+	// images and indexes that the index references, by digest, which are
+	// written to the registry with the index when they are not there yet.
+	images  map[v1.Hash]v1.Image
+	indexes map[v1.Hash]v1.ImageIndex
+}
+
+var _ v1.ImageIndex = &bundleIndex{}
+
+// newBundleIndex creates the index of a bundle with its config manifest. The
+// index must be marshaled again when more manifests are added to it.
+func newBundleIndex(b *bundle.Bundle) (*bundleIndex, error) {
+	core, err := newConfigImage(b)
+	if err != nil {
+		return nil, err
+	}
+	img, err := partial.CompressedToImage(core)
+	if err != nil {
+		return nil, err
+	}
+	digest, size, err := v1.SHA256(bytes.NewReader(core.manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{
+		ArtifactTypeAnnotation:             ArtifactType,
+		RuntimeVersionAnnotation:           string(b.SchemaVersion),
+		"org.opencontainers.image.title":   b.Name,
+		"org.opencontainers.image.version": b.Version,
+	}
+	if b.Description != "" {
+		annotations["org.opencontainers.image.description"] = b.Description
+	}
+	index := &bundleIndex{
+		index: v1.IndexManifest{
+			SchemaVersion: 2,
+			MediaType:     types.OCIImageIndex,
+			Manifests: []v1.Descriptor{
+				{
+					MediaType:   types.OCIManifestSchema1,
+					Size:        size,
+					Digest:      digest,
+					Annotations: map[string]string{ManifestTypeAnnotation: ManifestTypeConfig},
+				},
+			},
+			Annotations: annotations,
+		},
+		images:  map[v1.Hash]v1.Image{digest: img},
+		indexes: map[v1.Hash]v1.ImageIndex{},
+	}
+	return index, index.marshal()
+}
+
+// marshal creates the manifest of the index.
+func (i *bundleIndex) marshal() error {
+	manifest, err := json.Marshal(i.index)
+	if err != nil {
+		return err
+	}
+	i.manifest = manifest
+	return nil
+}
+
+func (i *bundleIndex) MediaType() (types.MediaType, error) {
+	return types.OCIImageIndex, nil
+}
+
+func (i *bundleIndex) Digest() (v1.Hash, error) {
+	digest, _, err := v1.SHA256(bytes.NewReader(i.manifest))
+	return digest, err
+}
+
+func (i *bundleIndex) Size() (int64, error) {
+	return int64(len(i.manifest)), nil
+}
+
+func (i *bundleIndex) IndexManifest() (*v1.IndexManifest, error) {
+	return v1.ParseIndexManifest(bytes.NewReader(i.manifest))
+}
+
+func (i *bundleIndex) RawManifest() ([]byte, error) {
+	return i.manifest, nil
+}
+
+func (i *bundleIndex) Image(h v1.Hash) (v1.Image, error) {
+	img, ok := i.images[h]
+	if !ok {
+		return nil, fmt.Errorf("the bundle index does not have image %s", h)
+	}
+	return img, nil
+}
+
+func (i *bundleIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
+	ii, ok := i.indexes[h]
+	if !ok {
+		return nil, fmt.Errorf("the bundle index does not have index %s", h)
+	}
+	return ii, nil
+}
@@ -0,0 +1,221 @@
+package loader
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+// newTestRegistry starts an in-process registry, and returns its host.
+func newTestRegistry(t *testing.T) (string, func()) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	return u.Host, s.Close
+}
+
+func newTestRegistryLoader() *RegistryLoader {
+	return NewRegistryLoader(remote.WithAuth(authn.Anonymous))
+}
+
+// pushTestImage pushes a random image to the registry, and returns its digest.
+func pushTestImage(t *testing.T, ref string) string {
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(r, img, remote.WithAuth(authn.Anonymous)))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest.String()
+}
+
+// registryBundle returns a bundle whose images are pushed to the registry.
+func registryBundle(t *testing.T, host string) *bundle.Bundle {
+	pushTestImage(t, host+"/cnab/mybunii:def456")
+	return &bundle.Bundle{
+		SchemaVersion: "v1.0.0",
+		Name:          "mybun",
+		Version:       "v1.0.0",
+		Description:   "My First Bundle",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: host + "/cnab/mybunii:def456", ImageType: "docker"}},
+		},
+		Images: map[string]bundle.Image{
+			"web": {BaseImage: bundle.BaseImage{
+				Image:     host + "/library/nginx:1.19",
+				ImageType: "oci",
+				Digest:    pushTestImage(t, host+"/library/nginx:1.19"),
+			}},
+		},
+		Definitions: definition.Definitions{
+			"ratio": {Type: "number", Default: 1.5},
+		},
+	}
+}
+
+func TestRegistryLoader_PushPull(t *testing.T) {
+	host, stop := newTestRegistry(t)
+	defer stop()
+
+	l := newTestRegistryLoader()
+	b := registryBundle(t, host)
+	ref := host + "/cnab/mybun:v1.0.0"
+
+	digest, err := l.Push(b, ref)
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", digest)
+
+	pulled, pulledDigest, err := l.Pull(ref)
+	require.NoError(t, err)
+	assert.Equal(t, digest, pulledDigest, "the tag should resolve to the pushed index")
+	assert.Equal(t, b, pulled)
+
+	byDigest, byDigestDigest, err := l.Pull(host + "/cnab/mybun@" + digest)
+	require.NoError(t, err)
+	assert.Equal(t, digest, byDigestDigest)
+	assert.Equal(t, b, byDigest)
+
+	loaded, err := l.Load(ref)
+	require.NoError(t, err)
+	assert.Equal(t, b, loaded)
+}
+
+func TestRegistryLoader_PushIndex(t *testing.T) {
+	host, stop := newTestRegistry(t)
+	defer stop()
+
+	l := newTestRegistryLoader()
+	b := registryBundle(t, host)
+	ref := host + "/cnab/mybun:v1.0.0"
+	_, err := l.Push(b, ref)
+	require.NoError(t, err)
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	index, err := remote.Index(r, l.Options...)
+	require.NoError(t, err)
+	m, err := index.IndexManifest()
+	require.NoError(t, err)
+
+	assert.Equal(t, ArtifactType, m.Annotations[ArtifactTypeAnnotation])
+	assert.Equal(t, "v1.0.0", m.Annotations[RuntimeVersionAnnotation])
+	assert.Equal(t, "mybun", m.Annotations["org.opencontainers.image.title"])
+	require.Len(t, m.Manifests, 3)
+	assert.Equal(t, ManifestTypeConfig, m.Manifests[0].Annotations[ManifestTypeAnnotation])
+
+	img, err := index.Image(m.Manifests[0].Digest)
+	require.NoError(t, err)
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, ConfigMediaType, manifest.Config.MediaType)
+	assert.Empty(t, manifest.Layers)
+
+	invocation := m.Manifests[1]
+	assert.Equal(t, ManifestTypeInvocation, invocation.Annotations[ManifestTypeAnnotation])
+	iiRef, err := name.ParseReference(b.InvocationImages[0].Image)
+	require.NoError(t, err)
+	iiDesc, err := remote.Get(iiRef, l.Options...)
+	require.NoError(t, err)
+	assert.Equal(t, iiDesc.Digest, invocation.Digest)
+
+	component := m.Manifests[2]
+	assert.Equal(t, ManifestTypeComponent, component.Annotations[ManifestTypeAnnotation])
+	assert.Equal(t, "web", component.Annotations[ComponentNameAnnotation])
+	assert.Equal(t, b.Images["web"].Digest, component.Digest.String())
+
+	// The image manifests are copied to the repository of the bundle
+	for _, d := range m.Manifests[1:] {
+		_, err := remote.Get(r.Context().Digest(d.Digest.String()), l.Options...)
+		assert.NoError(t, err, "the manifest %s should be in the repository of the bundle", d.Digest)
+	}
+}
+
+func TestRegistryLoader_Pull_Errors(t *testing.T) {
+	host, stop := newTestRegistry(t)
+	defer stop()
+
+	l := newTestRegistryLoader()
+
+	t.Run("missing tag", func(t *testing.T) {
+		_, _, err := l.Pull(host + "/cnab/missing:v1.0.0")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot fetch bundle "+host+"/cnab/missing:v1.0.0")
+	})
+
+	t.Run("invalid reference", func(t *testing.T) {
+		_, _, err := l.Pull("INVALID/Ref:")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid bundle reference INVALID/Ref:")
+	})
+
+	t.Run("not a bundle", func(t *testing.T) {
+		img, err := random.Image(64, 1)
+		require.NoError(t, err)
+		r, err := name.ParseReference(host + "/cnab/image:v1")
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(r, img, l.Options...))
+
+		_, _, err = l.Pull(host + "/cnab/image:v1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the config of the manifest has the media type application/vnd.docker.container.image.v1+json instead of "+string(ConfigMediaType))
+	})
+}
+
+func TestRegistryLoader_Push_Invalid(t *testing.T) {
+	host, stop := newTestRegistry(t)
+	defer stop()
+
+	l := newTestRegistryLoader()
+	ref := host + "/cnab/mybun:v1.0.0"
+
+	t.Run("invalid bundle", func(t *testing.T) {
+		b := registryBundle(t, host)
+		b.InvocationImages = nil
+
+		_, err := l.Push(b, ref)
+		assert.EqualError(t, err, "cannot push an invalid bundle: at least one invocation image must be defined in the bundle")
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		b := registryBundle(t, host)
+		b.InvocationImages[0].Image = host + "/cnab/missing:v1"
+
+		_, err := l.Push(b, ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot push invocation image 0 of bundle "+ref+": cannot fetch image "+host+"/cnab/missing:v1")
+	})
+
+	t.Run("missing digest", func(t *testing.T) {
+		b := registryBundle(t, host)
+		web := b.Images["web"]
+		web.Image = host + "/cnab/mybunii:def456"
+		b.Images["web"] = web
+
+		_, err := l.Push(b, ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot push image web of bundle "+ref+": cannot fetch image "+host+"/cnab/mybunii@"+web.Digest)
+	})
+
+	t.Run("unsupported image type", func(t *testing.T) {
+		b := registryBundle(t, host)
+		b.InvocationImages[0].ImageType = "qcow2"
+
+		_, err := l.Push(b, ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported image type qcow2")
+	})
+}
@@ -0,0 +1,39 @@
+package loader
+
+import (
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/signature"
+)
+
+var _ BundleLoader = &SignedLoader{}
+
+// SignedLoader loads a clear-signed bundle (bundle.cnab), and only returns the
+// bundle after its signature has been verified against the trusted keys.
+// Unsigned bundles are rejected.
+type SignedLoader struct {
+	// TrustedKeys are the public keys that are allowed to sign bundles.
+	TrustedKeys signature.KeyRing
+}
+
+// NewSignedLoader creates a loader for signed bundle files that trusts the
+// specified keys.
+func NewSignedLoader(trusted signature.KeyRing) *SignedLoader {
+	return &SignedLoader{
+		TrustedKeys: trusted,
+	}
+}
+
+// Load loads and verifies the given signed bundle.
+func (l *SignedLoader) Load(filename string) (*bundle.Bundle, error) {
+	data, err := loadData(filename)
+	if err != nil {
+		return &bundle.Bundle{}, err
+	}
+	return l.LoadData(data)
+}
+
+// LoadData loads a Bundle from the given signed bundle data, verifying its
+// signature.
+func (l *SignedLoader) LoadData(data []byte) (*bundle.Bundle, error) {
+	return signature.Verify(data, l.TrustedKeys)
+}
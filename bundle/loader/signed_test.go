@@ -0,0 +1,57 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/cnabio/cnab-go/bundle/signature"
+)
+
+func TestSignedLoader(t *testing.T) {
+	b, err := NewLoader().Load(testFooJSON)
+	require.NoError(t, err, "could not load test bundle")
+
+	key, err := openpgp.NewEntity("Test", "", "test@example.com", &packet.Config{RSABits: 1024})
+	require.NoError(t, err, "NewEntity failed")
+
+	signed, err := signature.Sign(*b, key)
+	require.NoError(t, err, "Sign failed")
+
+	dir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err, "TempDir failed")
+	defer os.RemoveAll(dir)
+
+	bundleFile := filepath.Join(dir, "bundle.cnab")
+	require.NoError(t, ioutil.WriteFile(bundleFile, signed, 0644))
+
+	t.Run("trusted key", func(t *testing.T) {
+		l := NewSignedLoader(signature.NewKeyRing(key))
+		got, err := l.Load(bundleFile)
+		require.NoError(t, err, "Load failed")
+		assert.Equal(t, "mybun", got.Name)
+		assert.Equal(t, "v1.0.0", got.Version)
+	})
+
+	t.Run("untrusted key", func(t *testing.T) {
+		other, err := openpgp.NewEntity("Other", "", "other@example.com", &packet.Config{RSABits: 1024})
+		require.NoError(t, err, "NewEntity failed")
+
+		l := NewSignedLoader(signature.NewKeyRing(other))
+		_, err = l.Load(bundleFile)
+		assert.Equal(t, signature.ErrNotVerified, err)
+	})
+
+	t.Run("unsigned bundle", func(t *testing.T) {
+		l := NewSignedLoader(signature.NewKeyRing(key))
+		_, err = l.Load(testFooJSON)
+		require.Error(t, err)
+		assert.Equal(t, signature.ErrNotSigned, err)
+	})
+}
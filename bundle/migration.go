@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/schema"
+)
+
+// Migrations upgrade bundles written with earlier versions of the CNAB Core
+// specification, in order.
+var Migrations = []schema.Migration{
+	{From: "v1.0.0-WD", To: "v1.0.0", Migrate: flattenFields},
+	{From: "v1.0.0", To: "1.0.1"},
+}
+
+// NewMigrator creates a migrator that upgrades bundles to the schema version
+// that this library implements.
+func NewMigrator() (schema.Migrator, error) {
+	current, err := GetDefaultSchemaVersion()
+	if err != nil {
+		return schema.Migrator{}, err
+	}
+	return schema.Migrator{Current: current, Migrations: Migrations}, nil
+}
+
+// MigrateDocument upgrades a decoded bundle.json to the schema version that
+// this library implements, and reports whether it was changed.
+func MigrateDocument(doc map[string]interface{}) (bool, error) {
+	m, err := NewMigrator()
+	if err != nil {
+		return false, err
+	}
+	return m.Migrate(doc)
+}
+
+// Migrate upgrades a bundle.json to the schema version that this library
+// implements, and unmarshals it.
+func Migrate(data []byte) (*Bundle, error) {
+	m, err := NewMigrator()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err = m.MigrateData(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot migrate the bundle")
+	}
+	return Unmarshal(data)
+}
+
+// flattenFields upgrades the parameters and outputs of the working draft,
+// which were nested in a fields object, with the names of the required
+// parameters in a list next to it.
+func flattenFields(doc map[string]interface{}) error {
+	if params, ok := doc["parameters"].(map[string]interface{}); ok {
+		fields, err := nestedFields("parameters", params)
+		if err != nil {
+			return err
+		}
+		required, err := requiredNames(params["required"])
+		if err != nil {
+			return err
+		}
+		for _, name := range required {
+			param, ok := fields[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("the required parameter %s is not defined", name)
+			}
+			param["required"] = true
+		}
+		doc["parameters"] = fields
+	}
+
+	if outputs, ok := doc["outputs"].(map[string]interface{}); ok {
+		fields, err := nestedFields("outputs", outputs)
+		if err != nil {
+			return err
+		}
+		doc["outputs"] = fields
+	}
+	return nil
+}
+
+func nestedFields(key string, value map[string]interface{}) (map[string]interface{}, error) {
+	fields, ok := value["fields"]
+	if !ok || fields == nil {
+		return map[string]interface{}{}, nil
+	}
+	m, ok := fields.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s.fields, it must be an object", key)
+	}
+	return m, nil
+}
+
+func requiredNames(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	// Round trip the list, to accept both decoded JSON and Go values
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, errors.New("invalid parameters.required, it must be a list of parameter names")
+	}
+	return names, nil
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/schema"
+)
+
+// workingDraftBundle is a bundle written with the working draft of the CNAB
+// Core specification, with the parameters and outputs nested in fields.
+const workingDraftBundle = `{
+  "schemaVersion": "v1.0.0-WD",
+  "name": "mybun",
+  "version": "v1.0.0",
+  "invocationImages": [{"imageType": "docker", "image": "cnabio/mybunii:def456"}],
+  "definitions": {
+    "port": {"type": "integer", "default": 8080},
+    "url": {"type": "string"}
+  },
+  "parameters": {
+    "fields": {
+      "port": {"definition": "port", "destination": {"env": "PORT"}},
+      "debug": {"definition": "port", "destination": {"env": "DEBUG"}}
+    },
+    "required": ["port"]
+  },
+  "outputs": {
+    "fields": {
+      "url": {"definition": "url", "path": "/cnab/app/outputs/url"}
+    }
+  }
+}`
+
+func TestMigrate(t *testing.T) {
+	b, err := Migrate([]byte(workingDraftBundle))
+	require.NoError(t, err)
+
+	assert.Equal(t, schema.Version("1.0.1"), b.SchemaVersion)
+	require.Len(t, b.Parameters, 2)
+	assert.True(t, b.Parameters["port"].Required)
+	assert.False(t, b.Parameters["debug"].Required)
+	assert.Equal(t, "PORT", b.Parameters["port"].Destination.EnvironmentVariable)
+	require.Len(t, b.Outputs, 1)
+	assert.Equal(t, "/cnab/app/outputs/url", b.Outputs["url"].Path)
+	assert.Equal(t, 8080, int(b.Definitions["port"].Default.(float64)))
+	assert.NoError(t, b.Validate())
+}
+
+func TestMigrate_Current(t *testing.T) {
+	b, err := Migrate([]byte(`{"schemaVersion": "v1.0.0", "name": "mybun", "parameters": {"port": {"definition": "port", "required": true}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, schema.Version("1.0.1"), b.SchemaVersion)
+	assert.True(t, b.Parameters["port"].Required)
+
+	b, err = Migrate([]byte(`{"schemaVersion": "1.0.1", "name": "mybun"}`))
+	require.NoError(t, err)
+	assert.Equal(t, schema.Version("1.0.1"), b.SchemaVersion)
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "no schemaVersion",
+			data: `{"name": "mybun"}`,
+			err:  "cannot migrate the bundle: cannot migrate a document without a schemaVersion",
+		},
+		{
+			name: "newer",
+			data: `{"schemaVersion": "v2.0.0", "name": "mybun"}`,
+			err:  "cannot migrate the bundle: the schema version v2.0.0 is newer than the supported schema version 1.0.1",
+		},
+		{
+			name: "undefined required parameter",
+			data: `{"schemaVersion": "v1.0.0-WD", "parameters": {"fields": {}, "required": ["port"]}}`,
+			err:  "cannot migrate the bundle: cannot migrate the document from schema version v1.0.0-WD to v1.0.0: the required parameter port is not defined",
+		},
+		{
+			name: "invalid required parameters",
+			data: `{"schemaVersion": "v1.0.0-WD", "parameters": {"required": "port"}}`,
+			err:  "cannot migrate the bundle: cannot migrate the document from schema version v1.0.0-WD to v1.0.0: invalid parameters.required, it must be a list of parameter names",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Migrate([]byte(tc.data))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ParameterSourcesExtensionKey represents the full key for the Parameter Sources Extension
+	ParameterSourcesExtensionKey = "io.cnab.parameter-sources"
+
+	// ParameterSourceTypeOutput defines a type of parameter source that is
+	// provided by an output of the bundle, from the last time that it was
+	// run for the installation.
+	ParameterSourceTypeOutput = "output"
+)
+
+// ParameterSources describes the set of custom extension metadata associated
+// with the parameter sources extension, keyed by the name of the parameter.
+type ParameterSources map[string]ParameterSource
+
+// ParameterSource defines where the value of a parameter may come from, when
+// it is not set.
+type ParameterSource struct {
+	// Priority is the order that the sources are tried, by their type in Sources.
+	Priority []string `json:"priority" yaml:"priority"`
+
+	// Sources is a map of the sources of the parameter, keyed by the type of
+	// source, for example ParameterSourceTypeOutput.
+	Sources map[string]ParameterSourceDefinition `json:"sources" yaml:"sources"`
+}
+
+// ParameterSourceDefinition defines a source of a parameter value.
+type ParameterSourceDefinition struct {
+	// Name of the source, for example the name of the output.
+	Name string `json:"name" yaml:"name"`
+}
+
+// ReadParameterSources reads the parameter sources extension from the Custom
+// section of the bundle. The returned boolean is false when the bundle does
+// not have parameter sources.
+func (b Bundle) ReadParameterSources() (ParameterSources, bool, error) {
+	var ps ParameterSources
+
+	data, ok := b.Custom[ParameterSourcesExtensionKey]
+	if !ok {
+		return ps, false, nil
+	}
+
+	dataB, err := json.Marshal(data)
+	if err != nil {
+		return ps, true, errors.Wrapf(err, "could not marshal the %s extension", ParameterSourcesExtensionKey)
+	}
+	err = json.Unmarshal(dataB, &ps)
+	if err != nil {
+		return ps, true, errors.Wrapf(err, "could not unmarshal the %s extension", ParameterSourcesExtensionKey)
+	}
+	return ps, true, nil
+}
+
+// Validate that the parameter sources refer to the parameters and outputs of
+// the bundle.
+func (ps ParameterSources) Validate(b Bundle) error {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := b.Parameters[name]; !ok {
+			return fmt.Errorf("parameter source %s refers to a parameter that is not defined", name)
+		}
+		if err := ps[name].Validate(b); err != nil {
+			return errors.Wrapf(err, "invalid parameter source %s", name)
+		}
+	}
+	return nil
+}
+
+// Validate the parameter source.
+func (s ParameterSource) Validate(b Bundle) error {
+	for _, sourceType := range s.Priority {
+		if _, ok := s.Sources[sourceType]; !ok {
+			return fmt.Errorf("source %s is in the priority but it is not defined", sourceType)
+		}
+	}
+
+	for sourceType, source := range s.Sources {
+		if sourceType != ParameterSourceTypeOutput {
+			continue
+		}
+		if _, ok := b.Outputs[source.Name]; !ok {
+			return fmt.Errorf("output %q is not defined", source.Name)
+		}
+	}
+	return nil
+}
+
+// ListSourcesByPriority returns the types of the sources, in the order of the
+// priority, followed by the remaining sources sorted by type.
+func (s ParameterSource) ListSourcesByPriority() []string {
+	types := make([]string, 0, len(s.Sources))
+	seen := make(map[string]bool, len(s.Priority))
+	for _, sourceType := range s.Priority {
+		if _, ok := s.Sources[sourceType]; ok && !seen[sourceType] {
+			types = append(types, sourceType)
+			seen[sourceType] = true
+		}
+	}
+
+	var remaining []string
+	for sourceType := range s.Sources {
+		if !seen[sourceType] {
+			remaining = append(remaining, sourceType)
+		}
+	}
+	sort.Strings(remaining)
+	return append(types, remaining...)
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func TestReadParameterSources(t *testing.T) {
+	b := Bundle{
+		Parameters: map[string]Parameter{
+			"tfstate": {Definition: "tfstate"},
+		},
+		Outputs: map[string]Output{
+			"tfstate": {Definition: "tfstate"},
+		},
+		Custom: map[string]interface{}{
+			ParameterSourcesExtensionKey: map[string]interface{}{
+				"tfstate": map[string]interface{}{
+					"priority": []interface{}{"output"},
+					"sources": map[string]interface{}{
+						"output": map[string]interface{}{"name": "tfstate"},
+					},
+				},
+			},
+		},
+	}
+
+	ps, ok, err := b.ReadParameterSources()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, ParameterSources{
+		"tfstate": {
+			Priority: []string{ParameterSourceTypeOutput},
+			Sources: map[string]ParameterSourceDefinition{
+				ParameterSourceTypeOutput: {Name: "tfstate"},
+			},
+		},
+	}, ps)
+	assert.NoError(t, ps.Validate(b))
+
+	_, ok, err = Bundle{}.ReadParameterSources()
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestParameterSources_Validate(t *testing.T) {
+	b := Bundle{
+		Parameters: map[string]Parameter{"tfstate": {Definition: "tfstate"}},
+		Outputs:    map[string]Output{"tfstate": {Definition: "tfstate"}},
+	}
+
+	testcases := []struct {
+		name    string
+		sources ParameterSources
+		wantErr string
+	}{
+		{
+			name:    "unknown parameter",
+			sources: ParameterSources{"missing": {}},
+			wantErr: "parameter source missing refers to a parameter that is not defined",
+		},
+		{
+			name:    "unknown priority",
+			sources: ParameterSources{"tfstate": {Priority: []string{"output"}}},
+			wantErr: "invalid parameter source tfstate: source output is in the priority but it is not defined",
+		},
+		{
+			name: "unknown output",
+			sources: ParameterSources{"tfstate": {
+				Sources: map[string]ParameterSourceDefinition{"output": {Name: "missing"}},
+			}},
+			wantErr: `invalid parameter source tfstate: output "missing" is not defined`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.sources.Validate(b)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestParameterSource_ListSourcesByPriority(t *testing.T) {
+	s := ParameterSource{
+		Priority: []string{"output", "missing"},
+		Sources: map[string]ParameterSourceDefinition{
+			"dependencies.output": {Name: "b"},
+			"custom":              {Name: "c"},
+			"output":              {Name: "a"},
+		},
+	}
+	assert.Equal(t, []string{"output", "custom", "dependencies.output"}, s.ListSourcesByPriority())
+}
+
+func TestBundle_IsParameterSensitive(t *testing.T) {
+	writeOnly := true
+	b := Bundle{
+		Definitions: map[string]*definition.Schema{
+			"port":     {Type: "integer"},
+			"password": {Type: "string", WriteOnly: &writeOnly},
+		},
+		Parameters: map[string]Parameter{
+			"port":     {Definition: "port"},
+			"password": {Definition: "password"},
+			"no-def":   {Definition: "no-def"},
+		},
+	}
+
+	sensitive, err := b.IsParameterSensitive("port")
+	require.NoError(t, err)
+	assert.False(t, sensitive)
+
+	sensitive, err = b.IsParameterSensitive("password")
+	require.NoError(t, err)
+	assert.True(t, sensitive)
+
+	_, err = b.IsParameterSensitive("no-def")
+	require.EqualError(t, err, `parameter definition "no-def" not found`)
+
+	_, err = b.IsParameterSensitive("missing")
+	require.EqualError(t, err, `parameter "missing" not defined`)
+}
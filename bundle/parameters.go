@@ -1,6 +1,10 @@
 package bundle
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // Parameter defines a single parameter for a CNAB bundle
 type Parameter struct {
@@ -35,3 +39,73 @@ func (p *Parameter) Validate() error {
 	}
 	return p.Destination.Validate()
 }
+
+// IsParameterSensitive is a convenience function that determines if a parameter's
+// value is sensitive.
+func (b Bundle) IsParameterSensitive(parameterName string) (bool, error) {
+	if param, ok := b.Parameters[parameterName]; ok {
+		if def, ok := b.Definitions[param.Definition]; ok {
+			sensitive := def.WriteOnly != nil && *def.WriteOnly
+			return sensitive, nil
+		}
+
+		return false, fmt.Errorf("parameter definition %q not found", param.Definition)
+	}
+
+	return false, fmt.Errorf("parameter %q not defined", parameterName)
+}
+
+// ParameterError describes a parameter value that failed validation.
+type ParameterError struct {
+	// Parameter name.
+	Parameter string
+
+	// Path is the JSON Pointer to the invalid value within the parameter
+	// values, for example /port, or /config/replicas for a property of an
+	// object parameter.
+	Path string
+
+	// Keyword is the JSON Schema keyword that failed validation, for example
+	// "minimum", or "required" when a required parameter is missing. It is
+	// empty when the keyword cannot be determined.
+	Keyword string
+
+	// Message describes why the value is invalid.
+	Message string
+
+	// Value that failed validation, which is nil for a missing parameter.
+	Value interface{}
+}
+
+func (e ParameterError) Error() string {
+	if e.Keyword == "required" && e.Value == nil {
+		return fmt.Sprintf("parameter %q is required", e.Parameter)
+	}
+	if e.Path != parameterPath(e.Parameter, "") {
+		return fmt.Sprintf("cannot use value: %v as parameter %s at %s: %s", e.Value, e.Parameter, e.Path, e.Message)
+	}
+	return fmt.Sprintf("cannot use value: %v as parameter %s: %s", e.Value, e.Parameter, e.Message)
+}
+
+// ParameterValidationErrors is returned when parameter values fail
+// validation, and lists every invalid value, ordered by parameter name.
+type ParameterValidationErrors []ParameterError
+
+func (e ParameterValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = "\t* " + err.Error()
+	}
+	return fmt.Sprintf("%d parameter validation errors occurred:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// parameterPath returns the JSON Pointer to a value of the parameter, where
+// propertyPath is the JSON Pointer to the value within the parameter's value.
+func parameterPath(name string, propertyPath string) string {
+	name = strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	return "/" + name + strings.TrimSuffix(propertyPath, "/")
+}
@@ -0,0 +1,127 @@
+package signature
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// KeyRing is a set of trusted OpenPGP public keys.
+type KeyRing openpgp.EntityList
+
+// NewKeyRing creates a KeyRing containing the specified OpenPGP keys.
+func NewKeyRing(keys ...*openpgp.Entity) KeyRing {
+	return KeyRing(keys)
+}
+
+// LoadKeyRing creates a KeyRing from OpenPGP public key files, for example
+// the output of gpg --export. Both armored and binary keys are supported.
+func LoadKeyRing(paths ...string) (KeyRing, error) {
+	var kr KeyRing
+	for _, path := range paths {
+		keys, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kr = append(kr, keys...)
+	}
+	return kr, nil
+}
+
+// LoadSigningKey reads an OpenPGP private key from a file, for example the
+// output of gpg --export-secret-keys. When the key is encrypted, it is
+// decrypted with the passphrase.
+func LoadSigningKey(path string, passphrase []byte) (*openpgp.Entity, error) {
+	keys, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.PrivateKey == nil {
+			continue
+		}
+		if err := decryptKey(key, passphrase); err != nil {
+			return nil, errors.Wrapf(err, "could not decrypt the private key %s", path)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("no private key found in %s", path)
+}
+
+func readKeyFile(path string) (openpgp.EntityList, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys openpgp.EntityList
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		keys, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keys, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read OpenPGP keys from %s", path)
+	}
+	return keys, nil
+}
+
+// decryptKey decrypts the private key and subkeys of the entity.
+func decryptKey(key *openpgp.Entity, passphrase []byte) error {
+	privateKeys := []*packet.PrivateKey{key.PrivateKey}
+	for _, subkey := range key.Subkeys {
+		if subkey.PrivateKey != nil {
+			privateKeys = append(privateKeys, subkey.PrivateKey)
+		}
+	}
+
+	for _, pk := range privateKeys {
+		if !pk.Encrypted {
+			continue
+		}
+		if len(passphrase) == 0 {
+			return errors.New("the key is encrypted and no passphrase was provided")
+		}
+		if err := pk.Decrypt(passphrase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signingKey returns the private key of the entity that can make signatures:
+// the primary key, or else a signing subkey.
+func signingKey(key *openpgp.Entity) (*packet.PrivateKey, error) {
+	id := key.PrimaryKey.KeyIdString()
+	if key.PrivateKey == nil {
+		return nil, fmt.Errorf("key %s does not have a private key", id)
+	}
+	if canSign(primarySelfSignature(key)) {
+		return key.PrivateKey, nil
+	}
+	for _, subkey := range key.Subkeys {
+		if subkey.PrivateKey != nil && canSign(subkey.Sig) {
+			return subkey.PrivateKey, nil
+		}
+	}
+	return nil, fmt.Errorf("key %s cannot make signatures", id)
+}
+
+func primarySelfSignature(key *openpgp.Entity) *packet.Signature {
+	var sig *packet.Signature
+	for _, ident := range key.Identities {
+		if sig == nil || (ident.SelfSignature.IsPrimaryId != nil && *ident.SelfSignature.IsPrimaryId) {
+			sig = ident.SelfSignature
+		}
+	}
+	return sig
+}
+
+func canSign(sig *packet.Signature) bool {
+	return sig == nil || !sig.FlagsValid || sig.FlagSign
+}
@@ -0,0 +1,71 @@
+package signature
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func writeKey(t *testing.T, path string, blockType string, serialize func(w *bytes.Buffer) error) {
+	var data bytes.Buffer
+	if blockType == "" {
+		require.NoError(t, serialize(&data))
+	} else {
+		var raw bytes.Buffer
+		require.NoError(t, serialize(&raw))
+		w, err := armor.Encode(&data, blockType, nil)
+		require.NoError(t, err)
+		_, err = w.Write(raw.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+	require.NoError(t, ioutil.WriteFile(path, data.Bytes(), 0600))
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnab-signature")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key := newRSAKey(t)
+	privPath := filepath.Join(dir, "key.asc")
+	writeKey(t, privPath, openpgp.PrivateKeyType, func(w *bytes.Buffer) error { return key.SerializePrivate(w, nil) })
+	pubPath := filepath.Join(dir, "key.pub.asc")
+	writeKey(t, pubPath, openpgp.PublicKeyType, func(w *bytes.Buffer) error { return key.Serialize(w) })
+	binaryPubPath := filepath.Join(dir, "key.gpg")
+	writeKey(t, binaryPubPath, "", func(w *bytes.Buffer) error { return key.Serialize(w) })
+
+	signer, err := LoadSigningKey(privPath, nil)
+	require.NoError(t, err, "LoadSigningKey failed")
+
+	data, err := Sign(testBundle(), signer)
+	require.NoError(t, err, "Sign failed")
+
+	for _, path := range []string{pubPath, binaryPubPath} {
+		trusted, err := LoadKeyRing(path)
+		require.NoError(t, err, "LoadKeyRing failed")
+		require.Len(t, trusted, 1)
+		_, err = Verify(data, trusted)
+		assert.NoError(t, err, "Verify failed with %s", path)
+	}
+}
+
+func TestLoadKeyRing_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnab-signature")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key.pub")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a key"), 0600))
+
+	_, err = LoadKeyRing(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not read OpenPGP keys from "+path)
+}
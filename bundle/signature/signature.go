@@ -0,0 +1,96 @@
+// Package signature signs bundles and verifies signed bundles (bundle.cnab)
+// against a set of trusted keys.
+//
+// As defined by the CNAB Core specification, a signed bundle is the canonical
+// JSON representation of the bundle, clear-signed with OpenPGP (RFC 4880,
+// section 7), so it can also be signed and verified with tools such as gpg:
+//
+//	-----BEGIN PGP SIGNED MESSAGE-----
+//	Hash: SHA256
+//
+//	{ ...bundle... }
+//	-----BEGIN PGP SIGNATURE-----
+//	...
+//	-----END PGP SIGNATURE-----
+package signature
+
+import (
+	"bytes"
+	"crypto"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	pgpErrors "golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/cnabio/cnab-go/bundle"
+)
+
+var (
+	// ErrNoTrustedKeys is returned when verification is attempted without any trusted keys.
+	ErrNoTrustedKeys = errors.New("no trusted keys were provided to verify the bundle")
+
+	// ErrNotVerified is returned when none of the signatures on a bundle were
+	// made by a trusted key.
+	ErrNotVerified = errors.New("the bundle is not signed by a trusted key")
+
+	// ErrNotSigned is returned when verifying a bundle that is not clear-signed.
+	ErrNotSigned = errors.New("the bundle is not a clear-signed bundle.cnab")
+)
+
+// Sign the bundle with each of the specified OpenPGP keys, returning the
+// clear-signed bundle, bundle.cnab.
+func Sign(b bundle.Bundle, keys ...*openpgp.Entity) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required to sign the bundle")
+	}
+
+	data, err := b.MarshalCanonical()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal the bundle")
+	}
+
+	privateKeys := make([]*packet.PrivateKey, len(keys))
+	for i, key := range keys {
+		if privateKeys[i], err = signingKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	var signed bytes.Buffer
+	w, err := clearsign.EncodeMulti(&signed, privateKeys, &packet.Config{DefaultHash: crypto.SHA256})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign the bundle")
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, errors.Wrap(err, "could not sign the bundle")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not sign the bundle")
+	}
+	return signed.Bytes(), nil
+}
+
+// Verify the clear-signed bundle against the trusted keys, and return the
+// bundle when it was signed by a trusted key.
+func Verify(data []byte, trusted KeyRing) (*bundle.Bundle, error) {
+	if len(trusted) == 0 {
+		return nil, ErrNoTrustedKeys
+	}
+
+	block, _ := clearsign.Decode(data)
+	if block == nil {
+		return nil, ErrNotSigned
+	}
+
+	_, err := openpgp.CheckDetachedSignature(openpgp.EntityList(trusted), bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err == pgpErrors.ErrUnknownIssuer {
+		return nil, ErrNotVerified
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "signature verification failed")
+	}
+
+	return bundle.Unmarshal(block.Plaintext)
+}
@@ -0,0 +1,152 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/cnabio/cnab-go/bundle"
+)
+
+func testBundle() bundle.Bundle {
+	return bundle.Bundle{
+		SchemaVersion: "1.0.1",
+		Name:          "mybun",
+		Version:       "1.0.0",
+		InvocationImages: []bundle.InvocationImage{
+			{
+				BaseImage: bundle.BaseImage{
+					ImageType: "docker",
+					Image:     "example.com/mybun:1.0.0",
+				},
+			},
+		},
+	}
+}
+
+func newRSAKey(t *testing.T) *openpgp.Entity {
+	key, err := openpgp.NewEntity("Test", "", "test@example.com", &packet.Config{RSABits: 1024})
+	require.NoError(t, err, "NewEntity failed")
+	return key
+}
+
+func newECDSAKey(t *testing.T) *openpgp.Entity {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "GenerateKey failed")
+
+	now := time.Now()
+	key := &openpgp.Entity{
+		PrimaryKey: packet.NewECDSAPublicKey(now, &k.PublicKey),
+		PrivateKey: packet.NewECDSAPrivateKey(now, k),
+		Identities: make(map[string]*openpgp.Identity),
+	}
+	uid := packet.NewUserId("ECDSA Test", "", "ecdsa@example.com")
+	isPrimary := true
+	sig := &packet.Signature{
+		CreationTime: now,
+		SigType:      packet.SigTypePositiveCert,
+		PubKeyAlgo:   packet.PubKeyAlgoECDSA,
+		Hash:         crypto.SHA256,
+		IsPrimaryId:  &isPrimary,
+		FlagsValid:   true,
+		FlagSign:     true,
+		FlagCertify:  true,
+		IssuerKeyId:  &key.PrimaryKey.KeyId,
+	}
+	require.NoError(t, sig.SignUserId(uid.Id, key.PrimaryKey, key.PrivateKey, nil), "SignUserId failed")
+	key.Identities[uid.Id] = &openpgp.Identity{Name: uid.Id, UserId: uid, SelfSignature: sig}
+	return key
+}
+
+func TestSignAndVerify(t *testing.T) {
+	testcases := map[string]func(t *testing.T) *openpgp.Entity{
+		"rsa":   newRSAKey,
+		"ecdsa": newECDSAKey,
+	}
+
+	for name, newKey := range testcases {
+		t.Run(name, func(t *testing.T) {
+			key := newKey(t)
+			data, err := Sign(testBundle(), key)
+			require.NoError(t, err, "Sign failed")
+
+			b, err := Verify(data, NewKeyRing(key))
+			require.NoError(t, err, "Verify failed")
+			assert.Equal(t, testBundle(), *b)
+		})
+	}
+}
+
+func TestSign_ClearSigned(t *testing.T) {
+	b := testBundle()
+	b.Description = "- <html> & friends"
+	data, err := Sign(b, newRSAKey(t))
+	require.NoError(t, err, "Sign failed")
+
+	assert.True(t, bytes.HasPrefix(data, []byte("-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA256\n\n")), "the bundle should be clear-signed:\n%s", data)
+
+	block, _ := clearsign.Decode(data)
+	require.NotNil(t, block, "the signed bundle should be decoded")
+	canonical, err := b.MarshalCanonical()
+	require.NoError(t, err)
+	assert.Equal(t, string(canonical), string(bytes.TrimSuffix(block.Plaintext, []byte("\n"))), "the signed text should be the canonical bundle")
+}
+
+func TestVerify_MultipleSigners(t *testing.T) {
+	first, second := newRSAKey(t), newECDSAKey(t)
+	data, err := Sign(testBundle(), first, second)
+	require.NoError(t, err, "Sign failed")
+
+	for _, key := range []*openpgp.Entity{first, second} {
+		_, err := Verify(data, NewKeyRing(key))
+		assert.NoError(t, err, "the bundle should be verified by each of its signers")
+	}
+}
+
+func TestVerify_UntrustedKey(t *testing.T) {
+	data, err := Sign(testBundle(), newRSAKey(t))
+	require.NoError(t, err, "Sign failed")
+
+	_, err = Verify(data, NewKeyRing(newRSAKey(t)))
+	assert.Equal(t, ErrNotVerified, err)
+}
+
+func TestVerify_NoTrustedKeys(t *testing.T) {
+	data, err := Sign(testBundle(), newRSAKey(t))
+	require.NoError(t, err, "Sign failed")
+
+	_, err = Verify(data, nil)
+	assert.Equal(t, ErrNoTrustedKeys, err)
+}
+
+func TestVerify_TamperedBundle(t *testing.T) {
+	key := newECDSAKey(t)
+	data, err := Sign(testBundle(), key)
+	require.NoError(t, err, "Sign failed")
+
+	tampered := bytes.Replace(data, []byte("example.com/mybun"), []byte("example.com/evil0"), 1)
+	require.NotEqual(t, data, tampered)
+
+	_, err = Verify(tampered, NewKeyRing(key))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signature verification failed")
+}
+
+func TestVerify_UnsignedBundle(t *testing.T) {
+	b := testBundle()
+	data, err := b.MarshalCanonical()
+	require.NoError(t, err)
+
+	_, err = Verify(data, NewKeyRing(newRSAKey(t)))
+	assert.Equal(t, ErrNotSigned, err)
+}
@@ -0,0 +1,44 @@
+package bundle
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cnabio/cnab-go/utils/yamlconv"
+)
+
+// UnmarshalYAML unmarshals a bundle from YAML. The custom extensions are
+// unmarshaled with string keys for their maps, the same as they are from
+// JSON, so that the bundle can be marshaled to JSON.
+func (b *Bundle) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type wrapperType Bundle
+	if err := unmarshal((*wrapperType)(b)); err != nil {
+		return err
+	}
+	for key, value := range b.Custom {
+		b.Custom[key] = yamlconv.JSONValue(value)
+	}
+	return nil
+}
+
+// WriteYAMLFile serializes the bundle and writes it to a file as YAML.
+func (b Bundle) WriteYAMLFile(dest string, mode os.FileMode) error {
+	d, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dest, d, mode)
+}
+
+// WriteYAMLTo writes the bundle to an io.Writer as YAML.
+func (b Bundle) WriteYAMLTo(w io.Writer) (int64, error) {
+	d, err := yaml.Marshal(b)
+	if err != nil {
+		return 0, err
+	}
+	l, err := w.Write(d)
+	return int64(l), err
+}
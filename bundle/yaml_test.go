@@ -0,0 +1,59 @@
+package bundle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cnabio/cnab-go/bundle/definition"
+)
+
+func TestBundle_WriteYAMLTo(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := exampleBundle.WriteYAMLTo(&buf)
+	require.NoError(t, err)
+
+	expectedYAML, err := ioutil.ReadFile("../testdata/bundles/bundle.yaml")
+	require.NoError(t, err, "couldn't read test data")
+	assert.Equal(t, string(expectedYAML), buf.String())
+}
+
+func TestBundle_YAMLRoundTrip_Lossless(t *testing.T) {
+	b := digestBundle()
+	b.Definitions["config"] = &definition.Schema{
+		Type: "object",
+		Default: map[string]interface{}{
+			"replicas": 3,
+			"labels":   map[string]interface{}{"app": "web"},
+		},
+	}
+	b.Custom = map[string]interface{}{
+		"com.example.backup": map[string]interface{}{
+			"schedule": []interface{}{map[string]interface{}{"daily": true}},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "bundle.yaml")
+	require.NoError(t, b.WriteYAMLFile(dest, 0644))
+
+	data, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	var roundTrip Bundle
+	require.NoError(t, yaml.UnmarshalStrict(data, &roundTrip))
+
+	want, err := b.MarshalCanonical()
+	require.NoError(t, err)
+	got, err := roundTrip.MarshalCanonical()
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "the canonical JSON should not change after a YAML round trip")
+}
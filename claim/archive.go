@@ -0,0 +1,367 @@
+package claim
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+const (
+	// ArchiveSchemaVersion is the version of the installation archive format
+	// written by ExportInstallation.
+	ArchiveSchemaVersion = "1.0.0"
+
+	// ArchiveManifestPath is the path of the manifest within an installation archive.
+	ArchiveManifestPath = "manifest.json"
+)
+
+// Types of entries in an installation archive.
+const (
+	ArchiveEntryClaim  = "claim"
+	ArchiveEntryResult = "result"
+	ArchiveEntryOutput = "output"
+)
+
+// ErrInstallationExists represents an installation that cannot be imported
+// because it already exists in claim storage.
+var ErrInstallationExists = errors.New("Installation already exists")
+
+// ArchiveManifest describes the contents of an installation archive.
+type ArchiveManifest struct {
+	// SchemaVersion of the archive format, see ArchiveSchemaVersion.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Installation name.
+	Installation string `json:"installation"`
+
+	// Exported timestamp of the archive.
+	Exported time.Time `json:"exported"`
+
+	// Entries in the archive, ordered so that each claim is followed by its
+	// results, and each result by its outputs.
+	Entries []ArchiveEntry `json:"entries"`
+}
+
+// ArchiveEntry is a file in an installation archive.
+type ArchiveEntry struct {
+	// Path of the file in the archive.
+	Path string `json:"path"`
+
+	// Type of document in the file, for example ArchiveEntryClaim.
+	Type string `json:"type"`
+
+	// ClaimID of the claim, or the claim associated with the result.
+	ClaimID string `json:"claimId,omitempty"`
+
+	// ResultID of the result, or the result associated with the output.
+	ResultID string `json:"resultId,omitempty"`
+
+	// Output name.
+	Output string `json:"output,omitempty"`
+
+	// Sensitive indicates that the output is sensitive. Outputs are stored
+	// decrypted in the archive.
+	Sensitive bool `json:"sensitive,omitempty"`
+
+	// Digest of the file contents, for example sha256:abc123...
+	Digest string `json:"digest"`
+}
+
+// archiveFile is a file to write to an installation archive.
+type archiveFile struct {
+	entry ArchiveEntry
+	data  []byte
+}
+
+// ExportInstallation writes every Claim, Result and Output of the installation
+// to a tar archive, along with a manifest that records the checksum of each
+// file. Claims and sensitive outputs are decrypted with the store's
+// EncryptionHandler, so the archive should be protected accordingly.
+func (s Store) ExportInstallation(installation string, w io.Writer) error {
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	i, err := s.ReadInstallation(installation)
+	if err != nil {
+		return err
+	}
+
+	var files []archiveFile
+	for _, c := range i.Claims {
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal claim %s", c.ID)
+		}
+		files = append(files, newArchiveFile(ArchiveEntry{
+			Path:    path.Join("claims", c.ID+".json"),
+			Type:    ArchiveEntryClaim,
+			ClaimID: c.ID,
+		}, data))
+
+		if c.results == nil {
+			continue
+		}
+		for _, r := range *c.results {
+			data, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return errors.Wrapf(err, "could not marshal result %s", r.ID)
+			}
+			files = append(files, newArchiveFile(ArchiveEntry{
+				Path:     path.Join("results", c.ID, r.ID+".json"),
+				Type:     ArchiveEntryResult,
+				ClaimID:  c.ID,
+				ResultID: r.ID,
+			}, data))
+
+			outputs, err := s.ListOutputs(r.ID)
+			if err != nil {
+				return err
+			}
+			for _, name := range outputs {
+				o, err := s.ReadOutput(c, r, name)
+				if err != nil {
+					return err
+				}
+				sensitive, err := c.Bundle.IsOutputSensitive(name)
+				if err != nil {
+					return errors.Wrapf(err, "could not determine if the output %q is sensitive", name)
+				}
+				files = append(files, newArchiveFile(ArchiveEntry{
+					Path:      path.Join("outputs", r.ID, name),
+					Type:      ArchiveEntryOutput,
+					ClaimID:   c.ID,
+					ResultID:  r.ID,
+					Output:    name,
+					Sensitive: sensitive,
+				}, o.Value))
+			}
+		}
+	}
+
+	manifest := ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Installation:  installation,
+		Exported:      time.Now(),
+		Entries:       make([]ArchiveEntry, len(files)),
+	}
+	for i, f := range files {
+		manifest.Entries[i] = f.entry
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the archive manifest")
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeArchiveFile(tw, ArchiveManifestPath, manifestData, manifest.Exported); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := writeArchiveFile(tw, f.entry.Path, f.data, manifest.Exported); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(tw.Close(), "could not write the installation archive")
+}
+
+// ImportInstallation restores an installation from an archive created by
+// ExportInstallation, keeping the ids of its claims, results and outputs.
+// Claims and sensitive outputs are encrypted with the store's
+// EncryptionHandler.
+//
+// The entire archive is verified against the checksums in its manifest before
+// anything is saved, and then it is saved as a single batch, so that either the
+// whole installation is imported or none of it is. The installation must not
+// already exist in the store.
+// Returns the name of the imported installation.
+func (s Store) ImportInstallation(r io.Reader) (string, error) {
+	manifest, files, err := readInstallationArchive(r)
+	if err != nil {
+		return "", err
+	}
+
+	claims := make(map[string]Claim)
+	results := make(map[string]Result)
+	for _, entry := range manifest.Entries {
+		switch entry.Type {
+		case ArchiveEntryClaim:
+			var c Claim
+			if err := json.Unmarshal(files[entry.Path], &c); err != nil {
+				return "", errors.Wrapf(err, "could not parse claim %s", entry.Path)
+			}
+			if c.ID != entry.ClaimID {
+				return "", fmt.Errorf("claim %s does not match the archive manifest, expected claim %s", entry.Path, entry.ClaimID)
+			}
+			if c.Installation != manifest.Installation {
+				return "", fmt.Errorf("claim %s belongs to installation %s instead of %s", c.ID, c.Installation, manifest.Installation)
+			}
+			claims[c.ID] = c
+		case ArchiveEntryResult:
+			var r Result
+			if err := json.Unmarshal(files[entry.Path], &r); err != nil {
+				return "", errors.Wrapf(err, "could not parse result %s", entry.Path)
+			}
+			if r.ID != entry.ResultID || r.ClaimID != entry.ClaimID {
+				return "", fmt.Errorf("result %s does not match the archive manifest, expected result %s of claim %s", entry.Path, entry.ResultID, entry.ClaimID)
+			}
+			if _, ok := claims[r.ClaimID]; !ok {
+				return "", fmt.Errorf("result %s references claim %s which is not in the archive", r.ID, r.ClaimID)
+			}
+			results[r.ID] = r
+		case ArchiveEntryOutput:
+			if _, ok := results[entry.ResultID]; !ok {
+				return "", fmt.Errorf("output %s references result %s which is not in the archive", entry.Output, entry.ResultID)
+			}
+		default:
+			return "", fmt.Errorf("invalid entry type %q for %s in the archive manifest", entry.Type, entry.Path)
+		}
+	}
+
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.ListClaims(manifest.Installation)
+	if err == nil {
+		return "", errors.Wrapf(ErrInstallationExists, "cannot import installation %s", manifest.Installation)
+	} else if err != ErrInstallationNotFound {
+		return "", err
+	}
+
+	// Save everything in a single batch, so that a failed import does not
+	// leave part of the installation behind
+	var batch crud.Batch
+	for _, entry := range manifest.Entries {
+		switch entry.Type {
+		case ArchiveEntryClaim:
+			c := claims[entry.ClaimID]
+			data, err := s.encodeClaim(c)
+			if err != nil {
+				return "", errors.Wrapf(err, "could not import %s", entry.Path)
+			}
+			batch.Save(ItemTypeClaims, c.Installation, c.ID, data)
+		case ArchiveEntryResult:
+			r := results[entry.ResultID]
+			data, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return "", errors.Wrapf(err, "could not import %s", entry.Path)
+			}
+			batch.Save(ItemTypeResults, r.ClaimID, r.ID, data)
+		case ArchiveEntryOutput:
+			r := results[entry.ResultID]
+			o := NewOutput(claims[r.ClaimID], r, entry.Output, files[entry.Path])
+			data, err := s.encodeOutput(o)
+			if err != nil {
+				return "", errors.Wrapf(err, "could not import %s", entry.Path)
+			}
+			batch.Save(ItemTypeOutputs, r.ID, s.outputKey(r.ID, o.Name), data)
+		}
+	}
+	batch.Save(ItemTypeInstallations, "", manifest.Installation, nil)
+
+	if err := s.backingStore.CommitBatch(batch); err != nil {
+		return "", errors.Wrapf(err, "could not import installation %s", manifest.Installation)
+	}
+	if err := s.saveInstallationFields(manifest.Installation); err != nil {
+		return "", err
+	}
+
+	return manifest.Installation, nil
+}
+
+// readInstallationArchive reads the manifest and files from an installation
+// archive, verifying that the files match the manifest.
+func readInstallationArchive(r io.Reader) (ArchiveManifest, map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ArchiveManifest{}, nil, errors.Wrap(err, "could not read the installation archive")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return ArchiveManifest{}, nil, errors.Wrapf(err, "could not read %s from the installation archive", header.Name)
+		}
+		files[header.Name] = data
+	}
+
+	manifestData, ok := files[ArchiveManifestPath]
+	if !ok {
+		return ArchiveManifest{}, nil, fmt.Errorf("invalid installation archive, %s is missing", ArchiveManifestPath)
+	}
+	delete(files, ArchiveManifestPath)
+
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return ArchiveManifest{}, nil, errors.Wrap(err, "could not parse the archive manifest")
+	}
+	if manifest.SchemaVersion != ArchiveSchemaVersion {
+		return ArchiveManifest{}, nil, fmt.Errorf("unsupported installation archive schema version %q, expected %q", manifest.SchemaVersion, ArchiveSchemaVersion)
+	}
+	if manifest.Installation == "" {
+		return ArchiveManifest{}, nil, errors.New("invalid installation archive, the manifest does not specify an installation")
+	}
+
+	for _, entry := range manifest.Entries {
+		data, ok := files[entry.Path]
+		if !ok {
+			return ArchiveManifest{}, nil, fmt.Errorf("invalid installation archive, %s is missing", entry.Path)
+		}
+		if digest := archiveDigest(data); digest != entry.Digest {
+			return ArchiveManifest{}, nil, fmt.Errorf("invalid installation archive, the checksum of %s is %s but the manifest expected %s", entry.Path, digest, entry.Digest)
+		}
+	}
+	if len(files) != len(manifest.Entries) {
+		return ArchiveManifest{}, nil, errors.New("invalid installation archive, it contains files that are not in the manifest")
+	}
+
+	return manifest, files, nil
+}
+
+func newArchiveFile(entry ArchiveEntry, data []byte) archiveFile {
+	entry.Digest = archiveDigest(data)
+	return archiveFile{entry: entry, data: data}
+}
+
+func archiveDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "could not write %s to the installation archive", name)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return errors.Wrapf(err, "could not write %s to the installation archive", name)
+	}
+	return nil
+}
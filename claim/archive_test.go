@@ -0,0 +1,245 @@
+package claim
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// reverse is an EncryptionHandler that is different from the base64 handlers
+// used by the source store in the archive tests.
+var reverse = func(src []byte) ([]byte, error) {
+	dst := make([]byte, len(src))
+	for i, b := range src {
+		dst[len(src)-1-i] = b
+	}
+	return dst, nil
+}
+
+// generateArchiveData creates an installation with two claims, with results
+// and outputs, in a store that encrypts with base64.
+func generateArchiveData(t *testing.T) (Store, []Claim) {
+	writeOnly := true
+	b := bundle.Bundle{
+		Name: "mybun",
+		Definitions: map[string]*definition.Schema{
+			"password": {Type: "string", WriteOnly: &writeOnly},
+			"port":     {Type: "string"},
+		},
+		Outputs: map[string]bundle.Output{
+			"password": {Definition: "password"},
+			"port":     {Definition: "port"},
+		},
+	}
+
+	s := NewMockStore(b64encode, b64decode)
+	c1, err := New("wordpress", ActionInstall, b, map[string]interface{}{"color": "blue"})
+	require.NoError(t, err, "New claim failed")
+	c2, err := c1.NewClaim(ActionUpgrade, b, nil)
+	require.NoError(t, err, "NewClaim failed")
+
+	for _, c := range []Claim{c1, c2} {
+		require.NoError(t, s.SaveClaim(c), "SaveClaim failed")
+		r1, err := c.NewResult(StatusRunning)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, s.SaveResult(r1), "SaveResult failed")
+		r2, err := c.NewResult(StatusSucceeded)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, s.SaveResult(r2), "SaveResult failed")
+
+		require.NoError(t, s.SaveOutput(NewOutput(c, r2, "password", []byte("secret-"+c.Action))), "SaveOutput failed")
+		require.NoError(t, s.SaveOutput(NewOutput(c, r2, "port", []byte("8080"))), "SaveOutput failed")
+	}
+
+	return s, []Claim{c1, c2}
+}
+
+func exportInstallation(t *testing.T, s Store, installation string) []byte {
+	var archive bytes.Buffer
+	require.NoError(t, s.ExportInstallation(installation, &archive), "ExportInstallation failed")
+	return archive.Bytes()
+}
+
+func TestStore_ExportImportInstallation(t *testing.T) {
+	src, claims := generateArchiveData(t)
+	archive := exportInstallation(t, src, "wordpress")
+
+	tempDir, err := ioutil.TempDir("", "cnabtest")
+	require.NoError(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tempDir)
+	datastore := crud.NewFileSystemStore(filepath.Join(tempDir, "claimstore"), NewClaimStoreFileExtensions())
+	dest := NewClaimStore(crud.NewBackingStore(datastore), reverse, reverse)
+
+	installation, err := dest.ImportInstallation(bytes.NewReader(archive))
+	require.NoError(t, err, "ImportInstallation failed")
+	assert.Equal(t, "wordpress", installation)
+
+	want, err := src.ReadInstallation("wordpress")
+	require.NoError(t, err, "ReadInstallation failed")
+	got, err := dest.ReadInstallation("wordpress")
+	require.NoError(t, err, "ReadInstallation failed")
+
+	require.Len(t, got.Claims, len(claims))
+	for i, c := range got.Claims {
+		assert.Equal(t, claims[i].ID, c.ID, "the claim ids and order should be kept")
+		assert.Equal(t, claims[i].Revision, c.Revision)
+		assert.Equal(t, claims[i].Action, c.Action)
+		assert.Equal(t, claims[i].Created.Unix(), c.Created.Unix())
+
+		wantResults := *want.Claims[i].results
+		gotResults := *c.results
+		require.Len(t, gotResults, len(wantResults))
+		for j := range gotResults {
+			assert.Equal(t, wantResults[j].ID, gotResults[j].ID, "the result ids and order should be kept")
+			assert.Equal(t, wantResults[j].Status, gotResults[j].Status)
+		}
+
+		lastResult := gotResults[len(gotResults)-1]
+		password, err := dest.ReadOutput(c, lastResult, "password")
+		require.NoError(t, err, "ReadOutput failed")
+		assert.Equal(t, "secret-"+c.Action, string(password.Value))
+
+		// Verify that the sensitive output was re-encrypted with the destination's handler
+		raw, err := datastore.Read(ItemTypeOutputs, dest.outputKey(lastResult.ID, "password"))
+		require.NoError(t, err, "could not read raw output data")
+		wantRaw, _ := reverse([]byte("secret-" + c.Action))
+		assert.Equal(t, string(wantRaw), string(raw))
+
+		port, err := dest.ReadOutput(c, lastResult, "port")
+		require.NoError(t, err, "ReadOutput failed")
+		assert.Equal(t, "8080", string(port.Value))
+	}
+
+	t.Run("installation already exists", func(t *testing.T) {
+		_, err := dest.ImportInstallation(bytes.NewReader(archive))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInstallationExists.Error())
+	})
+}
+
+func TestStore_ExportInstallation_Manifest(t *testing.T) {
+	src, claims := generateArchiveData(t)
+	archive := exportInstallation(t, src, "wordpress")
+
+	manifest, files, err := readInstallationArchive(bytes.NewReader(archive))
+	require.NoError(t, err, "readInstallationArchive failed")
+
+	assert.Equal(t, ArchiveSchemaVersion, manifest.SchemaVersion)
+	assert.Equal(t, "wordpress", manifest.Installation)
+	require.Len(t, manifest.Entries, 10, "expected 2 claims, 4 results and 4 outputs")
+	assert.Len(t, files, 10)
+
+	first := manifest.Entries[0]
+	assert.Equal(t, ArchiveEntryClaim, first.Type)
+	assert.Equal(t, claims[0].ID, first.ClaimID)
+	assert.Equal(t, "claims/"+claims[0].ID+".json", first.Path)
+	assert.Contains(t, first.Digest, "sha256:")
+
+	password := manifest.Entries[3]
+	assert.Equal(t, ArchiveEntryOutput, password.Type)
+	assert.Equal(t, "password", password.Output)
+	assert.True(t, password.Sensitive)
+	assert.Equal(t, "secret-install", string(files[password.Path]), "outputs should be decrypted in the archive")
+}
+
+func TestStore_ImportInstallation_Invalid(t *testing.T) {
+	src, _ := generateArchiveData(t)
+	archive := exportInstallation(t, src, "wordpress")
+
+	// rewrite copies the archive, changing the contents of the matching file
+	rewrite := func(t *testing.T, name string, change func([]byte) []byte) []byte {
+		var result bytes.Buffer
+		tr := tar.NewReader(bytes.NewReader(archive))
+		tw := tar.NewWriter(&result)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			data, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+
+			if header.Name == name {
+				data = change(data)
+				if data == nil {
+					continue
+				}
+				header.Size = int64(len(data))
+			}
+			require.NoError(t, tw.WriteHeader(header))
+			_, err = tw.Write(data)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		return result.Bytes()
+	}
+
+	manifest, _, err := readInstallationArchive(bytes.NewReader(archive))
+	require.NoError(t, err, "readInstallationArchive failed")
+	outputPath := manifest.Entries[3].Path
+
+	testcases := []struct {
+		name    string
+		archive []byte
+		wantErr string
+	}{
+		{"tampered file", rewrite(t, outputPath, func(data []byte) []byte { return []byte("tampered") }), "the checksum of " + outputPath},
+		{"missing file", rewrite(t, outputPath, func(data []byte) []byte { return nil }), outputPath + " is missing"},
+		{"missing manifest", rewrite(t, ArchiveManifestPath, func(data []byte) []byte { return nil }), "manifest.json is missing"},
+		{"not an archive", []byte("oops"), "could not read the installation archive"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := NewMockStore(nil, nil)
+			_, err := dest.ImportInstallation(bytes.NewReader(tc.archive))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+
+			installations, err := dest.ListInstallations()
+			require.NoError(t, err, "ListInstallations failed")
+			assert.Empty(t, installations, "nothing should be imported from an invalid archive")
+		})
+	}
+}
+
+func TestStore_ImportInstallation_SaveFailed(t *testing.T) {
+	src, claims := generateArchiveData(t)
+	archive := exportInstallation(t, src, "wordpress")
+
+	failOn := ItemTypeOutputs
+	datastore := crud.NewMockStore()
+	dest := NewClaimStore(crud.NewBackingStore(failingStore{MockStore: datastore, failOn: &failOn}), nil, nil)
+
+	_, err := dest.ImportInstallation(bytes.NewReader(archive))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not import installation wordpress")
+
+	installations, err := dest.ListInstallations()
+	require.NoError(t, err, "ListInstallations failed")
+	assert.Empty(t, installations, "the installation should not be saved")
+	for _, c := range claims {
+		_, err := datastore.Read(ItemTypeClaims, c.ID)
+		assert.Equal(t, crud.ErrRecordDoesNotExist, err, "the claims saved before the failure should be removed")
+		results, err := datastore.List(ItemTypeResults, c.ID)
+		require.NoError(t, err, "List failed")
+		assert.Empty(t, results, "the results saved before the failure should be removed")
+	}
+
+	failOn = ""
+	installation, err := dest.ImportInstallation(bytes.NewReader(archive))
+	require.NoError(t, err, "the installation should be imported again once the store recovers")
+	assert.Equal(t, "wordpress", installation)
+}
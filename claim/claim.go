@@ -84,6 +84,10 @@ type Claim struct {
 	// BundleReference is the canonical reference to the bundle used in the action.
 	BundleReference string `json:"bundleReference,omitempty"`
 
+	// BundleDigest is the digest of the canonical representation of the bundle
+	// used in the action, see bundle.Bundle.Digest.
+	BundleDigest string `json:"bundleDigest,omitempty"`
+
 	// Parameters are the key/value pairs that were passed in during the operation.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 
@@ -129,6 +133,10 @@ func New(installation string, action string, bun bundle.Bundle, parameters map[s
 	if err != nil {
 		return Claim{}, err
 	}
+	digest, err := bun.Digest()
+	if err != nil {
+		return Claim{}, err
+	}
 
 	return Claim{
 		SchemaVersion: schemaVersion,
@@ -138,6 +146,7 @@ func New(installation string, action string, bun bundle.Bundle, parameters map[s
 		Created:       now,
 		Action:        action,
 		Bundle:        bun,
+		BundleDigest:  digest,
 		Parameters:    parameters,
 	}, nil
 }
@@ -156,6 +165,11 @@ func (c Claim) NewClaim(action string, bun bundle.Bundle, parameters map[string]
 	}
 	updatedClaim.ID = id
 
+	updatedClaim.BundleDigest, err = bun.Digest()
+	if err != nil {
+		return Claim{}, err
+	}
+
 	modifies, err := updatedClaim.IsModifyingAction()
 	if err != nil {
 		return Claim{}, err
@@ -229,6 +243,16 @@ func (c Claim) Validate() error {
 	return nil
 }
 
+// VerifyBundle checks that the bundle has not changed since the claim was
+// created, by comparing its digest to BundleDigest. It returns
+// bundle.ErrDigestMismatch when they are different.
+func (c Claim) VerifyBundle() error {
+	if c.BundleDigest == "" {
+		return errors.New("the claim does not have a bundle digest")
+	}
+	return c.Bundle.VerifyDigest(c.BundleDigest)
+}
+
 // GetLastResult returns the most recent (last) result associated with the
 // claim.
 func (c Claim) GetLastResult() (Result, error) {
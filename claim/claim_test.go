@@ -10,10 +10,12 @@ import (
 	"time"
 
 	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
 	"github.com/cnabio/cnab-go/schema"
 )
 
@@ -30,6 +32,21 @@ func TestNew(t *testing.T) {
 	assert.Nil(t, claim.Parameters)
 }
 
+func TestNew_FloatDefault(t *testing.T) {
+	bun := exampleBundle
+	bun.Definitions = definition.Definitions{
+		"ratio": {Type: "number", Default: 1.5},
+	}
+
+	c, err := New("my_claim", ActionInstall, bun, nil)
+	require.NoError(t, err, "a bundle with a non-integer number should have a digest")
+	assert.NoError(t, c.VerifyBundle())
+
+	next, err := c.NewClaim(ActionUpgrade, bun, nil)
+	require.NoError(t, err)
+	assert.Equal(t, c.BundleDigest, next.BundleDigest)
+}
+
 func TestClaim_Validate(t *testing.T) {
 	t.Run("builtin action", func(t *testing.T) {
 		c, err := New("test", ActionInstall, exampleBundle, nil)
@@ -118,6 +135,30 @@ func TestClaim_NewClaim(t *testing.T) {
 
 }
 
+func TestClaim_VerifyBundle(t *testing.T) {
+	c, err := New("claim", ActionInstall, exampleBundle, nil)
+	require.NoError(t, err)
+
+	digest, err := exampleBundle.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, c.BundleDigest)
+	assert.NoError(t, c.VerifyBundle())
+
+	t.Run("bundle changed", func(t *testing.T) {
+		changed := c
+		changed.Bundle.Version = "2.0.0"
+		err := changed.VerifyBundle()
+		require.Error(t, err)
+		assert.Equal(t, bundle.ErrDigestMismatch, errors.Cause(err))
+	})
+
+	t.Run("no digest", func(t *testing.T) {
+		missing := c
+		missing.BundleDigest = ""
+		assert.EqualError(t, missing.VerifyBundle(), "the claim does not have a bundle digest")
+	})
+}
+
 func TestValidName(t *testing.T) {
 	for name, expect := range map[string]bool{
 		"M4cb3th":               true,
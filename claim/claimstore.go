@@ -471,31 +471,70 @@ func (s Store) SaveClaim(c Claim) error {
 		return err
 	}
 
-	bytes, err := json.MarshalIndent(c, "", "  ")
+	bytes, err := s.encodeClaim(c)
 	if err != nil {
 		return err
 	}
 
-	bytes, err = s.encrypt(bytes)
-	if err != nil {
-		return errors.Wrapf(err, "error encrypting claim %s of installation %s", c.ID, c.Installation)
+	// Save the claim and the installation together, so that an installation
+	// is never recorded without its claim, or a claim without its installation.
+	var batch crud.Batch
+	batch.Save(ItemTypeClaims, c.Installation, c.ID, bytes)
+	batch.Save(ItemTypeInstallations, "", c.Installation, nil)
+	if err := s.backingStore.CommitBatch(batch); err != nil {
+		return err
 	}
+	return s.saveInstallationFields(c.Installation)
+}
 
-	err = s.backingStore.Save(ItemTypeClaims, c.Installation, c.ID, bytes)
+// encodeClaim marshals and encrypts a claim for storage.
+func (s Store) encodeClaim(c Claim) ([]byte, error) {
+	bytes, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.backingStore.Save(ItemTypeInstallations, "", c.Installation, nil)
+	bytes, err = s.encrypt(bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error encrypting claim %s of installation %s", c.ID, c.Installation)
+	}
+	return bytes, nil
 }
 
 func (s Store) SaveResult(r Result) error {
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
 	bytes, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return s.backingStore.Save(ItemTypeResults, r.ClaimID, r.ID, bytes)
+	err = s.backingStore.Save(ItemTypeResults, r.ClaimID, r.ID, bytes)
+	if err != nil {
+		return err
+	}
+	return s.saveClaimInstallationFields(r.ClaimID)
+}
+
+// saveClaimInstallationFields saves the query fields of the installation of
+// a claim, see saveInstallationFields.
+func (s Store) saveClaimInstallationFields(claimID string) error {
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); !ok {
+		return nil
+	}
+
+	c, err := s.ReadClaim(claimID)
+	if err == ErrClaimNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.saveInstallationFields(c.Installation)
 }
 
 func (s Store) SaveOutput(o Output) error {
@@ -503,20 +542,30 @@ func (s Store) SaveOutput(o Output) error {
 		return errors.New("output.Claim is not set")
 	}
 
-	sensitive, err := o.claim.Bundle.IsOutputSensitive(o.Name)
+	data, err := s.encodeOutput(o)
 	if err != nil {
-		return errors.Wrapf(err, "could not determine if the output %q is sensitive", o.Name)
+		return err
 	}
 
-	data := o.Value
-	if sensitive {
-		data, err = s.encrypt(o.Value)
-		if err != nil {
-			return errors.Wrapf(err, "error encrypting output %s for result %s of installation %s", o.Name, o.result.ID, o.claim.Installation)
-		}
+	return s.backingStore.Save(ItemTypeOutputs, o.result.ID, s.outputKey(o.result.ID, o.Name), data)
+}
+
+// encodeOutput returns the value of an output for storage, encrypted when the
+// output is sensitive.
+func (s Store) encodeOutput(o Output) ([]byte, error) {
+	sensitive, err := o.claim.Bundle.IsOutputSensitive(o.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not determine if the output %q is sensitive", o.Name)
+	}
+	if !sensitive {
+		return o.Value, nil
 	}
 
-	return s.backingStore.Save(ItemTypeOutputs, o.result.ID, s.outputKey(o.result.ID, o.Name), data)
+	data, err := s.encrypt(o.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error encrypting output %s for result %s of installation %s", o.Name, o.result.ID, o.claim.Installation)
+	}
+	return data, nil
 }
 
 func (s Store) DeleteInstallation(installation string) error {
@@ -549,20 +598,36 @@ func (s Store) DeleteClaim(claimID string) error {
 		return err
 	}
 
+	// The installation is only needed to update its query fields
+	var installation string
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); ok {
+		c, err := s.ReadClaim(claimID)
+		if err != nil {
+			return err
+		}
+		installation = c.Installation
+	}
+
 	resultIds, err := s.ListResults(claimID)
 	if err != nil {
 		return err
 	}
 
 	for _, resultID := range resultIds {
-		err := s.DeleteResult(resultID)
+		err := s.deleteResult(resultID)
 		if err != nil {
 			return err
 		}
 	}
 
 	err = s.backingStore.Delete(ItemTypeClaims, claimID)
-	return s.handleNotExistsError(err, ErrClaimNotFound)
+	if err != nil {
+		return s.handleNotExistsError(err, ErrClaimNotFound)
+	}
+	if installation == "" {
+		return nil
+	}
+	return s.saveInstallationFields(installation)
 }
 
 func (s Store) DeleteResult(resultID string) error {
@@ -572,6 +637,27 @@ func (s Store) DeleteResult(resultID string) error {
 		return err
 	}
 
+	// The claim is only needed to update the query fields of its installation
+	var claimID string
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); ok {
+		r, err := s.ReadResult(resultID)
+		if err != nil {
+			return err
+		}
+		claimID = r.ClaimID
+	}
+
+	if err := s.deleteResult(resultID); err != nil {
+		return err
+	}
+	if claimID == "" {
+		return nil
+	}
+	return s.saveClaimInstallationFields(claimID)
+}
+
+// deleteResult deletes the result and its outputs.
+func (s Store) deleteResult(resultID string) error {
 	outputNames, err := s.ListOutputs(resultID)
 	if err != nil {
 		return err
@@ -208,6 +208,32 @@ func TestCanSaveReadAndDelete(t *testing.T) {
 	is.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error(), "Installation should have been deleted")
 }
 
+func TestStore_SaveClaimIsAtomic(t *testing.T) {
+	c, err := New("foo", ActionInstall, exampleBundle, nil)
+	require.NoError(t, err)
+
+	tempDir, err := ioutil.TempDir("", "cnabtest")
+	require.NoError(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tempDir)
+
+	storeDir := filepath.Join(tempDir, "claimstore")
+	datastore := crud.NewFileSystemStore(storeDir, NewClaimStoreFileExtensions())
+	store := NewClaimStore(crud.NewBackingStore(datastore), nil, nil)
+
+	// Block the installations directory with a file so that the installation cannot be saved
+	require.NoError(t, os.MkdirAll(storeDir, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(storeDir, ItemTypeInstallations), nil, 0600))
+
+	err = store.SaveClaim(c)
+	require.Error(t, err, "SaveClaim should fail when the installation cannot be saved")
+
+	_, err = store.ListClaims(c.Installation)
+	assert.Equal(t, ErrInstallationNotFound, err, "the claim should not be saved without its installation")
+
+	info, err := os.Stat(filepath.Join(storeDir, ItemTypeClaims, c.Installation, c.ID+".json"))
+	assert.True(t, os.IsNotExist(err), "the claim file should not exist, got %v", info)
+}
+
 func TestCanUpdate(t *testing.T) {
 	is := assert.New(t)
 	b := bundle.Bundle{Name: "foobundle", Version: "0.1.2"}
@@ -0,0 +1,50 @@
+package claim
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// ErrInstallationLocked represents an installation that is locked by another
+// operation, for example while a modifying action is running.
+var ErrInstallationLocked = errors.New("Installation is locked")
+
+// InstallationLocker is implemented by claim providers that can lock an
+// installation, so that only one modifying action runs against an
+// installation at a time, even across processes that share claim storage.
+type InstallationLocker interface {
+	// LockInstallation acquires a lease on the installation for the owner,
+	// for example the ID of the claim being executed. The lease expires after
+	// the ttl, so that the lock is released when the owner crashes, and is
+	// renewed when the owner locks the installation again. Returns
+	// ErrInstallationLocked when another owner holds a lease that has not
+	// expired.
+	LockInstallation(installation string, owner string, ttl time.Duration) error
+
+	// UnlockInstallation releases the owner's lease on the installation.
+	UnlockInstallation(installation string, owner string) error
+}
+
+var _ InstallationLocker = Store{}
+
+// LockInstallation acquires a lease on the installation for the owner. The
+// backing data store must implement crud.HasLock.
+func (s Store) LockInstallation(installation string, owner string, ttl time.Duration) error {
+	err := s.backingStore.Lock(ItemTypeInstallations, installation, owner, ttl)
+	if lockedErr, ok := err.(*crud.LockedError); ok {
+		return errors.Wrapf(ErrInstallationLocked, "installation %s is locked by %s until %s",
+			installation, lockedErr.Lease.Owner, lockedErr.Lease.Expires.Format(time.RFC3339))
+	}
+	if crud.IsLocked(err) {
+		return errors.Wrapf(ErrInstallationLocked, "could not lock installation %s", installation)
+	}
+	return err
+}
+
+// UnlockInstallation releases the owner's lease on the installation.
+func (s Store) UnlockInstallation(installation string, owner string) error {
+	return s.backingStore.Unlock(ItemTypeInstallations, installation, owner)
+}
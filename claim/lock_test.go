@@ -0,0 +1,38 @@
+package claim
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+func TestStore_LockInstallation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "cnabtest")
+	require.NoError(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tempDir)
+
+	datastore := crud.NewFileSystemStore(filepath.Join(tempDir, "claimstore"), NewClaimStoreFileExtensions())
+	s := NewClaimStore(crud.NewBackingStore(datastore), nil, nil)
+
+	require.NoError(t, s.LockInstallation("wordpress", "claim1", time.Minute), "LockInstallation failed")
+
+	err = s.LockInstallation("wordpress", "claim2", time.Minute)
+	require.Error(t, err)
+	assert.Equal(t, ErrInstallationLocked, errors.Cause(err))
+	assert.Contains(t, err.Error(), "installation wordpress is locked by claim1")
+
+	installations, err := s.ListInstallations()
+	require.NoError(t, err, "ListInstallations failed")
+	assert.Empty(t, installations, "locking an installation should not create it")
+
+	require.NoError(t, s.UnlockInstallation("wordpress", "claim1"), "UnlockInstallation failed")
+	require.NoError(t, s.LockInstallation("wordpress", "claim2", time.Minute), "the installation should be unlocked")
+}
@@ -0,0 +1,340 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/schema"
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// LegacySchemaVersion is the schema version of claims written before the
+// result of the operation was stored separately from the claim, including
+// claims without a schemaVersion.
+const LegacySchemaVersion schema.Version = "1.0.0-DRAFT"
+
+// Migrations upgrade claims written with earlier drafts of the CNAB Claims
+// specification, in order.
+var Migrations = []schema.Migration{
+	{From: LegacySchemaVersion, To: "1.0.0-DRAFT+b5ed2f3", Migrate: separateResult},
+}
+
+// legacyStatuses maps the statuses of legacy claims to the current statuses.
+var legacyStatuses = map[string]string{
+	"success":  StatusSucceeded,
+	"failure":  StatusFailed,
+	"underway": StatusRunning,
+}
+
+// NewMigrator creates a migrator that upgrades claims to the schema version
+// that this library implements.
+func NewMigrator() (schema.Migrator, error) {
+	current, err := GetDefaultSchemaVersion()
+	if err != nil {
+		return schema.Migrator{}, err
+	}
+	return schema.Migrator{Current: current, Legacy: LegacySchemaVersion, Migrations: Migrations}, nil
+}
+
+// MigrateDocument upgrades a decoded claim to the schema version that this
+// library implements, and reports whether it was changed.
+//
+// The bundle of a legacy claim is upgraded too, while the bundle of other
+// claims is kept as it was used in the operation, so that it still matches
+// the bundleDigest. The result recorded on legacy claims is removed, use
+// Store.Migrate to keep it as a Result.
+func MigrateDocument(doc map[string]interface{}) (bool, error) {
+	m, err := NewMigrator()
+	if err != nil {
+		return false, err
+	}
+	return m.Migrate(doc)
+}
+
+// Migrate upgrades a claim document to the schema version that this library
+// implements, and unmarshals it. It reports whether the claim was changed.
+func Migrate(data []byte) (Claim, bool, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return Claim{}, false, errors.Wrap(err, "cannot decode the claim")
+	}
+
+	changed, err := MigrateDocument(doc)
+	if err != nil {
+		return Claim{}, false, errors.Wrap(err, "cannot migrate the claim")
+	}
+	if changed {
+		if data, err = json.Marshal(doc); err != nil {
+			return Claim{}, false, err
+		}
+	}
+
+	var c Claim
+	err = json.Unmarshal(data, &c)
+	return c, changed, err
+}
+
+// separateResult upgrades a legacy claim, which was named after its
+// installation and recorded the result of the operation.
+func separateResult(doc map[string]interface{}) error {
+	var legacy legacyClaim
+	if err := remarshal(doc, &legacy); err != nil {
+		return errors.Wrap(err, "invalid legacy claim")
+	}
+
+	if _, ok := doc["installation"]; !ok && legacy.Name != "" {
+		doc["installation"] = legacy.Name
+	}
+	// The IDs are derived from the legacy claim, so that migrating it again,
+	// for example after a migration failed, results in the same claim.
+	seed, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"id", "revision"} {
+		if _, ok := doc[key]; !ok {
+			id, err := legacyULID(legacy.Created, seed, key)
+			if err != nil {
+				return err
+			}
+			doc[key] = id
+		}
+	}
+	if _, ok := doc["action"]; !ok {
+		doc["action"] = ActionUnknown
+		if legacy.Result != nil && legacy.Result.Action != "" {
+			doc["action"] = legacy.Result.Action
+		}
+	}
+
+	if bun, ok := doc["bundle"].(map[string]interface{}); ok {
+		if _, err := bundle.MigrateDocument(bun); err != nil {
+			return errors.Wrap(err, "cannot migrate the bundle of the claim")
+		}
+	}
+
+	for _, key := range []string{"name", "modified", "result", "outputs"} {
+		delete(doc, key)
+	}
+	return nil
+}
+
+// legacyULID generates the ID of a document created from a legacy claim, with
+// the timestamp of the legacy claim and the same value every time that the
+// claim is migrated.
+func legacyULID(t time.Time, seed []byte, kind string) (string, error) {
+	entropy := sha256.Sum256(append([]byte(kind+"\x00"), seed...))
+	id, err := ulid.New(ulid.Timestamp(t), bytes.NewReader(entropy[:]))
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate a ULID for the legacy claim")
+	}
+	return id.String(), nil
+}
+
+// legacyClaim has the fields of legacy claims that were removed from claims.
+type legacyClaim struct {
+	SchemaVersion string                 `json:"schemaVersion"`
+	Name          string                 `json:"name"`
+	Created       time.Time              `json:"created"`
+	Modified      time.Time              `json:"modified"`
+	Result        *legacyResult          `json:"result"`
+	Outputs       map[string]interface{} `json:"outputs"`
+}
+
+type legacyResult struct {
+	Message string `json:"message"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+}
+
+func remarshal(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// MigrationReport describes the claims that were upgraded by Store.Migrate.
+type MigrationReport struct {
+	// Backup describes the items that were copied to the backup store before
+	// the claims were upgraded.
+	Backup crud.MigrationReport
+
+	// Claims that were upgraded.
+	Claims []MigratedClaim
+}
+
+// MigratedClaim is a claim in a MigrationReport.
+type MigratedClaim struct {
+	// ID of the claim, after it was upgraded.
+	ID string
+
+	// Installation of the claim.
+	Installation string
+
+	// Key of the claim in the backing store before it was upgraded, which
+	// differs from the ID for legacy claims.
+	Key string
+
+	// From is the schema version of the claim before it was upgraded, empty
+	// when the claim did not have a schemaVersion.
+	From schema.Version
+
+	// ResultID of the Result created from the result recorded on a legacy
+	// claim.
+	ResultID string
+}
+
+// Migrate upgrades every claim in the store, in place, to the schema version
+// that this library implements, after copying all the data in the store to the
+// backup store.
+//
+// Legacy claims are saved with a new ID in the group of their installation,
+// and the result and outputs that they recorded are saved as a Result and its
+// Outputs. Running a migration again, for example after it failed, only
+// upgrades the claims that were not upgraded yet. When the data store
+// implements crud.HasQuery, every installation is reindexed, see
+// ReindexInstallations.
+func (s Store) Migrate(backup crud.Store) (MigrationReport, error) {
+	var report MigrationReport
+	if backup == nil {
+		return report, errors.New("a backup store is required to migrate the claims")
+	}
+
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return report, err
+	}
+
+	report.Backup, err = crud.Migrate(s.backingStore, backup, crud.MigrateOptions{
+		ItemTypes: []string{ItemTypeInstallations, ItemTypeClaims, ItemTypeResults, ItemTypeOutputs},
+	})
+	if err != nil {
+		return report, errors.Wrap(err, "could not back up the claims")
+	}
+
+	for _, item := range report.Backup.Items {
+		if item.ItemType != ItemTypeClaims {
+			continue
+		}
+		migrated, changed, err := s.migrateClaim(item.Name)
+		if err != nil {
+			return report, errors.Wrapf(err, "could not migrate claim %s", item.Name)
+		}
+		if changed {
+			report.Claims = append(report.Claims, migrated)
+		}
+	}
+
+	// Installations that were saved before the data store supported queries
+	// do not have query fields yet
+	return report, s.ReindexInstallations()
+}
+
+// migrateClaim upgrades the claim with the key, and reports whether it was
+// changed.
+func (s Store) migrateClaim(key string) (MigratedClaim, bool, error) {
+	data, err := s.backingStore.Read(ItemTypeClaims, key)
+	if err != nil {
+		return MigratedClaim{}, false, err
+	}
+	data, err = s.decrypt(data)
+	if err != nil {
+		return MigratedClaim{}, false, errors.Wrap(err, "error decrypting claim")
+	}
+
+	c, changed, err := Migrate(data)
+	if err != nil || !changed {
+		return MigratedClaim{}, false, err
+	}
+
+	var legacy legacyClaim
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return MigratedClaim{}, false, errors.Wrap(err, "invalid legacy claim")
+	}
+	migrated := MigratedClaim{
+		ID:           c.ID,
+		Installation: c.Installation,
+		Key:          key,
+		From:         schema.Version(legacy.SchemaVersion),
+	}
+
+	// Everything is saved before the legacy claim is deleted, so that a
+	// migration that fails is done again, with the same IDs, when it is run
+	// again.
+	if err := s.SaveClaim(c); err != nil {
+		return migrated, false, err
+	}
+	if legacy.Result != nil {
+		r, err := s.saveLegacyResult(c, legacy)
+		if err != nil {
+			return migrated, false, err
+		}
+		migrated.ResultID = r.ID
+	}
+	if key != c.ID {
+		if err := s.backingStore.Delete(ItemTypeClaims, key); err != nil {
+			return migrated, false, errors.Wrap(err, "could not delete the legacy claim")
+		}
+	}
+	return migrated, true, nil
+}
+
+// saveLegacyResult saves the result and outputs recorded on a legacy claim.
+func (s Store) saveLegacyResult(c Claim, legacy legacyClaim) (Result, error) {
+	created := legacy.Modified
+	if created.IsZero() {
+		created = c.Created
+	}
+	id, err := legacyULID(created, []byte(c.ID), "result")
+	if err != nil {
+		return Result{}, err
+	}
+
+	status := legacy.Result.Status
+	if current, ok := legacyStatuses[status]; ok {
+		status = current
+	}
+	r := Result{
+		ID:             id,
+		ClaimID:        c.ID,
+		claim:          &c,
+		Created:        created,
+		Message:        legacy.Result.Message,
+		Status:         status,
+		OutputMetadata: OutputMetadata{},
+	}
+	if err := s.SaveResult(r); err != nil {
+		return r, errors.Wrap(err, "could not save the result of the legacy claim")
+	}
+
+	for name, value := range legacy.Outputs {
+		data, ok := outputValue(value)
+		if !ok {
+			if data, err = json.Marshal(value); err != nil {
+				return r, err
+			}
+		}
+		if err := s.SaveOutput(NewOutput(c, r, name, data)); err != nil {
+			return r, errors.Wrapf(err, "could not save output %s of the legacy claim", name)
+		}
+	}
+	return r, nil
+}
+
+// outputValue returns the value of a string output, which legacy claims
+// recorded without quotes.
+func outputValue(value interface{}) ([]byte, bool) {
+	s, ok := value.(string)
+	return []byte(s), ok
+}
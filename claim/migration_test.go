@@ -0,0 +1,196 @@
+package claim
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/schema"
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// legacyClaimJSON is a claim written before results were stored separately,
+// without a schemaVersion and with a bundle from the working draft.
+const legacyClaimJSON = `{
+  "name": "wordpress",
+  "revision": "01DS7D1C9YQ4F4NA6ZB9VQWJPS",
+  "created": "2019-09-21T10:00:00Z",
+  "modified": "2019-09-21T10:05:00Z",
+  "bundle": {
+    "schemaVersion": "v1.0.0-WD",
+    "name": "wordpress",
+    "version": "v1.0.0",
+    "invocationImages": [{"imageType": "docker", "image": "cnabio/wordpress:v1.0.0"}],
+    "definitions": {"string": {"type": "string"}},
+    "parameters": {"fields": {"port": {"definition": "string"}}, "required": ["port"]},
+    "outputs": {"fields": {"url": {"definition": "string", "path": "/cnab/app/outputs/url"}}}
+  },
+  "result": {"message": "installed", "action": "install", "status": "success"},
+  "parameters": {"port": "8080"},
+  "outputs": {"url": "http://example.com"}
+}`
+
+func TestMigrate_Legacy(t *testing.T) {
+	c, changed, err := Migrate([]byte(legacyClaimJSON))
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	assert.Equal(t, schema.Version(CNABSpecVersion[len("cnab-claim-"):]), c.SchemaVersion)
+	assert.Equal(t, "wordpress", c.Installation)
+	assert.Equal(t, "01DS7D1C9YQ4F4NA6ZB9VQWJPS", c.Revision)
+	assert.Equal(t, ActionInstall, c.Action)
+	assert.NotEmpty(t, c.ID)
+	assert.Equal(t, map[string]interface{}{"port": "8080"}, c.Parameters)
+	assert.NoError(t, c.Validate())
+
+	created, err := time.Parse(time.RFC3339, "2019-09-21T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, created.Unix(), c.Created.Unix())
+	id, err := ulid.Parse(c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ulid.Timestamp(created), id.Time(), "the claim ID should have the timestamp of the claim")
+
+	again, _, err := Migrate([]byte(legacyClaimJSON))
+	require.NoError(t, err)
+	assert.Equal(t, c.ID, again.ID, "migrating a legacy claim again should result in the same claim")
+
+	assert.Equal(t, schema.Version("1.0.1"), c.Bundle.SchemaVersion, "the bundle of a legacy claim should be migrated")
+	assert.True(t, c.Bundle.Parameters["port"].Required)
+	assert.Contains(t, c.Bundle.Outputs, "url")
+}
+
+func TestMigrate_Current(t *testing.T) {
+	c, err := New("wordpress", ActionInstall, bundle.Bundle{SchemaVersion: "v1.0.0", Name: "wordpress"}, nil)
+	require.NoError(t, err)
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+
+	migrated, changed, err := Migrate(data)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, schema.Version("v1.0.0"), migrated.Bundle.SchemaVersion, "the bundle of a current claim should not be changed")
+	assert.NoError(t, migrated.VerifyBundle())
+}
+
+func TestMigrate_Newer(t *testing.T) {
+	_, _, err := Migrate([]byte(`{"schemaVersion": "2.0.0", "installation": "wordpress"}`))
+	assert.EqualError(t, err, "cannot migrate the claim: the schema version 2.0.0 is newer than the supported schema version 1.0.0-DRAFT+b5ed2f3")
+}
+
+func TestStore_Migrate(t *testing.T) {
+	s := NewMockStore(b64encode, b64decode)
+
+	current, err := New("mysql", ActionInstall, bundle.Bundle{Name: "mysql"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveClaim(current))
+
+	legacy, err := b64encode([]byte(legacyClaimJSON))
+	require.NoError(t, err)
+	require.NoError(t, s.GetBackingStore().Save(ItemTypeClaims, "", "wordpress", legacy))
+
+	backup := crud.NewMockStore()
+	report, err := s.Migrate(backup)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Backup.Count(crud.MigrationCopied), "the claims and the installation should be backed up")
+	backedUp, err := backup.Read(ItemTypeClaims, "wordpress")
+	require.NoError(t, err)
+	assert.Equal(t, legacy, backedUp, "the backup should have the legacy claim as it was stored")
+
+	require.Len(t, report.Claims, 1)
+	migrated := report.Claims[0]
+	assert.Equal(t, "wordpress", migrated.Installation)
+	assert.Equal(t, "wordpress", migrated.Key)
+	assert.Empty(t, migrated.From)
+	assert.NotEmpty(t, migrated.ResultID)
+
+	_, err = s.GetBackingStore().Read(ItemTypeClaims, "wordpress")
+	assert.Error(t, err, "the legacy claim should be removed")
+
+	i, err := s.ReadInstallation("wordpress")
+	require.NoError(t, err)
+	require.Len(t, i.Claims, 1)
+	c := i.Claims[0]
+	assert.Equal(t, migrated.ID, c.ID)
+	assert.Equal(t, ActionInstall, c.Action)
+
+	r, err := s.ReadLastResult(c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, migrated.ResultID, r.ID)
+	assert.Equal(t, StatusSucceeded, r.Status)
+	assert.Equal(t, "installed", r.Message)
+
+	url, err := s.ReadLastOutput("wordpress", "url")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", string(url.Value))
+
+	mysql, err := s.ReadClaim(current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, current.BundleDigest, mysql.BundleDigest, "current claims should not be changed")
+
+	t.Run("again", func(t *testing.T) {
+		report, err := s.Migrate(backup)
+		require.NoError(t, err)
+		assert.Empty(t, report.Claims, "the claims were already migrated")
+	})
+}
+
+func TestStore_Migrate_NoBackup(t *testing.T) {
+	s := NewMockStore(nil, nil)
+	_, err := s.Migrate(nil)
+	assert.EqualError(t, err, "a backup store is required to migrate the claims")
+}
+
+// failingStore fails to save items of the specified type, until failOn is
+// cleared.
+type failingStore struct {
+	crud.MockStore
+	failOn *string
+}
+
+func (s failingStore) Save(itemType string, group string, name string, data []byte) error {
+	if itemType == *s.failOn {
+		return errors.New("save failed")
+	}
+	return s.MockStore.Save(itemType, group, name, data)
+}
+
+func TestStore_Migrate_Resume(t *testing.T) {
+	failOn := ItemTypeResults
+	s := NewClaimStore(crud.NewBackingStore(failingStore{MockStore: crud.NewMockStore(), failOn: &failOn}), nil, nil)
+	require.NoError(t, s.GetBackingStore().Save(ItemTypeClaims, "", "wordpress", []byte(legacyClaimJSON)))
+
+	backup := crud.NewMockStore()
+	_, err := s.Migrate(backup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not save the result of the legacy claim")
+
+	_, err = s.GetBackingStore().Read(ItemTypeClaims, "wordpress")
+	require.NoError(t, err, "the legacy claim should be kept until its result is saved")
+
+	failOn = ""
+	report, err := s.Migrate(backup)
+	require.NoError(t, err)
+	require.Len(t, report.Claims, 1)
+
+	i, err := s.ReadInstallation("wordpress")
+	require.NoError(t, err)
+	require.Len(t, i.Claims, 1, "the claim saved by the failed migration should be replaced")
+	assert.Equal(t, report.Claims[0].ID, i.Claims[0].ID)
+
+	r, err := s.ReadLastResult(i.Claims[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, r.Status)
+	url, err := s.ReadLastOutput("wordpress", "url")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", string(url.Value))
+
+	_, err = s.GetBackingStore().Read(ItemTypeClaims, "wordpress")
+	assert.Error(t, err, "the legacy claim should be removed")
+}
@@ -50,6 +50,12 @@ func (o Output) GetSchema() (definition.Schema, bool) {
 	return definition.Schema{}, false
 }
 
+// IsSensitive returns true when the output is sensitive (write-only) in the
+// bundle that generated it.
+func (o Output) IsSensitive() (bool, error) {
+	return o.claim.Bundle.IsOutputSensitive(o.Name)
+}
+
 type Outputs struct {
 	// Sorted list of outputs
 	vals []Output
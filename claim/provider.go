@@ -27,6 +27,10 @@ type Provider interface {
 	// ReadAllInstallationStatus returns all Installations with the last Claim and its last Result loaded.
 	ReadAllInstallationStatus() ([]Installation, error)
 
+	// QueryInstallations returns the Installations that match the query, with
+	// the last Claim and its last Result loaded.
+	QueryInstallations(query InstallationQuery) (InstallationQueryResult, error)
+
 	// ReadClaim returns the specified Claim.
 	ReadClaim(claimID string) (Claim, error)
 
@@ -0,0 +1,186 @@
+package claim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy determines which claims of an installation are kept when its
+// history is pruned. A claim is kept when any of the policy's rules keeps it,
+// and the most recent claim of an installation is always kept so that its
+// status is preserved.
+type RetentionPolicy struct {
+	// KeepLast keeps the specified number of most recent claims.
+	KeepLast int
+
+	// KeepNewerThan keeps claims that were created within this duration.
+	KeepNewerThan time.Duration
+
+	// KeepLastSuccessfulModifying keeps the most recent claim for a modifying
+	// action, such as install or upgrade, that succeeded.
+	KeepLastSuccessfulModifying bool
+}
+
+// Validate the retention policy.
+func (p RetentionPolicy) Validate() error {
+	if p.KeepLast < 0 {
+		return fmt.Errorf("invalid retention policy, KeepLast cannot be negative: %d", p.KeepLast)
+	}
+	if p.KeepNewerThan < 0 {
+		return fmt.Errorf("invalid retention policy, KeepNewerThan cannot be negative: %s", p.KeepNewerThan)
+	}
+	if p.KeepLast == 0 && p.KeepNewerThan == 0 && !p.KeepLastSuccessfulModifying {
+		return errors.New("invalid retention policy, at least one rule must be set")
+	}
+	return nil
+}
+
+// PruneReport describes the data that was removed by pruning, or that would be
+// removed when pruning with dry run.
+type PruneReport struct {
+	// DryRun indicates that nothing was removed.
+	DryRun bool
+
+	// Claims that were removed, with their results and outputs.
+	Claims []PrunedClaim
+}
+
+// PrunedClaim is a claim removed by pruning.
+type PrunedClaim struct {
+	Installation string
+	ClaimID      string
+	Action       string
+	Created      time.Time
+
+	// Results of the claim that were removed.
+	Results []PrunedResult
+}
+
+// PrunedResult is a result removed by pruning.
+type PrunedResult struct {
+	ResultID string
+
+	// Outputs are the names of the result's outputs that were removed.
+	Outputs []string
+}
+
+// Prune removes the claims of every installation that are not kept by the
+// retention policy, along with their results and outputs. When dryRun is true,
+// nothing is removed and the report describes what would be removed.
+func Prune(p Provider, policy RetentionPolicy, dryRun bool) (PruneReport, error) {
+	if err := policy.Validate(); err != nil {
+		return PruneReport{}, err
+	}
+
+	installations, err := p.ListInstallations()
+	if err != nil {
+		return PruneReport{}, err
+	}
+
+	report := PruneReport{DryRun: dryRun}
+	for _, installation := range installations {
+		if err := pruneInstallation(p, installation, policy, &report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// PruneInstallation removes the claims of an installation that are not kept by
+// the retention policy, along with their results and outputs. When dryRun is
+// true, nothing is removed and the report describes what would be removed.
+func PruneInstallation(p Provider, installation string, policy RetentionPolicy, dryRun bool) (PruneReport, error) {
+	if err := policy.Validate(); err != nil {
+		return PruneReport{}, err
+	}
+
+	report := PruneReport{DryRun: dryRun}
+	err := pruneInstallation(p, installation, policy, &report)
+	return report, err
+}
+
+func pruneInstallation(p Provider, installation string, policy RetentionPolicy, report *PruneReport) error {
+	i, err := p.ReadInstallation(installation)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range policy.selectPruned(i.Claims, time.Now()) {
+		pruned := PrunedClaim{
+			Installation: c.Installation,
+			ClaimID:      c.ID,
+			Action:       c.Action,
+			Created:      c.Created,
+		}
+
+		if c.results != nil {
+			for _, r := range *c.results {
+				outputs, err := p.ListOutputs(r.ID)
+				if err != nil {
+					return err
+				}
+				pruned.Results = append(pruned.Results, PrunedResult{
+					ResultID: r.ID,
+					Outputs:  outputs,
+				})
+			}
+		}
+
+		if !report.DryRun {
+			if err := p.DeleteClaim(c.ID); err != nil {
+				return fmt.Errorf("could not prune claim %s of installation %s: %v", c.ID, installation, err)
+			}
+		}
+		report.Claims = append(report.Claims, pruned)
+	}
+
+	return nil
+}
+
+// selectPruned returns the claims, sorted in ascending order, that are not
+// kept by the retention policy.
+func (p RetentionPolicy) selectPruned(claims Claims, now time.Time) []Claim {
+	if len(claims) == 0 {
+		return nil
+	}
+
+	keep := make([]bool, len(claims))
+
+	// Always keep the most recent claim
+	keep[len(claims)-1] = true
+
+	for i := len(claims) - p.KeepLast; i < len(claims); i++ {
+		if i >= 0 {
+			keep[i] = true
+		}
+	}
+
+	if p.KeepNewerThan > 0 {
+		cutoff := now.Add(-p.KeepNewerThan)
+		for i, c := range claims {
+			if c.Created.After(cutoff) {
+				keep[i] = true
+			}
+		}
+	}
+
+	if p.KeepLastSuccessfulModifying {
+		for i := len(claims) - 1; i >= 0; i-- {
+			c := claims[i]
+			modifies, err := c.IsModifyingAction()
+			if err == nil && modifies && c.GetStatus() == StatusSucceeded {
+				keep[i] = true
+				break
+			}
+		}
+	}
+
+	var pruned []Claim
+	for i, c := range claims {
+		if !keep[i] {
+			pruned = append(pruned, c)
+		}
+	}
+	return pruned
+}
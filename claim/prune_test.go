@@ -0,0 +1,174 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// generatePruneData creates the history of an installation for prune tests,
+// and returns the claim ids in the order they were created.
+//
+// | Claim | Age     | Action  | Status    | Outputs |
+// |-------|---------|---------|-----------|---------|
+// | 0     | 30 days | install | succeeded | output1 |
+// | 1     | 20 days | upgrade | succeeded | output1 |
+// | 2     | 10 days | test    | succeeded |         |
+// | 3     | 2 days  | upgrade | failed    |         |
+// | 4     | 1 day   | test    | succeeded |         |
+func generatePruneData(t *testing.T) (Store, []string) {
+	cp := NewClaimStore(crud.NewBackingStore(crud.NewMockStore()), nil, nil)
+	bun := bundle.Bundle{
+		Definitions: map[string]*definition.Schema{
+			"output1": {Type: "string"},
+		},
+		Outputs: map[string]bundle.Output{
+			"output1": {Definition: "output1"},
+		},
+		Actions: map[string]bundle.Action{
+			"test": {Modifies: false},
+		},
+	}
+
+	var ids []string
+	createClaim := func(age time.Duration, action string, status string, outputs ...string) {
+		c, err := New("foo", action, bun, nil)
+		require.NoError(t, err, "New claim failed")
+		c.Created = time.Now().Add(-age)
+		require.NoError(t, cp.SaveClaim(c), "SaveClaim failed")
+
+		r, err := c.NewResult(status)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, cp.SaveResult(r), "SaveResult failed")
+
+		for _, output := range outputs {
+			require.NoError(t, cp.SaveOutput(NewOutput(c, r, output, []byte(output))), "SaveOutput failed")
+		}
+		ids = append(ids, c.ID)
+	}
+
+	day := 24 * time.Hour
+	createClaim(30*day, ActionInstall, StatusSucceeded, "output1")
+	createClaim(20*day, ActionUpgrade, StatusSucceeded, "output1")
+	createClaim(10*day, "test", StatusSucceeded)
+	createClaim(2*day, ActionUpgrade, StatusFailed)
+	createClaim(1*day, "test", StatusSucceeded)
+
+	return cp, ids
+}
+
+func prunedClaimIDs(report PruneReport) []string {
+	ids := make([]string, 0, len(report.Claims))
+	for _, c := range report.Claims {
+		ids = append(ids, c.ClaimID)
+	}
+	return ids
+}
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	testcases := []struct {
+		name   string
+		policy RetentionPolicy
+		pruned []int
+	}{
+		{"keep last", RetentionPolicy{KeepLast: 2}, []int{0, 1, 2}},
+		{"keep newer than", RetentionPolicy{KeepNewerThan: 15 * day}, []int{0, 1}},
+		{"keep last successful modifying", RetentionPolicy{KeepLastSuccessfulModifying: true}, []int{0, 2, 3}},
+		{"combined rules", RetentionPolicy{KeepLast: 1, KeepNewerThan: 5 * day, KeepLastSuccessfulModifying: true}, []int{0, 2}},
+		{"most recent claim is always kept", RetentionPolicy{KeepNewerThan: time.Hour}, []int{0, 1, 2, 3}},
+		{"keep more than exists", RetentionPolicy{KeepLast: 10}, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cp, ids := generatePruneData(t)
+
+			wantPruned := make([]string, 0, len(tc.pruned))
+			for _, i := range tc.pruned {
+				wantPruned = append(wantPruned, ids[i])
+			}
+
+			report, err := Prune(cp, tc.policy, false)
+			require.NoError(t, err, "Prune failed")
+			assert.False(t, report.DryRun)
+			assert.Equal(t, wantPruned, prunedClaimIDs(report))
+
+			remaining, err := cp.ListClaims("foo")
+			require.NoError(t, err, "ListClaims failed")
+			assert.Len(t, remaining, len(ids)-len(tc.pruned))
+			for _, id := range wantPruned {
+				_, err := cp.ReadClaim(id)
+				assert.Equal(t, ErrClaimNotFound, err, "claim %s should have been pruned", id)
+			}
+		})
+	}
+}
+
+func TestPrune_Report(t *testing.T) {
+	cp, ids := generatePruneData(t)
+
+	report, err := PruneInstallation(cp, "foo", RetentionPolicy{KeepLast: 4}, false)
+	require.NoError(t, err, "PruneInstallation failed")
+	require.Len(t, report.Claims, 1)
+
+	pruned := report.Claims[0]
+	assert.Equal(t, "foo", pruned.Installation)
+	assert.Equal(t, ids[0], pruned.ClaimID)
+	assert.Equal(t, ActionInstall, pruned.Action)
+	require.Len(t, pruned.Results, 1)
+	assert.Equal(t, []string{"output1"}, pruned.Results[0].Outputs)
+
+	_, err = cp.ReadResult(pruned.Results[0].ResultID)
+	assert.Equal(t, ErrResultNotFound, err, "the result should have been pruned")
+	outputs, err := cp.ListOutputs(pruned.Results[0].ResultID)
+	require.NoError(t, err, "ListOutputs failed")
+	assert.Empty(t, outputs, "the outputs should have been pruned")
+}
+
+func TestPrune_DryRun(t *testing.T) {
+	cp, ids := generatePruneData(t)
+
+	report, err := Prune(cp, RetentionPolicy{KeepLast: 2}, true)
+	require.NoError(t, err, "Prune failed")
+	assert.True(t, report.DryRun)
+	assert.Equal(t, ids[:3], prunedClaimIDs(report))
+
+	remaining, err := cp.ListClaims("foo")
+	require.NoError(t, err, "ListClaims failed")
+	assert.Equal(t, ids, remaining, "no claims should be removed during a dry run")
+}
+
+func TestRetentionPolicy_Validate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		policy  RetentionPolicy
+		wantErr string
+	}{
+		{"valid", RetentionPolicy{KeepLast: 1}, ""},
+		{"empty", RetentionPolicy{}, "at least one rule must be set"},
+		{"negative keep last", RetentionPolicy{KeepLast: -1}, "KeepLast cannot be negative"},
+		{"negative duration", RetentionPolicy{KeepNewerThan: -time.Hour}, "KeepNewerThan cannot be negative"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			}
+		})
+	}
+
+	_, err := Prune(NewMockStore(nil, nil), RetentionPolicy{}, false)
+	assert.EqualError(t, err, "invalid retention policy, at least one rule must be set")
+}
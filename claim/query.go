@@ -0,0 +1,456 @@
+package claim
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// InstallationSortField is a field that installations can be sorted by.
+type InstallationSortField string
+
+const (
+	// SortInstallationsByName sorts installations by their name.
+	SortInstallationsByName InstallationSortField = "name"
+
+	// SortInstallationsByCreated sorts installations by the timestamp of their first claim.
+	SortInstallationsByCreated InstallationSortField = "created"
+
+	// SortInstallationsByModified sorts installations by the timestamp of their last claim.
+	SortInstallationsByModified InstallationSortField = "modified"
+)
+
+// InstallationQuery filters, sorts and paginates installations. The zero value
+// matches every installation, sorted by name.
+type InstallationQuery struct {
+	// Status of the last result of the installation, for example StatusFailed.
+	Status string
+
+	// BundleName of the last claim of the installation.
+	BundleName string
+
+	// BundleVersion of the last claim of the installation.
+	BundleVersion string
+
+	// Action of the last claim of the installation, for example ActionUpgrade.
+	Action string
+
+	// CreatedAfter only matches installations that were created at or after
+	// this time. An installation is created by its first claim.
+	CreatedAfter time.Time
+
+	// CreatedBefore only matches installations that were created before this time.
+	CreatedBefore time.Time
+
+	// SortBy is the field used to sort the installations, defaults to
+	// SortInstallationsByName.
+	SortBy InstallationSortField
+
+	// Descending sorts the installations in descending order.
+	Descending bool
+
+	// Limit is the maximum number of installations to return. All matching
+	// installations are returned when the limit is not set.
+	Limit int
+
+	// PageToken continues a query from the NextPageToken of a previous result.
+	// The token is only valid for the query that returned it.
+	PageToken string
+}
+
+// InstallationQueryResult is a page of installations that matched an
+// InstallationQuery.
+type InstallationQueryResult struct {
+	// Installations that matched the query, with their last Claim and its last
+	// Result loaded.
+	Installations []Installation
+
+	// NextPageToken is set when there are more installations that matched the
+	// query. Set it on InstallationQuery.PageToken to read the next page.
+	NextPageToken string
+}
+
+// Fields that installations are saved with, when the data store implements
+// crud.HasQuery, so that the data store can run an InstallationQuery itself
+// instead of the claim store reading each installation to evaluate the query.
+const (
+	installationFieldStatus        = "status"
+	installationFieldBundleName    = "bundleName"
+	installationFieldBundleVersion = "bundleVersion"
+	installationFieldAction        = "action"
+	installationFieldCreated       = "created"
+	installationFieldModified      = "modified"
+)
+
+// queryTimeFormat formats timestamps with a fixed width, so that they are
+// sorted and compared correctly as strings.
+const queryTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Validate the query.
+func (q InstallationQuery) Validate() error {
+	switch q.SortBy {
+	case "", SortInstallationsByName, SortInstallationsByCreated, SortInstallationsByModified:
+	default:
+		return fmt.Errorf("invalid installation sort field %q", q.SortBy)
+	}
+
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid limit %d, the limit cannot be negative", q.Limit)
+	}
+
+	if !q.CreatedAfter.IsZero() && !q.CreatedBefore.IsZero() && !q.CreatedAfter.Before(q.CreatedBefore) {
+		return fmt.Errorf("invalid creation time range, %s is not before %s", q.CreatedAfter, q.CreatedBefore)
+	}
+
+	_, err := q.offset()
+	return err
+}
+
+// hasFilter returns true when the query filters on the contents of the installation.
+func (q InstallationQuery) hasFilter() bool {
+	return q.Status != "" || q.BundleName != "" || q.BundleVersion != "" || q.Action != "" ||
+		!q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero()
+}
+
+// needsCreated returns true when the query requires the installation's first claim.
+func (q InstallationQuery) needsCreated() bool {
+	return !q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero() || q.SortBy == SortInstallationsByCreated
+}
+
+// matches returns true when the installation matches the query's filters.
+func (q InstallationQuery) matches(i Installation, created time.Time) bool {
+	lastClaim, err := i.GetLastClaim()
+	if err != nil {
+		return false
+	}
+
+	if q.Status != "" && q.Status != i.GetLastStatus() {
+		return false
+	}
+	if q.BundleName != "" && q.BundleName != lastClaim.Bundle.Name {
+		return false
+	}
+	if q.BundleVersion != "" && q.BundleVersion != lastClaim.Bundle.Version {
+		return false
+	}
+	if q.Action != "" && q.Action != lastClaim.Action {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && created.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !created.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// offset returns the number of installations skipped by the page token.
+func (q InstallationQuery) offset() (int, error) {
+	if q.PageToken == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.PageToken)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page token %q", q.PageToken)
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid page token %q", q.PageToken)
+	}
+	return offset, nil
+}
+
+// page returns the start and end of the page within the specified number of
+// installations, and the token for the next page.
+func (q InstallationQuery) page(total int) (int, int, string) {
+	start, _ := q.offset()
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if q.Limit > 0 && start+q.Limit < total {
+		end = start + q.Limit
+	}
+
+	var next string
+	if end < total {
+		next = pageToken(end)
+	}
+	return start, end, next
+}
+
+// pageToken returns the token of the page that starts at the offset.
+func pageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// installationMatch is an installation that matched a query, with the fields
+// used to sort it.
+type installationMatch struct {
+	installation Installation
+	created      time.Time
+	modified     string
+}
+
+// QueryInstallations returns the installations that match the query, with the
+// last Claim and its last Result loaded.
+//
+// When the backing data store implements crud.HasQuery, the query is run by
+// the data store against the fields that the installations are saved with, see
+// ReindexInstallations. Otherwise, the status of each installation is read to
+// evaluate the query.
+func (s Store) QueryInstallations(query InstallationQuery) (InstallationQueryResult, error) {
+	if err := query.Validate(); err != nil {
+		return InstallationQueryResult{}, err
+	}
+
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return InstallationQueryResult{}, err
+	}
+
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); ok {
+		return s.queryInstallations(query)
+	}
+
+	names, err := s.ListInstallations()
+	if err != nil {
+		return InstallationQueryResult{}, err
+	}
+
+	// Installations are listed by name, so only the requested page needs to be read
+	sortByName := query.SortBy == "" || query.SortBy == SortInstallationsByName
+	if sortByName && !query.hasFilter() {
+		if query.Descending {
+			sort.Sort(sort.Reverse(sort.StringSlice(names)))
+		}
+
+		start, end, next := query.page(len(names))
+		result := InstallationQueryResult{
+			Installations: make([]Installation, 0, end-start),
+			NextPageToken: next,
+		}
+		for _, name := range names[start:end] {
+			i, err := s.ReadInstallationStatus(name)
+			if err != nil {
+				return InstallationQueryResult{}, err
+			}
+			result.Installations = append(result.Installations, i)
+		}
+		return result, nil
+	}
+
+	matches := make([]installationMatch, 0, len(names))
+	for _, name := range names {
+		i, err := s.ReadInstallationStatus(name)
+		if err != nil {
+			return InstallationQueryResult{}, err
+		}
+
+		var created time.Time
+		if query.needsCreated() {
+			created, err = s.readInstallationCreated(name)
+			if err != nil {
+				return InstallationQueryResult{}, err
+			}
+		}
+
+		if !query.matches(i, created) {
+			continue
+		}
+
+		lastClaim, _ := i.GetLastClaim()
+		matches = append(matches, installationMatch{
+			installation: i,
+			created:      created,
+			modified:     lastClaim.ID,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if query.Descending {
+			a, b = b, a
+		}
+
+		switch query.SortBy {
+		case SortInstallationsByCreated:
+			if !a.created.Equal(b.created) {
+				return a.created.Before(b.created)
+			}
+		case SortInstallationsByModified:
+			if a.modified != b.modified {
+				return a.modified < b.modified
+			}
+		}
+		return a.installation.Name < b.installation.Name
+	})
+
+	start, end, next := query.page(len(matches))
+	result := InstallationQueryResult{
+		Installations: make([]Installation, 0, end-start),
+		NextPageToken: next,
+	}
+	for _, m := range matches[start:end] {
+		result.Installations = append(result.Installations, m.installation)
+	}
+	return result, nil
+}
+
+// readInstallationCreated returns when the installation was created, which is
+// the timestamp of its first claim.
+func (s Store) readInstallationCreated(installation string) (time.Time, error) {
+	claimIds, err := s.ListClaims(installation)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c, err := s.ReadClaim(claimIds[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Created, nil
+}
+
+// crudQuery translates the query into a query of the installation fields.
+func (q InstallationQuery) crudQuery() crud.Query {
+	cq := crud.Query{
+		ItemType:   ItemTypeInstallations,
+		Descending: q.Descending,
+	}
+
+	equal := func(field string, value string) {
+		if value != "" {
+			cq.Filters = append(cq.Filters, crud.Filter{Field: field, Operator: crud.FilterEqual, Value: value})
+		}
+	}
+	equal(installationFieldStatus, q.Status)
+	equal(installationFieldBundleName, q.BundleName)
+	equal(installationFieldBundleVersion, q.BundleVersion)
+	equal(installationFieldAction, q.Action)
+	if !q.CreatedAfter.IsZero() {
+		cq.Filters = append(cq.Filters, crud.Filter{
+			Field:    installationFieldCreated,
+			Operator: crud.FilterGreaterOrEqual,
+			Value:    q.CreatedAfter.UTC().Format(queryTimeFormat),
+		})
+	}
+	if !q.CreatedBefore.IsZero() {
+		cq.Filters = append(cq.Filters, crud.Filter{
+			Field:    installationFieldCreated,
+			Operator: crud.FilterLess,
+			Value:    q.CreatedBefore.UTC().Format(queryTimeFormat),
+		})
+	}
+
+	switch q.SortBy {
+	case SortInstallationsByCreated:
+		cq.SortBy = installationFieldCreated
+	case SortInstallationsByModified:
+		cq.SortBy = installationFieldModified
+	}
+
+	cq.Skip, _ = q.offset()
+	if q.Limit > 0 {
+		// Query one more installation to know if there is a next page
+		cq.Limit = q.Limit + 1
+	}
+	return cq
+}
+
+// queryInstallations runs the query in the data store, which implements
+// crud.HasQuery.
+func (s Store) queryInstallations(query InstallationQuery) (InstallationQueryResult, error) {
+	cq := query.crudQuery()
+	names, err := s.backingStore.Query(cq)
+	if err != nil {
+		return InstallationQueryResult{}, err
+	}
+
+	var result InstallationQueryResult
+	if query.Limit > 0 && len(names) > query.Limit {
+		names = names[:query.Limit]
+		result.NextPageToken = pageToken(cq.Skip + query.Limit)
+	}
+
+	result.Installations = make([]Installation, 0, len(names))
+	for _, name := range names {
+		i, err := s.ReadInstallationStatus(name)
+		if err != nil {
+			return InstallationQueryResult{}, err
+		}
+		result.Installations = append(result.Installations, i)
+	}
+	return result, nil
+}
+
+// ReindexInstallations saves the query fields of every installation, when the
+// data store implements crud.HasQuery. Installations that were saved before the
+// data store supported queries, or that were copied between data stores, do
+// not have query fields, and are not matched by QueryInstallations until they
+// are reindexed.
+func (s Store) ReindexInstallations() error {
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); !ok {
+		return nil
+	}
+
+	handleClose, err := s.backingStore.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	installations, err := s.ListInstallations()
+	if err != nil {
+		return err
+	}
+	for _, installation := range installations {
+		if err := s.saveInstallationFields(installation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveInstallationFields saves the installation with the fields that it is
+// queried by, when the data store implements crud.HasQuery. The fields are
+// updated whenever a claim or result of the installation changes.
+func (s Store) saveInstallationFields(installation string) error {
+	if _, ok := s.backingStore.GetDataStore().(crud.HasQuery); !ok {
+		return nil
+	}
+
+	i, err := s.ReadInstallationStatus(installation)
+	if err == ErrInstallationNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	created, err := s.readInstallationCreated(installation)
+	if err != nil {
+		return err
+	}
+
+	lastClaim, _ := i.GetLastClaim()
+	fields := crud.Fields{
+		installationFieldStatus:        i.GetLastStatus(),
+		installationFieldBundleName:    lastClaim.Bundle.Name,
+		installationFieldBundleVersion: lastClaim.Bundle.Version,
+		installationFieldAction:        lastClaim.Action,
+		installationFieldCreated:       created.UTC().Format(queryTimeFormat),
+		installationFieldModified:      lastClaim.ID,
+	}
+	err = s.backingStore.SaveWithFields(ItemTypeInstallations, "", installation, nil, fields)
+	return errors.Wrapf(err, "could not save the query fields of installation %s", installation)
+}
@@ -0,0 +1,306 @@
+package claim
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// generateQueryData creates installations for query tests.
+//
+// | Installation | Created | Bundle      | Last Action | Last Status |
+// |--------------|---------|-------------|-------------|-------------|
+// | alpha        | day 1   | mysql 1.0.0 | install     | succeeded   |
+// | bravo        | day 3   | mysql 1.1.0 | upgrade     | failed      |
+// | charlie      | day 2   | redis 2.0.0 | install     | succeeded   |
+// | delta        | day 4   | redis 2.0.0 | uninstall   | succeeded   |
+func generateQueryData(t *testing.T, datastore crud.Store) Store {
+	cp := NewClaimStore(crud.NewBackingStore(datastore), nil, nil)
+	day := func(n int) time.Time {
+		return time.Date(2020, time.May, n, 0, 0, 0, 0, time.UTC)
+	}
+
+	createClaim := func(installation string, action string, created time.Time, name string, version string, status string) {
+		b := bundle.Bundle{Name: name, Version: version}
+		c, err := New(installation, action, b, nil)
+		require.NoError(t, err, "New claim failed")
+		c.Created = created
+		require.NoError(t, cp.SaveClaim(c), "SaveClaim failed")
+
+		r, err := c.NewResult(status)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, cp.SaveResult(r), "SaveResult failed")
+	}
+
+	createClaim("alpha", ActionInstall, day(1), "mysql", "1.0.0", StatusSucceeded)
+	createClaim("charlie", ActionInstall, day(2), "redis", "2.0.0", StatusSucceeded)
+	createClaim("bravo", ActionInstall, day(3), "mysql", "1.0.0", StatusSucceeded)
+	createClaim("delta", ActionInstall, day(4), "redis", "2.0.0", StatusSucceeded)
+	createClaim("bravo", ActionUpgrade, day(5), "mysql", "1.1.0", StatusFailed)
+	createClaim("delta", ActionUninstall, day(6), "redis", "2.0.0", StatusSucceeded)
+
+	return cp
+}
+
+func installationNames(installations []Installation) []string {
+	names := make([]string, len(installations))
+	for i, installation := range installations {
+		names[i] = installation.Name
+	}
+	return names
+}
+
+// queryDataStores are the data stores that the query tests are run against,
+// with and without support for queries.
+func queryDataStores() map[string]func() crud.Store {
+	return map[string]func() crud.Store{
+		"claim store": func() crud.Store { return crud.NewMockStore() },
+		"data store":  func() crud.Store { return newQueryingStore() },
+	}
+}
+
+func TestStore_QueryInstallations(t *testing.T) {
+	testcases := []struct {
+		name  string
+		query InstallationQuery
+		want  []string
+	}{
+		{"all", InstallationQuery{}, []string{"alpha", "bravo", "charlie", "delta"}},
+		{"descending", InstallationQuery{Descending: true}, []string{"delta", "charlie", "bravo", "alpha"}},
+		{"status", InstallationQuery{Status: StatusFailed}, []string{"bravo"}},
+		{"bundle name", InstallationQuery{BundleName: "redis"}, []string{"charlie", "delta"}},
+		{"bundle version", InstallationQuery{BundleName: "mysql", BundleVersion: "1.0.0"}, []string{"alpha"}},
+		{"action", InstallationQuery{Action: ActionInstall}, []string{"alpha", "charlie"}},
+		{"created after", InstallationQuery{CreatedAfter: time.Date(2020, time.May, 3, 0, 0, 0, 0, time.UTC)}, []string{"bravo", "delta"}},
+		{"created before", InstallationQuery{CreatedBefore: time.Date(2020, time.May, 3, 0, 0, 0, 0, time.UTC)}, []string{"alpha", "charlie"}},
+		{"sort by created", InstallationQuery{SortBy: SortInstallationsByCreated}, []string{"alpha", "charlie", "bravo", "delta"}},
+		{"sort by modified", InstallationQuery{SortBy: SortInstallationsByModified, Descending: true}, []string{"delta", "bravo", "charlie", "alpha"}},
+		{"no matches", InstallationQuery{BundleName: "missing"}, []string{}},
+	}
+
+	for storeName, newStore := range queryDataStores() {
+		t.Run(storeName, func(t *testing.T) {
+			cp := generateQueryData(t, newStore())
+
+			for _, tc := range testcases {
+				t.Run(tc.name, func(t *testing.T) {
+					result, err := cp.QueryInstallations(tc.query)
+					require.NoError(t, err, "QueryInstallations failed")
+					assert.Equal(t, tc.want, installationNames(result.Installations))
+					assert.Empty(t, result.NextPageToken)
+				})
+			}
+
+			t.Run("last claim and result loaded", func(t *testing.T) {
+				result, err := cp.QueryInstallations(InstallationQuery{Status: StatusFailed})
+				require.NoError(t, err, "QueryInstallations failed")
+				require.Len(t, result.Installations, 1)
+
+				bravo := result.Installations[0]
+				lastClaim, err := bravo.GetLastClaim()
+				require.NoError(t, err, "GetLastClaim failed")
+				assert.Equal(t, ActionUpgrade, lastClaim.Action)
+				assert.Equal(t, StatusFailed, bravo.GetLastStatus())
+			})
+		})
+	}
+}
+
+func TestStore_QueryInstallations_Paginate(t *testing.T) {
+	testcases := []struct {
+		name  string
+		query InstallationQuery
+		pages [][]string
+	}{
+		{"by name", InstallationQuery{Limit: 3}, [][]string{{"alpha", "bravo", "charlie"}, {"delta"}}},
+		{"by created", InstallationQuery{Limit: 2, SortBy: SortInstallationsByCreated}, [][]string{{"alpha", "charlie"}, {"bravo", "delta"}}},
+		{"filtered", InstallationQuery{Limit: 1, Status: StatusSucceeded}, [][]string{{"alpha"}, {"charlie"}, {"delta"}}},
+	}
+
+	for storeName, newStore := range queryDataStores() {
+		t.Run(storeName, func(t *testing.T) {
+			cp := generateQueryData(t, newStore())
+
+			for _, tc := range testcases {
+				t.Run(tc.name, func(t *testing.T) {
+					query := tc.query
+					var pages [][]string
+					for {
+						result, err := cp.QueryInstallations(query)
+						require.NoError(t, err, "QueryInstallations failed")
+						pages = append(pages, installationNames(result.Installations))
+
+						if result.NextPageToken == "" {
+							break
+						}
+						require.Less(t, len(pages), 10, "too many pages were returned")
+						query.PageToken = result.NextPageToken
+					}
+					assert.Equal(t, tc.pages, pages)
+				})
+			}
+		})
+	}
+}
+
+func TestInstallationQuery_Validate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		query   InstallationQuery
+		wantErr string
+	}{
+		{"valid", InstallationQuery{SortBy: SortInstallationsByModified, Limit: 10}, ""},
+		{"invalid sort", InstallationQuery{SortBy: "size"}, `invalid installation sort field "size"`},
+		{"negative limit", InstallationQuery{Limit: -1}, "invalid limit -1"},
+		{"invalid page token", InstallationQuery{PageToken: "oops"}, `invalid page token "oops"`},
+		{"invalid time range", InstallationQuery{
+			CreatedAfter:  time.Date(2020, time.May, 2, 0, 0, 0, 0, time.UTC),
+			CreatedBefore: time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC),
+		}, "invalid creation time range"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.query.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+// queryingStore is a data store that saves the fields of items, and runs
+// queries against them, like a database would.
+type queryingStore struct {
+	crud.MockStore
+	fields  map[string]crud.Fields
+	queries *int
+}
+
+func newQueryingStore() queryingStore {
+	return queryingStore{
+		MockStore: crud.NewMockStore(),
+		fields:    map[string]crud.Fields{},
+		queries:   new(int),
+	}
+}
+
+func (s queryingStore) SaveWithFields(itemType string, group string, name string, data []byte, fields crud.Fields) error {
+	s.fields[itemType+"/"+name] = fields
+	return s.MockStore.Save(itemType, group, name, data)
+}
+
+func (s queryingStore) ReadFields(itemType string, name string) (crud.Fields, error) {
+	return s.fields[itemType+"/"+name], nil
+}
+
+func (s queryingStore) Query(query crud.Query) ([]string, error) {
+	*s.queries++
+
+	names, err := s.MockStore.List(query.ItemType, query.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	for _, name := range names {
+		fields := s.fields[query.ItemType+"/"+name]
+		matched := true
+		for _, f := range query.Filters {
+			value := fields[f.Field]
+			switch f.Operator {
+			case crud.FilterEqual:
+				matched = matched && value == f.Value
+			case crud.FilterGreaterOrEqual:
+				matched = matched && value >= f.Value
+			case crud.FilterLess:
+				matched = matched && value < f.Value
+			}
+		}
+		if matched {
+			matches = append(matches, name)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if query.Descending {
+			a, b = b, a
+		}
+		fa, fb := s.fields[query.ItemType+"/"+a][query.SortBy], s.fields[query.ItemType+"/"+b][query.SortBy]
+		if query.SortBy != "" && fa != fb {
+			return fa < fb
+		}
+		return a < b
+	})
+
+	if query.Skip > len(matches) {
+		query.Skip = len(matches)
+	}
+	matches = matches[query.Skip:]
+	if query.Limit > 0 && query.Limit < len(matches) {
+		matches = matches[:query.Limit]
+	}
+	return matches, nil
+}
+
+func TestStore_QueryInstallations_DataStore(t *testing.T) {
+	datastore := newQueryingStore()
+	cp := generateQueryData(t, datastore)
+
+	fields := datastore.fields[ItemTypeInstallations+"/bravo"]
+	assert.Equal(t, StatusFailed, fields[installationFieldStatus])
+	assert.Equal(t, "1.1.0", fields[installationFieldBundleVersion])
+	assert.Equal(t, "2020-05-03T00:00:00.000000000Z", fields[installationFieldCreated])
+
+	_, err := cp.QueryInstallations(InstallationQuery{BundleName: "mysql", Limit: 5})
+	require.NoError(t, err, "QueryInstallations failed")
+	assert.Equal(t, 1, *datastore.queries, "the query should be run by the data store")
+
+	t.Run("fields updated when a claim is deleted", func(t *testing.T) {
+		c, err := cp.ReadLastClaim("bravo")
+		require.NoError(t, err, "ReadLastClaim failed")
+		require.NoError(t, cp.DeleteClaim(c.ID), "DeleteClaim failed")
+
+		result, err := cp.QueryInstallations(InstallationQuery{Status: StatusFailed})
+		require.NoError(t, err, "QueryInstallations failed")
+		assert.Empty(t, result.Installations)
+
+		fields := datastore.fields[ItemTypeInstallations+"/bravo"]
+		assert.Equal(t, StatusSucceeded, fields[installationFieldStatus])
+		assert.Equal(t, "1.0.0", fields[installationFieldBundleVersion])
+	})
+}
+
+func TestStore_ReindexInstallations(t *testing.T) {
+	datastore := newQueryingStore()
+	cp := generateQueryData(t, datastore)
+
+	// Installations saved before the data store supported queries have no fields
+	for key := range datastore.fields {
+		delete(datastore.fields, key)
+	}
+	result, err := cp.QueryInstallations(InstallationQuery{BundleName: "mysql"})
+	require.NoError(t, err, "QueryInstallations failed")
+	assert.Empty(t, result.Installations, "installations without fields should not be matched")
+
+	require.NoError(t, cp.ReindexInstallations(), "ReindexInstallations failed")
+	assert.Equal(t, "2020-05-03T00:00:00.000000000Z", datastore.fields[ItemTypeInstallations+"/bravo"][installationFieldCreated])
+
+	result, err = cp.QueryInstallations(InstallationQuery{BundleName: "mysql"})
+	require.NoError(t, err, "QueryInstallations failed")
+	assert.NotEmpty(t, result.Installations, "reindexed installations should be matched")
+
+	t.Run("no queries", func(t *testing.T) {
+		assert.NoError(t, NewMockStore(nil, nil).ReindexInstallations())
+	})
+}
@@ -62,6 +62,10 @@ func Validate(given valuesource.Set, spec map[string]bundle.Credential) error {
 //	- Validate the credentials against a spec
 //	- Resolve the credentials
 //	- Expand them into bundle values
+//
+// Each credential is resolved by the secret store using its source key, for
+// example "env". Use a secrets.Registry to resolve credentials from more than
+// one secret store.
 func (c *CredentialSet) ResolveCredentials(s secrets.Store) (valuesource.Set, error) {
 	l := len(c.Credentials)
 	res := make(map[string]string, l)
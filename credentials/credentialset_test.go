@@ -2,13 +2,18 @@ package credentials
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/cnabio/cnab-go/secrets"
+	"github.com/cnabio/cnab-go/secrets/file"
 	"github.com/cnabio/cnab-go/secrets/host"
 )
 
@@ -50,3 +55,28 @@ func TestCredentialSet_ResolveCredentials(t *testing.T) {
 		is.Equal(tt.expect, strings.TrimSpace(dest))
 	}
 }
+
+func TestCredentialSet_ResolveCredentials_Registry(t *testing.T) {
+	if err := os.Setenv("TEST_USE_VAR", "kakapu"); err != nil {
+		t.Fatal("could not setup env")
+	}
+	defer os.Unsetenv("TEST_USE_VAR")
+
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	vault := file.NewSecretStore(filepath.Join(tmpdir, "vault.json"), []byte("top-secret"))
+	require.NoError(t, vault.Set("db-password", "hunter2"))
+
+	r := secrets.NewRegistry()
+	require.NoError(t, r.Register(&host.SecretStore{}, host.Sources...))
+	require.NoError(t, r.Register(vault, file.SourceSecret))
+
+	credset, err := Load("testdata/staging-mixed.yaml")
+	require.NoError(t, err)
+
+	results, err := credset.ResolveCredentials(r)
+	require.NoError(t, err)
+	assert.Equal(t, "kakapu", results["use_var"])
+	assert.Equal(t, "hunter2", results["db_password"])
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,7 +23,12 @@ type Driver struct {
 
 // Run executes the command
 func (d *Driver) Run(op *driver.Operation) (driver.OperationResult, error) {
-	return d.exec(op)
+	return d.exec(context.Background(), op)
+}
+
+// RunWithContext executes the command, killing it when the context is done.
+func (d *Driver) RunWithContext(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
+	return d.exec(ctx, op)
 }
 
 // Handles executes the driver with `--handles` and parses the results
@@ -45,7 +51,7 @@ func (d *Driver) cliName() string {
 	return "cnab-" + strings.ToLower(d.Name)
 }
 
-func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
+func (d *Driver) exec(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
 	// We need to do two things here: We need to make it easier for the
 	// command to access data, and we need to make it easy for the command
 	// to pass that data on to the image it invokes. So we do some data
@@ -81,7 +87,7 @@ func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
 	}
 
 	args := []string{}
-	cmd := exec.Command(d.cliName(), args...)
+	cmd := exec.CommandContext(ctx, d.cliName(), args...)
 	cmd.Dir, err = os.Getwd()
 	if err != nil {
 		return driver.OperationResult{}, err
@@ -117,6 +123,9 @@ func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
 	}
 
 	if err = cmd.Wait(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return driver.OperationResult{}, fmt.Errorf("Command driver (%s) was stopped: %v", d.Name, ctxErr)
+		}
 		return driver.OperationResult{}, fmt.Errorf("Command driver (%s) failed executing bundle: %v", d.Name, err)
 	}
 
@@ -3,10 +3,13 @@
 package command
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/bundle/definition"
@@ -174,3 +177,27 @@ func TestCommandDriverOutputs(t *testing.T) {
 	}
 	CreateAndRunTestCommandDriver(t, name, content, testfunc)
 }
+
+func TestCommandDriverRunWithContext(t *testing.T) {
+	content := `#!/bin/sh
+		exec sleep 30
+	`
+	name := "test-canceled.sh"
+	testfunc := func(t *testing.T, cmddriver *Driver) {
+		op := driver.Operation{
+			Action:       "install",
+			Installation: "test",
+			Out:          os.Stdout,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := cmddriver.RunWithContext(ctx, &op)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+		assert.True(t, time.Since(start) < 10*time.Second, "the command should have been killed when the context timed out")
+	}
+	CreateAndRunTestCommandDriver(t, name, content, testfunc)
+}
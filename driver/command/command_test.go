@@ -10,7 +10,7 @@ import (
 	"github.com/cnabio/cnab-go/driver"
 )
 
-var _ driver.Driver = &Driver{}
+var _ driver.ContextDriver = &Driver{}
 
 func TestCheckDriverExists(t *testing.T) {
 	name := "missing-driver"
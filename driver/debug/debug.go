@@ -1,6 +1,7 @@
 package debug
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -16,6 +17,16 @@ type Driver struct {
 
 // Run executes the operation on the Debug driver
 func (d *Driver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	return d.RunWithContext(context.Background(), op)
+}
+
+// RunWithContext executes the operation on the Debug driver, unless the context
+// is already done.
+func (d *Driver) RunWithContext(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return driver.OperationResult{}, err
+	}
+
 	data, err := json.MarshalIndent(op, "", "  ")
 	if err != nil {
 		return driver.OperationResult{}, err
@@ -1,6 +1,7 @@
 package debug
 
 import (
+	"context"
 	"io/ioutil"
 	"testing"
 
@@ -10,7 +11,7 @@ import (
 	"github.com/cnabio/cnab-go/driver"
 )
 
-var _ driver.Driver = &Driver{}
+var _ driver.ContextDriver = &Driver{}
 
 func TestDebugDriver_Handles(t *testing.T) {
 	d := &Driver{}
@@ -39,3 +40,17 @@ func TestDebugDriver_Run(t *testing.T) {
 	_, err := d.Run(op)
 	is.NoError(err)
 }
+
+func TestDebugDriver_RunWithContext(t *testing.T) {
+	d := &Driver{}
+	op := &driver.Operation{
+		Installation: "test",
+		Out:          ioutil.Discard,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := d.RunWithContext(ctx, op)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -40,7 +40,13 @@ type Driver struct {
 
 // Run executes the Docker driver
 func (d *Driver) Run(op *driver.Operation) (driver.OperationResult, error) {
-	return d.exec(op)
+	return d.exec(context.Background(), op)
+}
+
+// RunWithContext executes the Docker driver, stopping the container when the
+// context is done.
+func (d *Driver) RunWithContext(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
+	return d.exec(ctx, op)
 }
 
 // Handles indicates that the Docker driver supports "docker" and "oci"
@@ -171,9 +177,7 @@ func (d *Driver) initializeDockerCli() (command.Cli, error) {
 	return cli, nil
 }
 
-func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
-	ctx := context.Background()
-
+func (d *Driver) exec(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
 	cli, err := d.initializeDockerCli()
 	if err != nil {
 		return driver.OperationResult{}, err
@@ -220,7 +224,8 @@ func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
 	}
 
 	if d.config["CLEANUP_CONTAINERS"] == "true" {
-		defer cli.Client().ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{})
+		// Use a fresh context so that the container is still removed when ctx is done
+		defer cli.Client().ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
 	}
 
 	tarContent, err := generateTar(op.Files)
@@ -271,7 +276,12 @@ func (d *Driver) exec(op *driver.Operation) (driver.OperationResult, error) {
 	}
 	statusc, errc := cli.Client().ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
+	case <-ctx.Done():
+		return d.stopContainer(cli, resp.ID, op, ctx.Err())
 	case err := <-errc:
+		if ctx.Err() != nil {
+			return d.stopContainer(cli, resp.ID, op, ctx.Err())
+		}
 		if err != nil {
 			opResult, fetchErr := d.fetchOutputs(ctx, resp.ID, op)
 			return opResult, containerError("error in container", err, fetchErr)
@@ -304,6 +314,19 @@ func (d *Driver) ApplyConfigurationOptions() error {
 	return nil
 }
 
+// stopContainer stops a container whose operation was cancelled, and collects
+// any outputs that were generated before it was stopped.
+func (d *Driver) stopContainer(cli command.Cli, containerID string, op *driver.Operation, ctxErr error) (driver.OperationResult, error) {
+	// The operation's context is done, so use a fresh one to clean up
+	ctx := context.Background()
+	if err := cli.Client().ContainerKill(ctx, containerID, "SIGKILL"); err != nil {
+		return driver.OperationResult{}, fmt.Errorf("operation stopped: %v. stopping the container failed: %v", ctxErr, err)
+	}
+
+	opResult, fetchErr := d.fetchOutputs(ctx, containerID, op)
+	return opResult, containerError("operation stopped", ctxErr, fetchErr)
+}
+
 func containerError(containerMessage string, containerErr, fetchErr error) error {
 	if fetchErr != nil {
 		return fmt.Errorf("%s: %v. fetching outputs failed: %s", containerMessage, containerErr, fetchErr)
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 
@@ -95,6 +96,30 @@ type Driver interface {
 	Handles(string) bool
 }
 
+// ContextDriver is a Driver that can be cancelled, or time out, using a context.
+// When the context is done, the driver stops the running operation and returns
+// the context's error.
+type ContextDriver interface {
+	Driver
+	// RunWithContext executes the operation inside of the invocation image,
+	// stopping it when the context is done.
+	RunWithContext(ctx context.Context, op *Operation) (OperationResult, error)
+}
+
+// RunWithContext executes the operation with the driver, passing the context
+// along when the driver supports it. Drivers that only implement Driver are not
+// started when the context is already done, but cannot be stopped once running.
+func RunWithContext(ctx context.Context, d Driver, op *Operation) (OperationResult, error) {
+	if cd, ok := d.(ContextDriver); ok {
+		return cd.RunWithContext(ctx, op)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return OperationResult{}, err
+	}
+	return d.Run(op)
+}
+
 // Configurable drivers can explain their configuration, and have it explicitly set
 type Configurable interface {
 	// Config returns a map of configuration names and values that can be set via environment variable
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -84,3 +85,35 @@ func TestOperation_Marshall(t *testing.T) {
 	expectedJSON := string(bytes)
 	is.Equal(expectedJSON, actualJSON)
 }
+
+type contextlessDriver struct {
+	ran bool
+}
+
+func (d *contextlessDriver) Run(op *Operation) (OperationResult, error) {
+	d.ran = true
+	return OperationResult{}, nil
+}
+
+func (d *contextlessDriver) Handles(string) bool {
+	return true
+}
+
+func TestRunWithContext(t *testing.T) {
+	t.Run("driver without context support", func(t *testing.T) {
+		d := &contextlessDriver{}
+		_, err := RunWithContext(context.Background(), d, &Operation{})
+		assert.NoError(t, err)
+		assert.True(t, d.ran, "the driver should have been run")
+	})
+
+	t.Run("driver without context support is not started when canceled", func(t *testing.T) {
+		d := &contextlessDriver{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := RunWithContext(ctx, d, &Operation{})
+		assert.Equal(t, context.Canceled, err)
+		assert.False(t, d.ran, "the driver should not have been run")
+	})
+}
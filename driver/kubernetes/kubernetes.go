@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	watchapi "k8s.io/apimachinery/pkg/watch"
 	batchclientv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -132,6 +134,12 @@ func (k *Driver) setClient(conf *rest.Config) error {
 
 // Run executes the operation inside of the invocation image.
 func (k *Driver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	return k.RunWithContext(context.Background(), op)
+}
+
+// RunWithContext executes the operation inside of the invocation image,
+// deleting the job when the context is done.
+func (k *Driver) RunWithContext(ctx context.Context, op *driver.Operation) (driver.OperationResult, error) {
 	if k.Namespace == "" {
 		return driver.OperationResult{}, fmt.Errorf("KUBE_NAMESPACE is required")
 	}
@@ -253,13 +261,19 @@ func (k *Driver) Run(op *driver.Operation) (driver.OperationResult, error) {
 		LabelSelector: newSingleFieldSelector("job-name", job.ObjectMeta.Name),
 	}
 
-	return driver.OperationResult{}, k.watchJobStatusAndLogs(podSelector, jobSelector, op.Out)
+	err = k.watchJobStatusAndLogs(ctx, podSelector, jobSelector, op.Out)
+	if ctx.Err() != nil && k.SkipCleanup {
+		// The job is normally removed by the deferred cleanup, but it must
+		// always be stopped when the operation is cancelled.
+		k.deleteJob(job.ObjectMeta.Name)
+	}
+	return driver.OperationResult{}, err
 }
 
-func (k *Driver) watchJobStatusAndLogs(podSelector metav1.ListOptions, jobSelector metav1.ListOptions, out io.Writer) error {
+func (k *Driver) watchJobStatusAndLogs(ctx context.Context, podSelector metav1.ListOptions, jobSelector metav1.ListOptions, out io.Writer) error {
 	// Stream Pod logs in the background
 	logsStreamingComplete := make(chan bool)
-	err := k.streamPodLogs(podSelector, out, logsStreamingComplete)
+	err := k.streamPodLogs(ctx, podSelector, out, logsStreamingComplete)
 	if err != nil {
 		return err
 	}
@@ -268,7 +282,19 @@ func (k *Driver) watchJobStatusAndLogs(podSelector metav1.ListOptions, jobSelect
 	if err != nil {
 		return err
 	}
-	for event := range watch.ResultChan() {
+	defer watch.Stop()
+	for {
+		var event watchapi.Event
+		var open bool
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("operation stopped: %v", ctx.Err())
+		case event, open = <-watch.ResultChan():
+		}
+		if !open {
+			break
+		}
+
 		job, ok := event.Object.(*batchv1.Job)
 		if !ok {
 			return fmt.Errorf("unexpected type")
@@ -292,23 +318,41 @@ func (k *Driver) watchJobStatusAndLogs(podSelector metav1.ListOptions, jobSelect
 
 	// Wait for pod logs to finish printing
 	for i := 0; i < int(k.requiredCompletions); i++ {
-		<-logsStreamingComplete
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("operation stopped: %v", ctx.Err())
+		case <-logsStreamingComplete:
+		}
 	}
 
 	return err
 }
 
-func (k *Driver) streamPodLogs(options metav1.ListOptions, out io.Writer, done chan bool) error {
+func (k *Driver) streamPodLogs(ctx context.Context, options metav1.ListOptions, out io.Writer, done chan bool) error {
 	watcher, err := k.pods.Watch(options)
 	if err != nil {
 		return err
 	}
 
 	go func() {
+		// Stop watching once the operation is stopped, so that the goroutine does not leak.
+		defer watcher.Stop()
+
 		// Track pods whose logs have been streamed by pod name. We need to know when we've already
 		// processed logs for a given pod, since multiple lifecycle events are received per pod.
 		streamedLogs := map[string]bool{}
-		for event := range watcher.ResultChan() {
+		for {
+			var event watchapi.Event
+			var open bool
+			select {
+			case <-ctx.Done():
+				return
+			case event, open = <-watcher.ResultChan():
+			}
+			if !open {
+				return
+			}
+
 			pod, ok := event.Object.(*v1.Pod)
 			if !ok {
 				continue
@@ -348,7 +392,11 @@ func (k *Driver) streamPodLogs(options metav1.ListOptions, out io.Writer, done c
 				break
 			}
 
-			done <- true
+			select {
+			case <-ctx.Done():
+				return
+			case done <- true:
+			}
 		}
 	}()
 
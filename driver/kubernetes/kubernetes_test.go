@@ -1,12 +1,17 @@
 package kubernetes
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	watchapi "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/driver"
@@ -41,6 +46,58 @@ func TestDriver_Run(t *testing.T) {
 	assert.Equal(t, len(secretList.Items), 1, "expected one secret to be created")
 }
 
+func TestDriver_RunWithContext(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	namespace := "default"
+	k := Driver{
+		Namespace:   namespace,
+		jobs:        client.BatchV1().Jobs(namespace),
+		secrets:     client.CoreV1().Secrets(namespace),
+		pods:        client.CoreV1().Pods(namespace),
+		SkipCleanup: true,
+	}
+	op := driver.Operation{
+		Action: "install",
+		Out:    os.Stdout,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := k.RunWithContext(ctx, &op)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "operation stopped")
+
+	jobList, _ := k.jobs.List(metav1.ListOptions{})
+	assert.Empty(t, jobList.Items, "expected the job to be deleted when the operation is canceled")
+}
+
+// watchedPods returns a fake watcher from Watch, so that tests can check when it is stopped.
+type watchedPods struct {
+	coreclientv1.PodInterface
+	watcher *watchapi.FakeWatcher
+}
+
+func (p watchedPods) Watch(metav1.ListOptions) (watchapi.Interface, error) {
+	return p.watcher, nil
+}
+
+func TestDriver_StreamPodLogs_Canceled(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	pods := watchedPods{
+		PodInterface: client.CoreV1().Pods("default"),
+		watcher:      watchapi.NewFake(),
+	}
+	k := Driver{pods: pods}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, k.streamPodLogs(ctx, metav1.ListOptions{}, os.Stdout, make(chan bool)))
+	cancel()
+
+	assert.Eventually(t, pods.watcher.IsStopped, time.Second, 10*time.Millisecond,
+		"expected the pod watch to be stopped when the operation is canceled")
+}
+
 func TestImageWithDigest(t *testing.T) {
 	testCases := map[string]bundle.InvocationImage{
 		"foo": {
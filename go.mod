@@ -36,6 +36,7 @@ require (
 	github.com/gofrs/uuid v3.2.0+incompatible // indirect
 	github.com/gogo/googleapis v1.3.0 // indirect
 	github.com/gogo/protobuf v1.3.1 // indirect
+	github.com/google/go-containerregistry v0.0.0-20191015185424-71da34e4d9b3
 	github.com/gorilla/mux v1.7.3 // indirect
 	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
 	github.com/hashicorp/go-multierror v1.1.0
@@ -62,9 +63,9 @@ require (
 	github.com/urfave/cli v1.22.1 // indirect
 	github.com/xeipuuv/gojsonschema v1.2.0
 	github.com/xlab/handysort v0.0.0-20150421192137-fb3537ed64a1 // indirect
-	go.etcd.io/bbolt v1.3.3 // indirect
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/crypto v0.0.0-20191122220453-ac88ee75c92c
 	golang.org/x/mod v0.3.0 // indirect
-	golang.org/x/sys v0.0.0-20190830141801-acfa387b8d69 // indirect
 	golang.org/x/tools v0.0.0-20200521155704-91d71f6c2f04 // indirect
 	gopkg.in/dancannon/gorethink.v3 v3.0.5 // indirect
 	gopkg.in/fatih/pool.v2 v2.0.0 // indirect
@@ -1,6 +1,7 @@
 package packager
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,13 +11,22 @@ import (
 
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/bundle/loader"
+	"github.com/cnabio/cnab-go/bundle/signature"
 )
 
+// ErrUnsignedBundle is returned when importing a bundle that is not signed
+// with an Importer that has TrustedKeys.
+var ErrUnsignedBundle = errors.New("the bundle is not signed, bundle.cnab is missing")
+
 // Importer is responsible for importing a file
 type Importer struct {
 	Source      string
 	Destination string
 	Loader      loader.BundleLoader
+
+	// TrustedKeys verify the signature of a signed bundle (bundle.cnab). When
+	// they are set, unsigned bundles can not be imported.
+	TrustedKeys signature.KeyRing
 }
 
 // NewImporter creates a new secure *Importer
@@ -24,6 +34,9 @@ type Importer struct {
 // source is the filesystem path to the archive.
 // destination is the directory to unpack the contents.
 // load is a loader.BundleLoader preconfigured for loading bundles.
+//
+// Signed bundles are always verified against the Importer's TrustedKeys. When
+// TrustedKeys are set, unsigned bundles are rejected.
 func NewImporter(source, destination string, load loader.BundleLoader) *Importer {
 	return &Importer{
 		Source:      source,
@@ -65,19 +78,32 @@ func (im *Importer) Unzip() (string, *bundle.Bundle, error) {
 		return "", nil, fmt.Errorf("untar failed: %s", err)
 	}
 
-	// We try to load a bundle.cnab file first, and fall back to a bundle.json
-	ext := "cnab"
-	if _, err := os.Stat(filepath.Join(dest, "bundle.cnab")); os.IsNotExist(err) {
-		ext = "json"
-	}
-
-	bun, err := im.Loader.Load(filepath.Join(dest, "bundle."+ext))
+	bundleFile, bun, err := im.load(dest)
 	if err != nil {
 		removeErr := os.RemoveAll(dest)
 		if removeErr != nil {
-			return "", nil, fmt.Errorf("failed to load and validate bundle.%s on import %s and failed to remove invalid bundle from filesystem %s", ext, err, removeErr)
+			return "", nil, fmt.Errorf("failed to load and validate %s on import %s and failed to remove invalid bundle from filesystem %s", bundleFile, err, removeErr)
 		}
-		return "", nil, fmt.Errorf("failed to load and validate bundle.%s: %s", ext, err)
+		return "", nil, fmt.Errorf("failed to load and validate %s: %s", bundleFile, err)
 	}
 	return dest, bun, nil
 }
+
+// load verifies and loads the signed bundle (bundle.cnab) in the directory.
+// When the bundle is not signed, its bundle.json is loaded, unless the Importer
+// has TrustedKeys. Returns the name of the bundle file, along with the bundle.
+func (im *Importer) load(dir string) (string, *bundle.Bundle, error) {
+	signed := filepath.Join(dir, "bundle.cnab")
+	if _, err := os.Stat(signed); err == nil {
+		bun, err := loader.NewSignedLoader(im.TrustedKeys).Load(signed)
+		return "bundle.cnab", bun, err
+	} else if !os.IsNotExist(err) {
+		return "bundle.cnab", nil, err
+	}
+
+	if len(im.TrustedKeys) > 0 {
+		return "bundle.cnab", nil, ErrUnsignedBundle
+	}
+	bun, err := im.Loader.Load(filepath.Join(dir, "bundle.json"))
+	return "bundle.json", bun, err
+}
@@ -1,14 +1,20 @@
 package packager
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
 
 	"github.com/cnabio/cnab-go/bundle/loader"
+	"github.com/cnabio/cnab-go/bundle/signature"
 )
 
 func TestImport(t *testing.T) {
@@ -51,3 +57,93 @@ func TestMalformedImport(t *testing.T) {
 		t.Error("expected malformed bundle error")
 	}
 }
+
+func newTestKey(t *testing.T) *openpgp.Entity {
+	key, err := openpgp.NewEntity("Test", "", "test@example.com", &packet.Config{RSABits: 1024})
+	require.NoError(t, err, "NewEntity failed")
+	return key
+}
+
+func TestImport_UnsignedBundleRejected(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "duffle-import-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	im := Importer{
+		Source:      "testdata/examplebun-0.1.0.tgz",
+		Destination: tempDir,
+		Loader:      loader.NewLoader(),
+		TrustedKeys: signature.NewKeyRing(newTestKey(t)),
+	}
+
+	err = im.Import()
+	require.Error(t, err, "expected the unsigned bundle to be rejected")
+	assert.Contains(t, err.Error(), "failed to load and validate bundle.cnab: "+ErrUnsignedBundle.Error())
+	assert.NoDirExists(t, filepath.Join(tempDir, "examplebun-0.1.0"))
+}
+
+// writeSignedArchive writes a bundle archive that contains the examplebun test
+// bundle, clear-signed with the key as bundle.cnab.
+func writeSignedArchive(t *testing.T, dir string, key *openpgp.Entity) string {
+	b, err := loader.NewLoader().Load("testdata/examplebun/bundle.json")
+	require.NoError(t, err, "could not load the test bundle")
+	signed, err := signature.Sign(*b, key)
+	require.NoError(t, err, "Sign failed")
+
+	source := filepath.Join(dir, "signedbun-0.1.0.tgz")
+	f, err := os.Create(source)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "bundle.cnab",
+		Mode:     0644,
+		Size:     int64(len(signed)),
+	}
+	require.NoError(t, tw.WriteHeader(header))
+	_, err = tw.Write(signed)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return source
+}
+
+func TestImport_SignedBundle(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "duffle-import-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	key := newTestKey(t)
+	source := writeSignedArchive(t, tempDir, key)
+
+	t.Run("trusted key", func(t *testing.T) {
+		im := NewImporter(source, filepath.Join(tempDir, "trusted"), loader.NewLoader())
+		im.TrustedKeys = signature.NewKeyRing(key)
+		_, b, err := im.Unzip()
+		require.NoError(t, err, "Unzip failed")
+		assert.Equal(t, "examplebun", b.Name)
+	})
+
+	t.Run("untrusted key", func(t *testing.T) {
+		dest := filepath.Join(tempDir, "untrusted")
+		im := NewImporter(source, dest, loader.NewLoader())
+		im.TrustedKeys = signature.NewKeyRing(newTestKey(t))
+		err := im.Import()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), signature.ErrNotVerified.Error())
+		assert.NoDirExists(t, filepath.Join(dest, "signedbun-0.1.0"))
+	})
+
+	t.Run("no trusted keys", func(t *testing.T) {
+		dest := filepath.Join(tempDir, "nokeys")
+		im := NewImporter(source, dest, loader.NewLoader())
+		err := im.Import()
+		require.Error(t, err, "a signed bundle should be verified even without trusted keys")
+		assert.Contains(t, err.Error(), signature.ErrNoTrustedKeys.Error())
+	})
+}
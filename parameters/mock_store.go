@@ -0,0 +1,10 @@
+package parameters
+
+import (
+	"github.com/cnabio/cnab-go/utils/crud"
+)
+
+// NewMockStore creates a mock parameters store for unit testing.
+func NewMockStore() Store {
+	return NewParameterStore(crud.NewBackingStore(crud.NewMockStore()))
+}
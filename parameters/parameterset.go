@@ -0,0 +1,119 @@
+package parameters
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/secrets"
+	"github.com/cnabio/cnab-go/valuesource"
+)
+
+// ParameterSet represents a collection of parameters
+type ParameterSet struct {
+	// Name is the name of the parameter set.
+	Name string `json:"name" yaml:"name"`
+	// Created timestamp of the parameter set.
+	Created time.Time `json:"created" yaml:"created"`
+	// Modified timestamp of the parameter set.
+	Modified time.Time `json:"modified" yaml:"modified"`
+	// Parameters is a list of parameter specs.
+	Parameters []valuesource.Strategy `json:"parameters" yaml:"parameters"`
+}
+
+// Load a ParameterSet from a file at a given path.
+//
+// It does not load the individual parameters.
+func Load(path string) (*ParameterSet, error) {
+	pset := &ParameterSet{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pset, err
+	}
+	return pset, yaml.Unmarshal(data, pset)
+}
+
+// ResolveParameters looks up the parameters as described in Source, then copies
+// the resulting value into the Value field of each parameter strategy.
+//
+// The values are strings, use Resolve to convert them to the types defined
+// by a bundle.
+func (p *ParameterSet) ResolveParameters(s secrets.Store) (valuesource.Set, error) {
+	l := len(p.Parameters)
+	res := make(map[string]string, l)
+	for i := 0; i < l; i++ {
+		param := p.Parameters[i]
+		val, err := s.Resolve(param.Source.Key, param.Source.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %v", p.Parameters[i].Name, err)
+		}
+		param.Value = val
+		res[p.Parameters[i].Name] = param.Value
+	}
+	return res, nil
+}
+
+// Resolve the parameters into the values for an action on the bundle, which
+// may be passed to claim.New.
+//
+// The typical workflow for working with a parameter set is:
+//
+//   - Load the set
+//   - Resolve the parameters, which converts each value to the type defined
+//     by the bundle, and applies the defaults of the parameters that are not
+//     in the set
+func (p *ParameterSet) Resolve(s secrets.Store, b *bundle.Bundle, action string) (map[string]interface{}, error) {
+	resolved, err := p.ResolveParameters(s)
+	if err != nil {
+		return nil, err
+	}
+
+	vals, err := ConvertValues(resolved, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle.ValuesOrDefaults(vals, b, action)
+}
+
+// ConvertValues converts resolved parameter values to the type of the
+// parameter's definition in the bundle.
+func ConvertValues(vals valuesource.Set, b *bundle.Bundle) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(vals))
+	for name, val := range vals {
+		converted, err := convertValue(name, val, b)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = converted
+	}
+	return res, nil
+}
+
+// convertValue converts a parameter value to the type of the parameter's
+// definition in the bundle.
+func convertValue(name string, val string, b *bundle.Bundle) (interface{}, error) {
+	param, ok := b.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("parameter %q is not defined by bundle %s", name, b.Name)
+	}
+
+	def, ok := b.Definitions[param.Definition]
+	if !ok {
+		return nil, fmt.Errorf("unable to find definition for %s", name)
+	}
+	def, err := b.Definitions.Dereference(def)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve definition for %s", name)
+	}
+
+	converted, err := def.ConvertValue(val)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to convert parameter %s", name)
+	}
+	return converted, nil
+}
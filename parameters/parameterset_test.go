@@ -0,0 +1,102 @@
+package parameters
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/secrets/host"
+	"github.com/cnabio/cnab-go/valuesource"
+)
+
+func testBundle() *bundle.Bundle {
+	return &bundle.Bundle{
+		Name: "mybun",
+		Definitions: map[string]*definition.Schema{
+			"port":   {Type: "integer"},
+			"flag":   {Type: "boolean"},
+			"string": {Type: "string", Default: "localhost"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"port":  {Definition: "port", Required: true},
+			"debug": {Definition: "flag"},
+			"host":  {Definition: "string"},
+			"region": {
+				Definition: "string",
+				ApplyTo:    []string{claim.ActionInstall},
+			},
+		},
+	}
+}
+
+func TestParameterSet_Resolve(t *testing.T) {
+	if err := os.Setenv("TEST_PORT", "8080"); err != nil {
+		t.Fatal("could not setup env")
+	}
+	defer os.Unsetenv("TEST_PORT")
+
+	pset, err := Load("testdata/staging.yaml")
+	require.NoError(t, err, "Load failed")
+	assert.Equal(t, "staging", pset.Name)
+
+	resolved, err := pset.ResolveParameters(&host.SecretStore{})
+	require.NoError(t, err, "ResolveParameters failed")
+	assert.Equal(t, valuesource.Set{"port": "8080", "debug": "true", "host": "example.com"}, resolved)
+
+	vals, err := pset.Resolve(&host.SecretStore{}, testBundle(), claim.ActionInstall)
+	require.NoError(t, err, "Resolve failed")
+	want := map[string]interface{}{
+		"port":   8080,
+		"debug":  true,
+		"host":   "example.com",
+		"region": "localhost",
+	}
+	assert.Equal(t, want, vals)
+
+	vals, err = pset.Resolve(&host.SecretStore{}, testBundle(), claim.ActionUpgrade)
+	require.NoError(t, err, "Resolve failed")
+	assert.NotContains(t, vals, "region", "parameters that do not apply to the action should be excluded")
+}
+
+func TestParameterSet_Resolve_Errors(t *testing.T) {
+	testcases := []struct {
+		name    string
+		params  []valuesource.Strategy
+		wantErr string
+	}{
+		{
+			name:    "missing required parameter",
+			params:  []valuesource.Strategy{{Name: "host", Source: valuesource.Source{Key: host.SourceValue, Value: "example.com"}}},
+			wantErr: `parameter "port" is required`,
+		},
+		{
+			name:    "invalid value",
+			params:  []valuesource.Strategy{{Name: "port", Source: valuesource.Source{Key: host.SourceValue, Value: "eighty"}}},
+			wantErr: "unable to convert parameter port",
+		},
+		{
+			name:    "undefined parameter",
+			params:  []valuesource.Strategy{{Name: "color", Source: valuesource.Source{Key: host.SourceValue, Value: "blue"}}},
+			wantErr: `parameter "color" is not defined by bundle mybun`,
+		},
+		{
+			name:    "unresolvable source",
+			params:  []valuesource.Strategy{{Name: "port", Source: valuesource.Source{Key: host.SourceEnv, Value: "TEST_MISSING_PORT"}}},
+			wantErr: `parameter "port"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pset := ParameterSet{Name: "test", Parameters: tc.params}
+			_, err := pset.Resolve(&host.SecretStore{}, testBundle(), claim.ActionInstall)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package parameters
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/cnabio/cnab-go/valuesource"
+)
+
+func TestParameterStore(t *testing.T) {
+	ps := NewMockStore()
+	pset := ParameterSet{
+		Name: "staging",
+		Parameters: []valuesource.Strategy{
+			{Name: "port", Source: valuesource.Source{Key: "env", Value: "TEST_PORT"}},
+		},
+	}
+	require.NoError(t, ps.Save(pset), "Save failed")
+
+	names, err := ps.List()
+	require.NoError(t, err, "List failed")
+	assert.Equal(t, []string{"staging"}, names)
+
+	got, err := ps.Read("staging")
+	require.NoError(t, err, "Read failed")
+	assert.Equal(t, pset.Parameters, got.Parameters)
+
+	all, err := ps.ReadAll()
+	require.NoError(t, err, "ReadAll failed")
+	assert.Len(t, all, 1)
+
+	require.NoError(t, ps.Delete("staging"), "Delete failed")
+	_, err = ps.Read("staging")
+	assert.EqualError(t, err, ErrNotFound.Error())
+}
+
+func TestParameterStore_HandlesNotFoundError(t *testing.T) {
+	ps := NewMockStore()
+	mockStore := ps.GetBackingStore().GetDataStore().(crud.MockStore)
+	mockStore.ReadMock = func(itemType string, name string) (bytes []byte, err error) {
+		// Change the default error message to test that we are checking
+		// inside the error message and not matching it exactly
+		return nil, errors.New("wrapping error message: " + crud.ErrRecordDoesNotExist.Error())
+	}
+
+	_, err := ps.Read("missing param set")
+	assert.EqualError(t, err, ErrNotFound.Error())
+}
@@ -0,0 +1,105 @@
+package parameters
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+)
+
+// ResolveSources sets the parameters that are not in vals from their sources
+// in the io.cnab.parameter-sources extension of the bundle, for example an
+// output from the last time that the installation was run. Pass the result to
+// bundle.ValuesOrDefaults, which applies the defaults of any parameters that
+// are still not set.
+//
+// The sources of a parameter are tried in the order of their priority, and
+// the parameter is left unset when none of them have a value, such as when the
+// installation does not exist yet. Source types that are not supported are
+// skipped. Values are converted to the type of the parameter's definition.
+//
+// Sensitive outputs are decrypted by the claim provider, and may only be used
+// as the value of a sensitive parameter. vals is not modified.
+func ResolveSources(p claim.Provider, installation string, b *bundle.Bundle, action string, vals map[string]interface{}) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(vals))
+	for name, val := range vals {
+		res[name] = val
+	}
+
+	sources, ok, err := b.ReadParameterSources()
+	if err != nil || !ok {
+		return res, err
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := res[name]; ok {
+			continue
+		}
+		param, ok := b.Parameters[name]
+		if !ok {
+			return nil, fmt.Errorf("parameter source %s refers to a parameter that is not defined by bundle %s", name, b.Name)
+		}
+		if !param.AppliesTo(action) {
+			continue
+		}
+
+		source := sources[name]
+		for _, sourceType := range source.ListSourcesByPriority() {
+			if sourceType != bundle.ParameterSourceTypeOutput {
+				continue
+			}
+
+			val, ok, err := resolveOutputSource(p, installation, b, name, source.Sources[sourceType].Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not resolve the source of parameter %s", name)
+			}
+			if ok {
+				res[name] = val
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// resolveOutputSource returns the last value of an output of the installation,
+// converted to the type of the parameter. The returned boolean is false when
+// the installation does not have a value for the output.
+func resolveOutputSource(p claim.Provider, installation string, b *bundle.Bundle, param string, output string) (interface{}, bool, error) {
+	o, err := p.ReadLastOutput(installation, output)
+	if err != nil {
+		cause := errors.Cause(err)
+		if cause == claim.ErrInstallationNotFound || cause == claim.ErrOutputNotFound {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "could not read output %s of installation %s", output, installation)
+	}
+
+	outputSensitive, err := o.IsSensitive()
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "could not determine if the output %q is sensitive", output)
+	}
+	paramSensitive, err := b.IsParameterSensitive(param)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "could not determine if the parameter %q is sensitive", param)
+	}
+	if outputSensitive && !paramSensitive {
+		return nil, false, fmt.Errorf("the output %s is sensitive, so it can only be the source of a sensitive parameter", output)
+	}
+
+	val, err := convertValue(param, string(o.Value), b)
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
@@ -0,0 +1,130 @@
+package parameters
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+)
+
+func b64encode(src []byte) ([]byte, error) {
+	dst := make([]byte, base64.StdEncoding.EncodedLen(len(src)))
+	base64.StdEncoding.Encode(dst, src)
+	return dst, nil
+}
+
+func b64decode(src []byte) ([]byte, error) {
+	dst := make([]byte, base64.StdEncoding.DecodedLen(len(src)))
+	n, err := base64.StdEncoding.Decode(dst, src)
+	return dst[:n], err
+}
+
+func sourcesBundle() *bundle.Bundle {
+	writeOnly := true
+	return &bundle.Bundle{
+		Name: "mysql",
+		Definitions: map[string]*definition.Schema{
+			"password": {Type: "string", WriteOnly: &writeOnly},
+			"port":     {Type: "integer", Default: 3306},
+			"host":     {Type: "string"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"password": {Definition: "password"},
+			"port":     {Definition: "port"},
+			"host":     {Definition: "host"},
+		},
+		Outputs: map[string]bundle.Output{
+			"password": {Definition: "password", Path: "/cnab/app/outputs/password"},
+			"port":     {Definition: "port", Path: "/cnab/app/outputs/port"},
+			"host":     {Definition: "host", Path: "/cnab/app/outputs/host"},
+		},
+		Custom: map[string]interface{}{
+			bundle.ParameterSourcesExtensionKey: map[string]interface{}{
+				"password": map[string]interface{}{
+					"priority": []string{"output"},
+					"sources":  map[string]interface{}{"output": map[string]interface{}{"name": "password"}},
+				},
+				"port": map[string]interface{}{
+					"priority": []string{"output"},
+					"sources":  map[string]interface{}{"output": map[string]interface{}{"name": "port"}},
+				},
+			},
+		},
+	}
+}
+
+func saveOutputs(t *testing.T, cp claim.Provider, b bundle.Bundle, outputs map[string]string) {
+	c, err := claim.New("mysql", claim.ActionInstall, b, nil)
+	require.NoError(t, err, "New claim failed")
+	r, err := c.NewResult(claim.StatusSucceeded)
+	require.NoError(t, err, "NewResult failed")
+
+	require.NoError(t, cp.SaveClaim(c), "SaveClaim failed")
+	require.NoError(t, cp.SaveResult(r), "SaveResult failed")
+	for name, value := range outputs {
+		require.NoError(t, cp.SaveOutput(claim.NewOutput(c, r, name, []byte(value))), "SaveOutput failed")
+	}
+}
+
+func TestResolveSources(t *testing.T) {
+	b := sourcesBundle()
+	cp := claim.NewMockStore(b64encode, b64decode)
+	saveOutputs(t, cp, *b, map[string]string{"password": "mypassword", "port": "3307"})
+
+	vals := map[string]interface{}{"host": "localhost"}
+	res, err := ResolveSources(cp, "mysql", b, claim.ActionUpgrade, vals)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"host":     "localhost",
+		"password": "mypassword",
+		"port":     3307,
+	}, res, "the sensitive output should be decrypted and the values converted")
+	assert.Len(t, vals, 1, "the values should not be modified")
+
+	res, err = ResolveSources(cp, "mysql", b, claim.ActionUpgrade, map[string]interface{}{"port": 3308})
+	require.NoError(t, err)
+	assert.Equal(t, 3308, res["port"], "a parameter that is set should not be replaced")
+}
+
+func TestResolveSources_NoInstallation(t *testing.T) {
+	b := sourcesBundle()
+	cp := claim.NewMockStore(nil, nil)
+
+	res, err := ResolveSources(cp, "mysql", b, claim.ActionInstall, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Empty(t, res, "the parameters should be unset when the installation does not exist")
+
+	vals, err := bundle.ValuesOrDefaults(res, b, claim.ActionInstall)
+	require.NoError(t, err)
+	assert.Equal(t, 3306, vals["port"], "the default should be used")
+}
+
+func TestResolveSources_MissingOutput(t *testing.T) {
+	b := sourcesBundle()
+	cp := claim.NewMockStore(nil, nil)
+	saveOutputs(t, cp, *b, map[string]string{"port": "3307"})
+
+	res, err := ResolveSources(cp, "mysql", b, claim.ActionUpgrade, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"port": 3307}, res)
+}
+
+func TestResolveSources_SensitiveOutput(t *testing.T) {
+	b := sourcesBundle()
+	b.Custom[bundle.ParameterSourcesExtensionKey] = map[string]interface{}{
+		"host": map[string]interface{}{
+			"priority": []string{"output"},
+			"sources":  map[string]interface{}{"output": map[string]interface{}{"name": "password"}},
+		},
+	}
+	cp := claim.NewMockStore(nil, nil)
+	saveOutputs(t, cp, *b, map[string]string{"password": "mypassword"})
+
+	_, err := ResolveSources(cp, "mysql", b, claim.ActionUpgrade, map[string]interface{}{})
+	require.EqualError(t, err, "could not resolve the source of parameter host: the output password is sensitive, so it can only be the source of a sensitive parameter")
+}
@@ -0,0 +1,145 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+)
+
+// Migration upgrades a document, such as a bundle or a claim, from one schema
+// version to the next.
+type Migration struct {
+	// From is the schema version of the documents that the migration applies to.
+	From Version
+
+	// To is the schema version of the documents after the migration.
+	To Version
+
+	// Migrate transforms the decoded document. The Migrator sets the
+	// schemaVersion, so a migration that only changes the version may leave
+	// it empty.
+	Migrate func(doc map[string]interface{}) error
+}
+
+// Migrator upgrades documents written with earlier schema versions to the
+// current schema version, by applying its migrations in order.
+type Migrator struct {
+	// Current is the schema version that documents are upgraded to.
+	Current Version
+
+	// Legacy is the schema version of documents without a schemaVersion. When
+	// it is empty, these documents cannot be migrated.
+	Legacy Version
+
+	// Migrations to apply, each one matching documents by its From version.
+	Migrations []Migration
+}
+
+// DocumentVersion returns the schemaVersion of a decoded document, trimming
+// the prefix of versions such as cnab-core-1.0.1. It returns an empty version
+// when the document does not have a schemaVersion.
+func DocumentVersion(doc map[string]interface{}) (Version, error) {
+	value, ok := doc["schemaVersion"]
+	if !ok || value == nil {
+		return "", nil
+	}
+	version, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid schemaVersion %v, it must be a string", value)
+	}
+	if strings.HasPrefix(version, "cnab-") {
+		return GetSemver(version)
+	}
+	return Version(version), nil
+}
+
+// Migrate upgrades the decoded document to the current schema version, and
+// reports whether it was changed.
+func (m Migrator) Migrate(doc map[string]interface{}) (bool, error) {
+	version, err := DocumentVersion(doc)
+	if err != nil {
+		return false, err
+	}
+	if version == "" {
+		if m.Legacy == "" {
+			return false, errors.New("cannot migrate a document without a schemaVersion")
+		}
+		version = m.Legacy
+	}
+
+	// Each migration is applied at most once, so that migrations that form a
+	// cycle cannot loop forever.
+	applied := make(map[int]bool, len(m.Migrations))
+	changed := false
+	for !sameVersion(version, m.Current) {
+		i, ok := m.find(version)
+		if !ok || applied[i] {
+			return changed, m.unsupported(version)
+		}
+		applied[i] = true
+
+		migration := m.Migrations[i]
+		if migration.Migrate != nil {
+			if err := migration.Migrate(doc); err != nil {
+				return changed, errors.Wrapf(err, "cannot migrate the document from schema version %s to %s", migration.From, migration.To)
+			}
+		}
+		version = migration.To
+		doc["schemaVersion"] = string(version)
+		changed = true
+	}
+	return changed, nil
+}
+
+// MigrateData upgrades a JSON document to the current schema version. It
+// returns the document unmodified when it already has the current schema
+// version, and whether it was changed.
+func (m Migrator) MigrateData(data []byte) ([]byte, bool, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as they were written, instead of converting them to floats
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false, errors.Wrap(err, "cannot decode the document")
+	}
+
+	changed, err := m.Migrate(doc)
+	if err != nil || !changed {
+		return data, false, err
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, false, errors.Wrap(err, "cannot encode the migrated document")
+	}
+	return migrated, true, nil
+}
+
+func (m Migrator) find(version Version) (int, bool) {
+	for i, migration := range m.Migrations {
+		if sameVersion(version, migration.From) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (m Migrator) unsupported(version Version) error {
+	v, err := semver.NewVersion(string(version))
+	current, currentErr := semver.NewVersion(string(m.Current))
+	if err == nil && currentErr == nil && v.GreaterThan(current) {
+		return fmt.Errorf("the schema version %s is newer than the supported schema version %s", version, m.Current)
+	}
+	return fmt.Errorf("there is no migration from schema version %s to %s", version, m.Current)
+}
+
+// sameVersion compares versions as strings, because semver ignores the build
+// metadata that distinguishes drafts, such as 1.0.0-DRAFT+b5ed2f3. The
+// optional v prefix is ignored.
+func sameVersion(a Version, b Version) bool {
+	return strings.TrimPrefix(string(a), "v") == strings.TrimPrefix(string(b), "v")
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrator() Migrator {
+	rename := func(from string, to string) func(map[string]interface{}) error {
+		return func(doc map[string]interface{}) error {
+			doc[to] = doc[from]
+			delete(doc, from)
+			return nil
+		}
+	}
+	return Migrator{
+		Current: "1.2.0",
+		Migrations: []Migration{
+			{From: "v1.0.0", To: "1.1.0", Migrate: rename("title", "name")},
+			{From: "1.1.0", To: "1.2.0"},
+		},
+	}
+}
+
+func TestDocumentVersion(t *testing.T) {
+	v, err := DocumentVersion(map[string]interface{}{"schemaVersion": "cnab-core-1.0.1"})
+	require.NoError(t, err)
+	assert.Equal(t, Version("1.0.1"), v)
+
+	v, err = DocumentVersion(map[string]interface{}{"schemaVersion": "v1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, Version("v1.0.0"), v)
+
+	v, err = DocumentVersion(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Empty(t, v)
+
+	_, err = DocumentVersion(map[string]interface{}{"schemaVersion": 1})
+	assert.EqualError(t, err, "invalid schemaVersion 1, it must be a string")
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	m := testMigrator()
+
+	t.Run("ordered", func(t *testing.T) {
+		doc := map[string]interface{}{"schemaVersion": "1.0.0", "title": "mybun"}
+		changed, err := m.Migrate(doc)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, map[string]interface{}{"schemaVersion": "1.2.0", "name": "mybun"}, doc)
+	})
+
+	t.Run("current", func(t *testing.T) {
+		doc := map[string]interface{}{"schemaVersion": "v1.2.0", "name": "mybun"}
+		changed, err := m.Migrate(doc)
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, "v1.2.0", doc["schemaVersion"])
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		doc := map[string]interface{}{"title": "mybun"}
+		_, err := m.Migrate(doc)
+		assert.EqualError(t, err, "cannot migrate a document without a schemaVersion")
+
+		legacy := m
+		legacy.Legacy = "1.0.0"
+		changed, err := legacy.Migrate(doc)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, map[string]interface{}{"schemaVersion": "1.2.0", "name": "mybun"}, doc)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		_, err := m.Migrate(map[string]interface{}{"schemaVersion": "2.0.0"})
+		assert.EqualError(t, err, "the schema version 2.0.0 is newer than the supported schema version 1.2.0")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := m.Migrate(map[string]interface{}{"schemaVersion": "0.9.0"})
+		assert.EqualError(t, err, "there is no migration from schema version 0.9.0 to 1.2.0")
+	})
+
+	t.Run("build metadata", func(t *testing.T) {
+		drafts := Migrator{
+			Current:    "1.0.0-DRAFT+b",
+			Migrations: []Migration{{From: "1.0.0-DRAFT+a", To: "1.0.0-DRAFT+b"}},
+		}
+		changed, err := drafts.Migrate(map[string]interface{}{"schemaVersion": "1.0.0-DRAFT+a"})
+		require.NoError(t, err)
+		assert.True(t, changed, "drafts that only differ by their build metadata should be migrated")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		cycle := Migrator{
+			Current: "2.0.0",
+			Migrations: []Migration{
+				{From: "1.0.0", To: "1.1.0"},
+				{From: "1.1.0", To: "1.0.0"},
+			},
+		}
+		_, err := cycle.Migrate(map[string]interface{}{"schemaVersion": "1.0.0"})
+		assert.EqualError(t, err, "there is no migration from schema version 1.0.0 to 2.0.0")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		failing := Migrator{
+			Current: "1.1.0",
+			Migrations: []Migration{{From: "1.0.0", To: "1.1.0", Migrate: func(map[string]interface{}) error {
+				return errors.New("oops")
+			}}},
+		}
+		_, err := failing.Migrate(map[string]interface{}{"schemaVersion": "1.0.0"})
+		assert.EqualError(t, err, "cannot migrate the document from schema version 1.0.0 to 1.1.0: oops")
+	})
+}
+
+func TestMigrator_MigrateData(t *testing.T) {
+	m := testMigrator()
+
+	current := []byte(`{"schemaVersion": "1.2.0", "name": "mybun"}`)
+	data, changed, err := m.MigrateData(current)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, current, data, "a current document should not be rewritten")
+
+	data, changed, err = m.MigrateData([]byte(`{"schemaVersion": "v1.0.0", "title": "mybun", "size": 12345678901234567890}`))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.JSONEq(t, `{"schemaVersion": "1.2.0", "name": "mybun", "size": 12345678901234567890}`, string(data))
+	assert.Contains(t, string(data), "12345678901234567890", "numbers should not be converted to floats")
+
+	_, _, err = m.MigrateData([]byte(`not json`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot decode the document")
+}
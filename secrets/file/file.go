@@ -0,0 +1,307 @@
+package file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/cnabio/cnab-go/secrets"
+)
+
+const (
+	// SourceSecret is the source key for secrets in an encrypted file, for
+	// example a credential with a source of "secret: db-password".
+	SourceSecret = "secret"
+
+	// SchemaVersion of the encrypted secrets file.
+	SchemaVersion = "1.0.0"
+
+	// kdfScrypt identifies the scrypt key derivation function.
+	kdfScrypt = "scrypt"
+
+	// keySize is the size of the derived AES-256 key.
+	keySize = 32
+
+	// fileMode only allows the current user to access the secrets file.
+	fileMode os.FileMode = 0600
+
+	// The maximum scrypt parameters that are accepted from a secrets file, so
+	// that a modified file cannot make deriving the key use excessive memory
+	// or time.
+	maxScryptN = 1 << 20
+	maxScryptR = 16
+	maxScryptP = 16
+)
+
+// ErrSecretNotFound represents a secret that is not in the secrets file.
+var ErrSecretNotFound = errors.New("Secret does not exist")
+
+// defaultKDF is the cost of deriving the encryption key from a passphrase
+// when the secrets file is written, using the recommended scrypt parameters.
+var defaultKDF = kdfParameters{Name: kdfScrypt, N: 32768, R: 8, P: 1}
+
+var _ secrets.Store = &SecretStore{}
+
+// SecretStore resolves secrets from a local file that is encrypted with a
+// passphrase. The secrets are encrypted with AES-256-GCM, using a key derived
+// from the passphrase with scrypt.
+//
+// Secrets are identified by name. Register the store with a secrets.Registry
+// for SourceSecret, or any other source key, to use it with a credential set.
+type SecretStore struct {
+	path       string
+	passphrase []byte
+
+	// mu serializes changes to the file made by this store.
+	mu sync.Mutex
+
+	// keysMu protects keys, the keys derived from the passphrase, by the
+	// parameters used to derive them, so that scrypt is only run once for
+	// each salt.
+	keysMu sync.Mutex
+	keys   map[string][]byte
+}
+
+// encryptedFile is the format of the secrets file.
+type encryptedFile struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	KDF           kdfParameters `json:"kdf"`
+	Nonce         []byte        `json:"nonce"`
+	Ciphertext    []byte        `json:"ciphertext"`
+}
+
+// kdfParameters are the parameters used to derive the key from the passphrase.
+type kdfParameters struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// NewSecretStore creates a store for the secrets file at the path, which is
+// created when the first secret is set.
+func NewSecretStore(path string, passphrase []byte) *SecretStore {
+	return &SecretStore{
+		path:       path,
+		passphrase: passphrase,
+		keys:       map[string][]byte{},
+	}
+}
+
+// Resolve the secret named keyValue. The keyName is not used, so the store
+// can be registered for any source key.
+func (s *SecretStore) Resolve(keyName string, keyValue string) (string, error) {
+	values, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[keyValue]
+	if !ok {
+		return "", errors.Wrapf(ErrSecretNotFound, "secret %s is not defined in %s", keyValue, s.path)
+	}
+	return value, nil
+}
+
+// List the names of the secrets in the file, sorted by name.
+func (s *SecretStore) List() ([]string, error) {
+	values, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set the value of a secret, creating the secrets file if it does not exist.
+func (s *SecretStore) Set(name string, value string) error {
+	if name == "" {
+		return errors.New("the secret name must be set")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.read()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return s.write(values)
+}
+
+// Delete a secret from the file.
+func (s *SecretStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return errors.Wrapf(ErrSecretNotFound, "secret %s is not defined in %s", name, s.path)
+	}
+	delete(values, name)
+	return s.write(values)
+}
+
+// read and decrypt the secrets in the file. A file that does not exist has
+// no secrets.
+func (s *SecretStore) read() (map[string]string, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, errors.Wrapf(err, "could not read the secrets file %s", s.path)
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "could not parse the secrets file %s", s.path)
+	}
+	if f.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("unsupported secrets file schema version %q, expected %q", f.SchemaVersion, SchemaVersion)
+	}
+
+	gcm, err := s.newCipher(f.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid secrets file %s, the nonce must be %d bytes", s.path, gcm.NonceSize())
+	}
+
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the secrets file %s, the passphrase is incorrect or the file is corrupt", s.path)
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, errors.Wrapf(err, "could not parse the decrypted secrets file %s", s.path)
+	}
+	return values, nil
+}
+
+// write the secrets to the file, encrypted with a new salt and nonce. The
+// file is replaced atomically, so it cannot be left partially written.
+func (s *SecretStore) write(values map[string]string) error {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the secrets")
+	}
+
+	f := encryptedFile{SchemaVersion: SchemaVersion, KDF: defaultKDF}
+	f.KDF.Salt, err = randomBytes(16)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := s.newCipher(f.KDF)
+	if err != nil {
+		return err
+	}
+	f.Nonce, err = randomBytes(gcm.NonceSize())
+	if err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the secrets file")
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "could not create the directory for the secrets file %s", s.path)
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "could not create a temporary file for %s", s.path)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(fileMode)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	return errors.Wrapf(err, "could not write the secrets file %s", s.path)
+}
+
+// newCipher derives the key from the passphrase and creates the AES-GCM cipher.
+func (s *SecretStore) newCipher(kdf kdfParameters) (cipher.AEAD, error) {
+	if kdf.Name != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation function %q in the secrets file %s", kdf.Name, s.path)
+	}
+
+	key, err := s.deriveKey(kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives the key from the passphrase with scrypt, or returns the
+// key previously derived with the same parameters.
+func (s *SecretStore) deriveKey(kdf kdfParameters) ([]byte, error) {
+	if kdf.N > maxScryptN || kdf.R > maxScryptR || kdf.P > maxScryptP {
+		return nil, fmt.Errorf("the scrypt parameters in the secrets file %s exceed the limits of N=%d, r=%d and p=%d", s.path, maxScryptN, maxScryptR, maxScryptP)
+	}
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	id := fmt.Sprintf("%x:%d:%d:%d", kdf.Salt, kdf.N, kdf.R, kdf.P)
+	if key, ok := s.keys[id]; ok {
+		return key, nil
+	}
+
+	key, err := scrypt.Key(s.passphrase, kdf.Salt, kdf.N, kdf.R, kdf.P, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not derive the encryption key from the passphrase")
+	}
+	s.keys[id] = key
+	return key, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, errors.Wrap(err, "could not generate random bytes")
+	}
+	return b, nil
+}
@@ -0,0 +1,127 @@
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSecretStore(t *testing.T) (*SecretStore, func()) {
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	return NewSecretStore(filepath.Join(tmpdir, "secrets", "vault.json"), []byte("top-secret")), func() { os.RemoveAll(tmpdir) }
+}
+
+func TestSecretStore(t *testing.T) {
+	s, cleanup := newTestSecretStore(t)
+	defer cleanup()
+
+	names, err := s.List()
+	require.NoError(t, err, "a missing secrets file should have no secrets")
+	assert.Empty(t, names)
+
+	require.NoError(t, s.Set("db-password", "hunter2"))
+	require.NoError(t, s.Set("api-token", "abc123"))
+
+	value, err := s.Resolve(SourceSecret, "db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	names, err = s.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api-token", "db-password"}, names)
+
+	data, err := ioutil.ReadFile(s.path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2", "the secrets should be encrypted")
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(s.path)
+		require.NoError(t, err)
+		assert.Equal(t, fileMode, fi.Mode().Perm())
+	}
+
+	require.NoError(t, s.Delete("db-password"))
+	_, err = s.Resolve(SourceSecret, "db-password")
+	require.Error(t, err)
+	assert.Equal(t, ErrSecretNotFound, errors.Cause(err))
+
+	err = s.Delete("db-password")
+	assert.Equal(t, ErrSecretNotFound, errors.Cause(err))
+}
+
+func TestSecretStore_WrongPassphrase(t *testing.T) {
+	s, cleanup := newTestSecretStore(t)
+	defer cleanup()
+	require.NoError(t, s.Set("db-password", "hunter2"))
+
+	other := NewSecretStore(s.path, []byte("wrong"))
+	_, err := other.Resolve(SourceSecret, "db-password")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the passphrase is incorrect")
+
+	assert.Error(t, other.Set("api-token", "abc123"), "the secrets file should not be overwritten with a different passphrase")
+	value, err := s.Resolve(SourceSecret, "db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+}
+
+func TestSecretStore_Tampered(t *testing.T) {
+	s, cleanup := newTestSecretStore(t)
+	defer cleanup()
+	require.NoError(t, s.Set("db-password", "hunter2"))
+
+	data, err := ioutil.ReadFile(s.path)
+	require.NoError(t, err)
+	var f encryptedFile
+	require.NoError(t, json.Unmarshal(data, &f))
+	f.Ciphertext[0] ^= 0xff
+	data, err = json.Marshal(f)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(s.path, data, fileMode))
+
+	_, err = s.Resolve(SourceSecret, "db-password")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not decrypt")
+}
+
+func TestSecretStore_CachesKey(t *testing.T) {
+	s, cleanup := newTestSecretStore(t)
+	defer cleanup()
+	require.NoError(t, s.Set("db-password", "hunter2"))
+	require.Len(t, s.keys, 1)
+
+	for i := 0; i < 2; i++ {
+		value, err := s.Resolve(SourceSecret, "db-password")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", value)
+	}
+	assert.Len(t, s.keys, 1, "the key derived for the salt should be reused")
+}
+
+func TestSecretStore_KDFLimits(t *testing.T) {
+	s, cleanup := newTestSecretStore(t)
+	defer cleanup()
+	require.NoError(t, s.Set("db-password", "hunter2"))
+
+	data, err := ioutil.ReadFile(s.path)
+	require.NoError(t, err)
+	var f encryptedFile
+	require.NoError(t, json.Unmarshal(data, &f))
+	f.KDF.N = maxScryptN << 1
+	data, err = json.Marshal(f)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(s.path, data, fileMode))
+
+	other := NewSecretStore(s.path, []byte("top-secret"))
+	_, err = other.Resolve(SourceSecret, "db-password")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceed the limits")
+	assert.Empty(t, other.keys)
+}
@@ -17,6 +17,10 @@ const (
 	SourceValue   = "value"
 )
 
+// Sources are the source keys resolved by a SecretStore, for registering it
+// with a secrets.Registry.
+var Sources = []string{SourceEnv, SourceCommand, SourcePath, SourceValue}
+
 var _ secrets.Store = &SecretStore{}
 
 type SecretStore struct{}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var _ Store = &Registry{}
+
+// Registry is a Store that dispatches each secret to the Store registered for
+// its source key, so that a credential set can mix secrets from several stores.
+//
+//	r := secrets.NewRegistry()
+//	r.Register(&host.SecretStore{}, host.Sources...)
+//	r.Register(vault, file.SourceSecret)
+//	values, err := credset.ResolveCredentials(r)
+type Registry struct {
+	// stores maps a lower case source key to the store that resolves it.
+	stores map[string]Store
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		stores: make(map[string]Store),
+	}
+}
+
+// Register the store for each of the source keys, for example "env". Source
+// keys are case insensitive, and may only be registered once.
+func (r *Registry) Register(store Store, keys ...string) error {
+	if store == nil {
+		return fmt.Errorf("cannot register a nil secret store")
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("at least one source key must be specified when registering a %T secret store", store)
+	}
+
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if key == "" {
+			return fmt.Errorf("cannot register a %T secret store for an empty source key", store)
+		}
+		if existing, ok := r.stores[key]; ok {
+			return fmt.Errorf("source key %q is already registered to a %T secret store", key, existing)
+		}
+	}
+
+	for _, key := range keys {
+		r.stores[strings.ToLower(key)] = store
+	}
+	return nil
+}
+
+// Lookup returns the store registered for the source key.
+func (r *Registry) Lookup(key string) (Store, bool) {
+	store, ok := r.stores[strings.ToLower(key)]
+	return store, ok
+}
+
+// Sources returns the registered source keys, sorted by name.
+func (r *Registry) Sources() []string {
+	keys := make([]string, 0, len(r.stores))
+	for key := range r.stores {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Resolve a secret with the store registered for the source key.
+func (r *Registry) Resolve(keyName string, keyValue string) (string, error) {
+	store, ok := r.Lookup(keyName)
+	if !ok {
+		return "", fmt.Errorf("invalid value source: %s, no secret store is registered for it", keyName)
+	}
+	return store.Resolve(keyName, keyValue)
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixStore resolves a secret by prefixing its value, to identify which
+// store resolved it.
+type prefixStore string
+
+func (s prefixStore) Resolve(keyName string, keyValue string) (string, error) {
+	return fmt.Sprintf("%s:%s", s, keyValue), nil
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(prefixStore("host"), "env", "value"))
+	require.NoError(t, r.Register(prefixStore("vault"), "secret"))
+
+	testcases := []struct {
+		keyName string
+		want    string
+	}{
+		{"env", "host:foo"},
+		{"value", "host:foo"},
+		{"secret", "vault:foo"},
+		{"SECRET", "vault:foo"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.keyName, func(t *testing.T) {
+			got, err := r.Resolve(tc.keyName, "foo")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	assert.Equal(t, []string{"env", "secret", "value"}, r.Sources())
+
+	_, err := r.Resolve("command", "foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid value source: command")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(prefixStore("host"), "env"))
+
+	err := r.Register(prefixStore("other"), "secret", "ENV")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `source key "env" is already registered`)
+	_, ok := r.Lookup("secret")
+	assert.False(t, ok, "no keys should be registered when registration fails")
+
+	assert.Error(t, r.Register(prefixStore("other")), "at least one key is required")
+	assert.Error(t, r.Register(prefixStore("other"), ""), "the key is required")
+	assert.Error(t, r.Register(nil, "secret"), "the store is required")
+}
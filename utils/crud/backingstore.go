@@ -1,6 +1,15 @@
 package crud
 
+import (
+	"fmt"
+	"time"
+)
+
 var _ Store = &BackingStore{}
+var _ HasCommitBatch = &BackingStore{}
+var _ HasListGroups = &BackingStore{}
+var _ HasLock = &BackingStore{}
+var _ HasQuery = &BackingStore{}
 
 // BackingStore wraps another store that may have Connect/Close methods that
 // need to be called.
@@ -130,6 +139,22 @@ func (s *BackingStore) ReadAll(itemType string, group string) ([][]byte, error)
 	return results, nil
 }
 
+// ListGroups returns the groups of an item type, when the backing store
+// implements HasListGroups.
+func (s *BackingStore) ListGroups(itemType string) ([]string, error) {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := s.datastore.(HasListGroups)
+	if !ok {
+		return nil, fmt.Errorf("the %T data store does not support listing groups", s.datastore)
+	}
+	return lister.ListGroups(itemType)
+}
+
 func (s *BackingStore) Delete(itemType string, name string) error {
 	handleClose, err := s.HandleConnect()
 	defer handleClose()
@@ -140,6 +165,105 @@ func (s *BackingStore) Delete(itemType string, name string) error {
 	return s.datastore.Delete(itemType, name)
 }
 
+// CommitBatch saves all of the items in the batch, or none of them. The batch is
+// committed by the backing store when it implements HasCommitBatch, otherwise
+// the items are saved one at a time and rolled back upon failure.
+func (s *BackingStore) CommitBatch(batch Batch) error {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	if batcher, ok := s.datastore.(HasCommitBatch); ok {
+		return batcher.CommitBatch(batch)
+	}
+	return commitBatch(s.datastore, batch)
+}
+
+// SaveWithFields saves the item with the fields that it can be queried by, when
+// the backing store implements HasQuery.
+func (s *BackingStore) SaveWithFields(itemType string, group string, name string, data []byte, fields Fields) error {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	querier, ok := s.datastore.(HasQuery)
+	if !ok {
+		return fmt.Errorf("the %T data store does not support queries", s.datastore)
+	}
+	return querier.SaveWithFields(itemType, group, name, data, fields)
+}
+
+// ReadFields returns the fields of the item, when the backing store implements
+// HasQuery.
+func (s *BackingStore) ReadFields(itemType string, name string) (Fields, error) {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return nil, err
+	}
+
+	querier, ok := s.datastore.(HasQuery)
+	if !ok {
+		return nil, fmt.Errorf("the %T data store does not support queries", s.datastore)
+	}
+	return querier.ReadFields(itemType, name)
+}
+
+// Query returns the names of the items that match the query, when the backing
+// store implements HasQuery.
+func (s *BackingStore) Query(query Query) ([]string, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return nil, err
+	}
+
+	querier, ok := s.datastore.(HasQuery)
+	if !ok {
+		return nil, fmt.Errorf("the %T data store does not support queries", s.datastore)
+	}
+	return querier.Query(query)
+}
+
+// Lock acquires a lease on the record, when the backing store implements HasLock.
+func (s *BackingStore) Lock(itemType string, name string, owner string, ttl time.Duration) error {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	locker, ok := s.datastore.(HasLock)
+	if !ok {
+		return fmt.Errorf("the %T data store does not support locking", s.datastore)
+	}
+	return locker.Lock(itemType, name, owner, ttl)
+}
+
+// Unlock releases the owner's lease on the record, when the backing store
+// implements HasLock.
+func (s *BackingStore) Unlock(itemType string, name string, owner string) error {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return err
+	}
+
+	locker, ok := s.datastore.(HasLock)
+	if !ok {
+		return fmt.Errorf("the %T data store does not support locking", s.datastore)
+	}
+	return locker.Unlock(itemType, name, owner)
+}
+
 func (s *BackingStore) shouldAutoConnect() bool {
 	// If the connection is already open, let the upstream
 	// caller manage the connection.
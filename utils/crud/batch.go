@@ -0,0 +1,93 @@
+package crud
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Batch is a set of items that are saved to a Store together, so that either
+// all of the items are saved or none of them are.
+type Batch struct {
+	// Items to save, in the order that they were added to the batch.
+	Items []BatchItem
+}
+
+// BatchItem is an item that is saved as part of a Batch.
+type BatchItem struct {
+	ItemType string
+	Group    string
+	Name     string
+	Data     []byte
+}
+
+// Save adds an item to the batch.
+func (b *Batch) Save(itemType string, group string, name string, data []byte) {
+	b.Items = append(b.Items, BatchItem{
+		ItemType: itemType,
+		Group:    group,
+		Name:     name,
+		Data:     data,
+	})
+}
+
+// HasCommitBatch indicates that a store can commit a Batch of changes
+// atomically itself. Stores that do not implement it are still supported by
+// BackingStore.CommitBatch, which rolls back the saved items when the batch
+// fails.
+type HasCommitBatch interface {
+	CommitBatch(batch Batch) error
+}
+
+// previousItem is the state of an item before it was changed by a batch.
+type previousItem struct {
+	BatchItem
+	exists bool
+}
+
+// commitBatch saves each item in the batch to a store that does not support
+// batches, and restores the items that were already saved when one of them
+// fails.
+func commitBatch(store Store, batch Batch) error {
+	previous := make([]previousItem, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		prev := previousItem{BatchItem: item}
+		data, err := store.Read(item.ItemType, item.Name)
+		if err == nil {
+			prev.exists = true
+			prev.Data = data
+		} else if !isNotExist(err) {
+			return errors.Wrapf(err, "could not read %s %s before saving the batch", item.ItemType, item.Name)
+		}
+
+		if err := store.Save(item.ItemType, item.Group, item.Name, item.Data); err != nil {
+			if rollbackErr := rollbackBatch(store, previous); rollbackErr != nil {
+				return errors.Wrapf(err, "could not save %s %s and the batch could not be rolled back: %v", item.ItemType, item.Name, rollbackErr)
+			}
+			return errors.Wrapf(err, "could not save %s %s, the batch was rolled back", item.ItemType, item.Name)
+		}
+		previous = append(previous, prev)
+	}
+	return nil
+}
+
+// rollbackBatch restores items to their state before the batch, in reverse order.
+func rollbackBatch(store Store, previous []previousItem) error {
+	for i := len(previous) - 1; i >= 0; i-- {
+		prev := previous[i]
+		var err error
+		if prev.exists {
+			err = store.Save(prev.ItemType, prev.Group, prev.Name, prev.Data)
+		} else {
+			err = store.Delete(prev.ItemType, prev.Name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not restore %s %s", prev.ItemType, prev.Name)
+		}
+	}
+	return nil
+}
+
+// isNotExist returns true when the error indicates that a record does not exist
+// in the store.
+func isNotExist(err error) bool {
+	return err != nil && errors.Cause(err) == ErrRecordDoesNotExist
+}
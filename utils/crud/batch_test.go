@@ -0,0 +1,62 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore fails to save items of the specified type.
+type failingStore struct {
+	MockStore
+	failOn string
+}
+
+func (s failingStore) Save(itemType string, group string, name string, data []byte) error {
+	if itemType == s.failOn {
+		return errors.New("save failed")
+	}
+	return s.MockStore.Save(itemType, group, name, data)
+}
+
+func TestBackingStore_CommitBatch(t *testing.T) {
+	t.Run("all items saved", func(t *testing.T) {
+		s := NewBackingStore(NewMockStore())
+
+		var batch Batch
+		batch.Save(testItemType, testGroup, "item1", []byte("data1"))
+		batch.Save("other-items", "", "item2", []byte("data2"))
+		require.NoError(t, s.CommitBatch(batch))
+
+		data, err := s.Read(testItemType, "item1")
+		require.NoError(t, err)
+		assert.Equal(t, "data1", string(data))
+
+		data, err = s.Read("other-items", "item2")
+		require.NoError(t, err)
+		assert.Equal(t, "data2", string(data))
+	})
+
+	t.Run("rollback on failure", func(t *testing.T) {
+		mock := NewMockStore()
+		s := NewBackingStore(failingStore{MockStore: mock, failOn: "other-items"})
+		require.NoError(t, mock.Save(testItemType, testGroup, "existing", []byte("original")))
+
+		var batch Batch
+		batch.Save(testItemType, testGroup, "existing", []byte("changed"))
+		batch.Save(testItemType, testGroup, "new", []byte("data"))
+		batch.Save("other-items", "", "item", []byte("data"))
+		err := s.CommitBatch(batch)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the batch was rolled back")
+
+		data, err := s.Read(testItemType, "existing")
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data), "the existing item should be restored")
+
+		_, err = s.Read(testItemType, "new")
+		assert.Equal(t, ErrRecordDoesNotExist, err, "the new item should be removed")
+	})
+}
@@ -0,0 +1,346 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultBoltTimeout is how long to wait to acquire the lock on a bolt
+// database file that is in use by another process.
+const DefaultBoltTimeout = 5 * time.Second
+
+var _ Store = &boltStore{}
+var _ HasCommitBatch = &boltStore{}
+var _ HasListGroups = &boltStore{}
+var _ HasLock = &boltStore{}
+
+// boltLocksBucket contains the leases on items, keyed by ITEMTYPE\x00NAME.
+var boltLocksBucket = []byte(".locks")
+
+// Buckets within each item type's bucket.
+var (
+	// boltItemsBucket maps an item's name to its data.
+	boltItemsBucket = []byte("items")
+
+	// boltGroupsBucket maps an item's name to its group.
+	boltGroupsBucket = []byte("groups")
+
+	// boltIndexBucket contains a key for each item, GROUP\x00NAME, so that the
+	// items in a group can be listed with a prefix scan.
+	boltIndexBucket = []byte("index")
+)
+
+type boltStore struct {
+	path    string
+	timeout time.Duration
+
+	// mu protects db, which is replaced by Connect and Close.
+	mu sync.RWMutex
+	db *bolt.DB
+}
+
+// NewBoltStore creates a store backed by a single bolt database file, which is
+// created if it does not exist.
+//
+// The file is locked while the store is connected. By default the store is
+// connected for the duration of each operation, so that other processes may
+// also use the file. Once connected, the store is safe for concurrent use and
+// reads do not block each other. To share the store between goroutines,
+// disable AutoClose on the returned *BackingStore and call Connect first.
+func NewBoltStore(path string) *BackingStore {
+	db := &boltStore{
+		path:    path,
+		timeout: DefaultBoltTimeout,
+	}
+	return NewBackingStore(db)
+}
+
+func (s *boltStore) Connect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), dirMode); err != nil {
+		return wrapBoltErr(err)
+	}
+
+	db, err := bolt.Open(s.path, fileMode, &bolt.Options{Timeout: s.timeout})
+	if err != nil {
+		return errors.Wrapf(err, "bolt storage error: could not open %s", s.path)
+	}
+	s.db = db
+	return nil
+}
+
+func (s *boltStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return wrapBoltErr(err)
+}
+
+// view runs a read-only transaction against the database.
+func (s *boltStore) view(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return fmt.Errorf("database %s is not connected", s.path)
+	}
+	return s.db.View(fn)
+}
+
+// update runs a read-write transaction against the database.
+func (s *boltStore) update(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return fmt.Errorf("database %s is not connected", s.path)
+	}
+	return s.db.Update(fn)
+}
+
+func (s *boltStore) List(itemType string, group string) ([]string, error) {
+	names := []string{}
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(itemType))
+		if b == nil {
+			return nil
+		}
+
+		prefix := boltIndexKey(group, "")
+		c := b.Bucket(boltIndexBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			names = append(names, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return names, wrapBoltErr(err)
+}
+
+func (s *boltStore) ListGroups(itemType string) ([]string, error) {
+	groups := []string{}
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(itemType))
+		if b == nil {
+			return nil
+		}
+
+		// Index keys are sorted by group, so each group is found once
+		c := b.Bucket(boltIndexBucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			group := string(k[:bytes.IndexByte(k, 0)])
+			if group != "" && (len(groups) == 0 || groups[len(groups)-1] != group) {
+				groups = append(groups, group)
+			}
+		}
+		return nil
+	})
+	return groups, wrapBoltErr(err)
+}
+
+func (s *boltStore) Save(itemType string, group string, name string, data []byte) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		return boltSave(tx, itemType, group, name, data)
+	})
+	return wrapBoltErr(err)
+}
+
+// CommitBatch saves all of the items in the batch in a single transaction.
+func (s *boltStore) CommitBatch(batch Batch) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, item := range batch.Items {
+			if err := boltSave(tx, item.ItemType, item.Group, item.Name, item.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return wrapBoltErr(err)
+}
+
+func (s *boltStore) Read(itemType string, name string) ([]byte, error) {
+	var data []byte
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(itemType))
+		if b == nil {
+			return ErrRecordDoesNotExist
+		}
+
+		value, ok := boltGet(b.Bucket(boltItemsBucket), []byte(name))
+		if !ok {
+			return ErrRecordDoesNotExist
+		}
+
+		// The value is only valid for the life of the transaction
+		data = make([]byte, len(value))
+		copy(data, value)
+		return nil
+	})
+	if err == ErrRecordDoesNotExist {
+		return nil, err
+	}
+	return data, wrapBoltErr(err)
+}
+
+func (s *boltStore) Delete(itemType string, name string) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(itemType))
+		if b == nil {
+			return ErrRecordDoesNotExist
+		}
+
+		key := []byte(name)
+		group, ok := boltGet(b.Bucket(boltGroupsBucket), key)
+		if !ok {
+			return ErrRecordDoesNotExist
+		}
+
+		if err := b.Bucket(boltIndexBucket).Delete(boltIndexKey(string(group), name)); err != nil {
+			return err
+		}
+		if err := b.Bucket(boltGroupsBucket).Delete(key); err != nil {
+			return err
+		}
+		return b.Bucket(boltItemsBucket).Delete(key)
+	})
+	if err == ErrRecordDoesNotExist {
+		return err
+	}
+	return wrapBoltErr(err)
+}
+
+// Lock acquires a lease on the record. The lease is checked and saved in a
+// single transaction, so only one owner can acquire it.
+func (s *boltStore) Lock(itemType string, name string, owner string, ttl time.Duration) error {
+	_, data, err := newLease(owner, ttl)
+	if err != nil {
+		return err
+	}
+
+	var current Lease
+	locked := false
+	err = s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(boltLocksBucket)
+		if err != nil {
+			return err
+		}
+
+		key := boltIndexKey(itemType, name)
+		if value, ok := boltGet(b, key); ok {
+			// A lease that cannot be parsed is treated as expired
+			if lease, err := parseLease(value); err == nil && lease.Owner != owner && !lease.IsExpired() {
+				current = lease
+				locked = true
+				return nil
+			}
+		}
+		return b.Put(key, data)
+	})
+	if err != nil {
+		return wrapBoltErr(err)
+	}
+	if locked {
+		return lockedError(itemType, name, current)
+	}
+	return nil
+}
+
+func (s *boltStore) Unlock(itemType string, name string, owner string) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltLocksBucket)
+		if b == nil {
+			return nil
+		}
+
+		key := boltIndexKey(itemType, name)
+		value, ok := boltGet(b, key)
+		if !ok {
+			return nil
+		}
+		if lease, err := parseLease(value); err != nil || lease.Owner != owner {
+			return nil
+		}
+		return b.Delete(key)
+	})
+	return wrapBoltErr(err)
+}
+
+// boltSave saves an item, moving it to the specified group if it was
+// previously saved in a different group.
+func boltSave(tx *bolt.Tx, itemType string, group string, name string, data []byte) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(itemType))
+	if err != nil {
+		return err
+	}
+
+	items, err := b.CreateBucketIfNotExists(boltItemsBucket)
+	if err != nil {
+		return err
+	}
+	groups, err := b.CreateBucketIfNotExists(boltGroupsBucket)
+	if err != nil {
+		return err
+	}
+	index, err := b.CreateBucketIfNotExists(boltIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	key := []byte(name)
+	if oldGroup, ok := boltGet(groups, key); ok && string(oldGroup) != group {
+		if err := index.Delete(boltIndexKey(string(oldGroup), name)); err != nil {
+			return err
+		}
+	}
+
+	if err := index.Put(boltIndexKey(group, name), []byte{}); err != nil {
+		return err
+	}
+	if err := groups.Put(key, []byte(group)); err != nil {
+		return err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return items.Put(key, data)
+}
+
+// boltGet returns the value of a key and whether the key exists. Bucket.Get
+// returns nil for both a missing key and an empty value, so the key is looked
+// up with a cursor instead.
+func boltGet(b *bolt.Bucket, key []byte) ([]byte, bool) {
+	k, v := b.Cursor().Seek(key)
+	if k == nil || !bytes.Equal(k, key) {
+		return nil, false
+	}
+	return v, true
+}
+
+// boltIndexKey is the key of an item in the index bucket.
+func boltIndexKey(group string, name string) []byte {
+	return []byte(group + "\x00" + name)
+}
+
+func wrapBoltErr(err error) error {
+	if err == nil {
+		return err
+	}
+	return errors.Wrap(err, "bolt storage error")
+}
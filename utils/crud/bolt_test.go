@@ -0,0 +1,190 @@
+package crud
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestBoltStore(t *testing.T) (*BackingStore, func()) {
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+
+	s := NewBoltStore(filepath.Join(tmpdir, "store", "cnab.db"))
+	return s, func() { os.RemoveAll(tmpdir) }
+}
+
+func TestBoltStore(t *testing.T) {
+	s, cleanup := newTestBoltStore(t)
+	defer cleanup()
+
+	require.NoError(t, s.Save(testItemType, testGroup, "item1", []byte("data1")))
+	require.NoError(t, s.Save(testItemType, testGroup, "item2", []byte("data2")))
+	require.NoError(t, s.Save(testItemType, "other-group", "item3", []byte("data3")))
+	require.NoError(t, s.Save(testItemType, "", "item4", nil))
+
+	list, err := s.List(testItemType, testGroup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"item1", "item2"}, list)
+
+	list, err = s.List(testItemType, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"item4"}, list)
+
+	list, err = s.List(testItemType, "missing-group")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	list, err = s.List("missing-items", testGroup)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	data, err := s.Read(testItemType, "item1")
+	require.NoError(t, err)
+	assert.Equal(t, "data1", string(data))
+
+	data, err = s.Read(testItemType, "item4")
+	require.NoError(t, err, "an item without data should exist")
+	assert.Empty(t, data)
+
+	_, err = s.Read(testItemType, "missing")
+	assert.Equal(t, ErrRecordDoesNotExist, err)
+
+	_, err = s.Read("missing-items", "item1")
+	assert.Equal(t, ErrRecordDoesNotExist, err)
+
+	require.NoError(t, s.Delete(testItemType, "item1"))
+	_, err = s.Read(testItemType, "item1")
+	assert.Equal(t, ErrRecordDoesNotExist, err)
+	list, err = s.List(testItemType, testGroup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"item2"}, list)
+
+	assert.Equal(t, ErrRecordDoesNotExist, s.Delete(testItemType, "item1"))
+}
+
+func TestBoltStore_MoveGroup(t *testing.T) {
+	s, cleanup := newTestBoltStore(t)
+	defer cleanup()
+
+	require.NoError(t, s.Save(testItemType, testGroup, "item1", []byte("data1")))
+	require.NoError(t, s.Save(testItemType, "other-group", "item1", []byte("updated")))
+
+	list, err := s.List(testItemType, testGroup)
+	require.NoError(t, err)
+	assert.Empty(t, list, "the item should be removed from its old group")
+
+	list, err = s.List(testItemType, "other-group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"item1"}, list)
+
+	data, err := s.Read(testItemType, "item1")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", string(data))
+}
+
+func TestBoltStore_Persistence(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	dbPath := filepath.Join(tmpdir, "cnab.db")
+
+	s := NewBoltStore(dbPath)
+	require.NoError(t, s.Save(testItemType, testGroup, "item1", []byte("data1")))
+
+	fi, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// The file is not held open between operations, so another store can use it
+	s2 := NewBoltStore(dbPath)
+	data, err := s2.Read(testItemType, "item1")
+	require.NoError(t, err)
+	assert.Equal(t, "data1", string(data))
+}
+
+func TestBoltStore_CommitBatch(t *testing.T) {
+	s, cleanup := newTestBoltStore(t)
+	defer cleanup()
+
+	require.NoError(t, s.Save(testItemType, testGroup, "existing", []byte("original")))
+
+	var batch Batch
+	batch.Save(testItemType, testGroup, "existing", []byte("changed"))
+	batch.Save(testItemType, testGroup, "new", []byte("data"))
+	batch.Save(testItemType, testGroup, "", []byte("empty names are invalid"))
+	require.Error(t, s.CommitBatch(batch))
+
+	data, err := s.Read(testItemType, "existing")
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data), "the batch should not be partially committed")
+
+	_, err = s.Read(testItemType, "new")
+	assert.Equal(t, ErrRecordDoesNotExist, err, "the batch should not be partially committed")
+
+	batch.Items = batch.Items[:2]
+	require.NoError(t, s.CommitBatch(batch))
+
+	list, err := s.List(testItemType, testGroup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"existing", "new"}, list)
+}
+
+func TestBoltStore_ConcurrentReaders(t *testing.T) {
+	s, cleanup := newTestBoltStore(t)
+	defer cleanup()
+
+	s.AutoClose = false
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Save(testItemType, testGroup, "item1", []byte("data1")))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := s.Read(testItemType, "item1")
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := s.List(testItemType, testGroup)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestBoltStore_LockedFile(t *testing.T) {
+	s, cleanup := newTestBoltStore(t)
+	defer cleanup()
+
+	s.AutoClose = false
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	s2 := NewBoltStore(s.GetDataStore().(*boltStore).path)
+	s2.GetDataStore().(*boltStore).timeout = 10 * time.Millisecond
+	_, err := s2.Read(testItemType, "item1")
+	require.Error(t, err)
+	assert.Equal(t, bolt.ErrTimeout, errors.Cause(err), "the bolt error should be preserved")
+}
@@ -1,11 +1,13 @@
 package crud
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -13,6 +15,20 @@ import (
 // ErrRecordDoesNotExist represents when file path is not found on file system
 var ErrRecordDoesNotExist = errors.New("File does not exist")
 
+const (
+	// fileMode is the permissions applied to files saved by a FileSystemStore.
+	// Files may contain sensitive data, such as claims and outputs, so they are
+	// only accessible to the current user.
+	fileMode os.FileMode = 0600
+
+	// dirMode is the permissions applied to directories created by a FileSystemStore.
+	dirMode os.FileMode = 0700
+
+	// locksDir is the directory, relative to the base directory, that contains
+	// the lock files of each item type.
+	locksDir = ".locks"
+)
+
 // NewFileSystemStore creates a Store backed by a file system directory.
 // Each key is represented by a file in that directory.
 // - baseDirectory: the base directory under which files should be stored, e.g. /Users/carolynvs/.cnab
@@ -24,6 +40,10 @@ func NewFileSystemStore(baseDirectory string, fileExtensions map[string]string)
 	}
 }
 
+var _ HasCommitBatch = FileSystemStore{}
+var _ HasListGroups = FileSystemStore{}
+var _ HasLock = FileSystemStore{}
+
 type FileSystemStore struct {
 	baseDirectory string
 
@@ -48,13 +68,165 @@ func (s FileSystemStore) List(itemType string, group string) ([]string, error) {
 	return names(s.storageFiles(itemType, files)), nil
 }
 
+// ListGroups returns the groups of an item type, which are the directories
+// in the item type's directory.
+func (s FileSystemStore) ListGroups(itemType string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.baseDirectory, itemType))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			groups = append(groups, file.Name())
+		}
+	}
+	return groups, nil
+}
+
 func (s FileSystemStore) Save(itemType string, group string, name string, data []byte) error {
 	filename, err := s.fullyQualifiedName(itemType, group, name)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(filename, data, os.ModePerm)
+	return writeFile(filename, data)
+}
+
+// CommitBatch saves all of the items in the batch, or none of them.
+//
+// Each item is first written to a temporary file, and then the temporary
+// files are moved into place. When an item cannot be moved into place, the
+// items that were already saved are restored to their previous contents.
+func (s FileSystemStore) CommitBatch(batch Batch) error {
+	staged := make([]stagedFile, 0, len(batch.Items))
+	defer func() {
+		for _, f := range staged {
+			os.Remove(f.tmpFile)
+		}
+	}()
+
+	for _, item := range batch.Items {
+		filename, err := s.fullyQualifiedName(item.ItemType, item.Group, item.Name)
+		if err != nil {
+			return err
+		}
+
+		f := stagedFile{filename: filename}
+		f.previous, err = ioutil.ReadFile(filename)
+		if err == nil {
+			f.exists = true
+		} else if !os.IsNotExist(err) {
+			return errors.Wrapf(err, "could not read %s before saving the batch", filename)
+		}
+
+		f.tmpFile, err = writeTempFile(filename, item.Data)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, f)
+	}
+
+	for i, f := range staged {
+		if err := os.Rename(f.tmpFile, f.filename); err != nil {
+			err = errors.Wrapf(err, "could not save %s", f.filename)
+			if rollbackErr := rollbackStagedFiles(staged[:i]); rollbackErr != nil {
+				return errors.Wrapf(err, "the batch could not be rolled back: %v", rollbackErr)
+			}
+			return errors.Wrap(err, "the batch was rolled back")
+		}
+	}
+
+	for _, f := range staged {
+		syncDir(filepath.Dir(f.filename))
+	}
+	return nil
+}
+
+// stagedFile is a file that was written to a temporary location as part of a
+// batch, and is waiting to be moved into place.
+type stagedFile struct {
+	filename string
+	tmpFile  string
+	previous []byte
+	exists   bool
+}
+
+// rollbackStagedFiles restores files that were saved by a batch to their
+// previous contents, in reverse order.
+func rollbackStagedFiles(files []stagedFile) error {
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		var err error
+		if f.exists {
+			err = writeFile(f.filename, f.previous)
+		} else {
+			err = os.Remove(f.filename)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not restore %s", f.filename)
+		}
+	}
+	return nil
+}
+
+// writeFile atomically replaces the contents of a file, so that a crash
+// while writing cannot leave behind a partially written file.
+func writeFile(filename string, data []byte) error {
+	tmpFile, err := writeTempFile(filename, data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFile, filename); err != nil {
+		os.Remove(tmpFile)
+		return errors.Wrapf(err, "could not save %s", filename)
+	}
+
+	syncDir(filepath.Dir(filename))
+	return nil
+}
+
+// syncDir flushes a directory to disk, persisting the files renamed into it.
+// Not every platform supports syncing a directory, and the file contents are
+// already safely written, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// writeTempFile writes the data to a temporary file in the same directory as
+// the destination file, flushing it to disk, and returns the temporary file's
+// path. The temporary file is removed when it cannot be written.
+func writeTempFile(filename string, data []byte) (string, error) {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return "", errors.Wrapf(err, "could not create a temporary file for %s", filename)
+	}
+
+	tmpFile := f.Name()
+	err = f.Chmod(fileMode)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return "", errors.Wrapf(err, "could not write %s", filename)
+	}
+
+	return tmpFile, nil
 }
 
 func (s FileSystemStore) Read(itemType string, name string) ([]byte, error) {
@@ -74,6 +246,154 @@ func (s FileSystemStore) Delete(itemType string, name string) error {
 	return os.Remove(filename)
 }
 
+// Lock acquires a lease on the record, which is stored in a lock file in the
+// .locks directory.
+//
+// A new lock file is written to a temporary file first and then hard linked
+// into place, which fails when another owner already created the lock file.
+// An expired lock file is moved aside before it is replaced, and restored
+// when another owner replaced it in the meantime.
+func (s FileSystemStore) Lock(itemType string, name string, owner string, ttl time.Duration) error {
+	_, data, err := newLease(owner, ttl)
+	if err != nil {
+		return err
+	}
+
+	lockFile, err := s.lockFileName(itemType, name)
+	if err != nil {
+		return err
+	}
+
+	// Retry when the lock file changes while it is being acquired, for
+	// example when it is released or an expired lease is removed.
+	const maxAttempts = 5
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		created, err := createLockFile(lockFile, data)
+		if err != nil {
+			return err
+		}
+		if created {
+			return nil
+		}
+
+		current, err := ioutil.ReadFile(lockFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "could not read the lock file %s", lockFile)
+		}
+
+		// A lock file that cannot be parsed is treated as an expired lease.
+		lease, err := parseLease(current)
+		if err == nil && lease.Owner == owner {
+			return writeFile(lockFile, data)
+		}
+		if err == nil && !lease.IsExpired() {
+			return lockedError(itemType, name, lease)
+		}
+
+		if err := removeLockFile(lockFile, current); err != nil {
+			if IsLocked(err) {
+				continue
+			}
+			return err
+		}
+	}
+
+	return errors.Wrapf(ErrLocked, "could not acquire the lock on %s %s", itemType, name)
+}
+
+// Unlock releases the owner's lease on the record by removing its lock file.
+func (s FileSystemStore) Unlock(itemType string, name string, owner string) error {
+	lockFile, err := s.lockFileName(itemType, name)
+	if err != nil {
+		return err
+	}
+
+	current, err := ioutil.ReadFile(lockFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "could not read the lock file %s", lockFile)
+	}
+
+	lease, err := parseLease(current)
+	if err != nil || lease.Owner != owner {
+		return nil
+	}
+
+	err = removeLockFile(lockFile, current)
+	if IsLocked(err) {
+		// The lease expired and was taken by another owner
+		return nil
+	}
+	return err
+}
+
+func (s FileSystemStore) lockFileName(itemType string, name string) (string, error) {
+	relPath := filepath.Join(locksDir, itemType)
+	if err := s.ensure(relPath); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.baseDirectory, relPath, name+".lock"), nil
+}
+
+// createLockFile creates the lock file with the data, returning false when the
+// lock file already exists.
+func createLockFile(lockFile string, data []byte) (bool, error) {
+	tmpFile, err := writeTempFile(lockFile, data)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmpFile)
+
+	if err := os.Link(tmpFile, lockFile); err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "could not create the lock file %s", lockFile)
+	}
+
+	syncDir(filepath.Dir(lockFile))
+	return true, nil
+}
+
+// removeLockFile removes the lock file when it still contains the expected
+// data. Otherwise the lock file is left in place, and ErrLocked is returned.
+func removeLockFile(lockFile string, expected []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(lockFile), "."+filepath.Base(lockFile)+".stale")
+	if err != nil {
+		return errors.Wrapf(err, "could not create a temporary file for %s", lockFile)
+	}
+	staleFile := f.Name()
+	f.Close()
+	defer os.Remove(staleFile)
+
+	// Moving the lock file aside is atomic, so only one owner can remove it
+	if err := os.Rename(lockFile, staleFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "could not remove the lock file %s", lockFile)
+	}
+
+	current, err := ioutil.ReadFile(staleFile)
+	if err != nil {
+		return errors.Wrapf(err, "could not read the lock file %s", lockFile)
+	}
+	if bytes.Equal(current, expected) {
+		return nil
+	}
+
+	// The lock file was replaced after it was read, so put it back
+	if err := os.Link(staleFile, lockFile); err != nil && !os.IsExist(err) {
+		return errors.Wrapf(err, "could not restore the lock file %s", lockFile)
+	}
+	return errors.Wrapf(ErrLocked, "the lock file %s was changed by another owner", lockFile)
+}
+
 func (s FileSystemStore) resolveFileName(itemType string, name string) (string, error) {
 	// First check for an exact match
 	exactName, err := s.fullyQualifiedName(itemType, "", name)
@@ -139,7 +459,7 @@ func (s FileSystemStore) ensure(relPath string) error {
 		}
 		return fmt.Errorf("storage path %s exists, but is not a directory", target)
 	}
-	return os.MkdirAll(target, os.ModePerm)
+	return os.MkdirAll(target, dirMode)
 }
 
 func (s FileSystemStore) storageFiles(itemType string, files []os.FileInfo) []os.FileInfo {
@@ -3,9 +3,12 @@ package crud
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var _ Store = FileSystemStore{}
@@ -30,3 +33,72 @@ func TestFilesystemStore(t *testing.T) {
 	is.NoError(err)
 	is.Len(list, 0)
 }
+
+func TestFilesystemStore_Save(t *testing.T) {
+	tmdir, err := ioutil.TempDir("", "duffle-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmdir)
+
+	s := NewFileSystemStore(tmdir, map[string]string{testItemType: ".json"})
+	require.NoError(t, s.Save(testItemType, testGroup, "testkey", []byte("testval")))
+	require.NoError(t, s.Save(testItemType, testGroup, "testkey", []byte("newval")))
+
+	files, err := ioutil.ReadDir(filepath.Join(tmdir, testItemType, testGroup))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "no temporary files should be left behind")
+	assert.Equal(t, "testkey.json", files[0].Name())
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), files[0].Mode().Perm())
+	}
+
+	d, err := s.Read(testItemType, "testkey")
+	require.NoError(t, err)
+	assert.Equal(t, "newval", string(d))
+}
+
+func TestFilesystemStore_CommitBatch(t *testing.T) {
+	t.Run("all items saved", func(t *testing.T) {
+		tmdir, err := ioutil.TempDir("", "duffle-test-")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmdir)
+		s := NewFileSystemStore(tmdir, map[string]string{testItemType: ".json"})
+
+		var batch Batch
+		batch.Save(testItemType, testGroup, "item1", []byte("data1"))
+		batch.Save("other-items", "", "item2", []byte("data2"))
+		require.NoError(t, s.CommitBatch(batch))
+
+		d, err := s.Read(testItemType, "item1")
+		require.NoError(t, err)
+		assert.Equal(t, "data1", string(d))
+
+		d, err = s.Read("other-items", "item2")
+		require.NoError(t, err)
+		assert.Equal(t, "data2", string(d))
+	})
+
+	t.Run("nothing saved on failure", func(t *testing.T) {
+		tmdir, err := ioutil.TempDir("", "duffle-test-")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmdir)
+		s := NewFileSystemStore(tmdir, map[string]string{testItemType: ".json"})
+		require.NoError(t, s.Save(testItemType, testGroup, "existing", []byte("original")))
+
+		// Block the other item type's directory with a file so that it cannot be saved
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmdir, "other-items"), nil, 0600))
+
+		var batch Batch
+		batch.Save(testItemType, testGroup, "existing", []byte("changed"))
+		batch.Save(testItemType, testGroup, "new", []byte("data"))
+		batch.Save("other-items", "", "item", []byte("data"))
+		require.Error(t, s.CommitBatch(batch))
+
+		d, err := s.Read(testItemType, "existing")
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(d))
+
+		list, err := s.List(testItemType, testGroup)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"existing"}, list, "no other files should be left behind")
+	})
+}
@@ -0,0 +1,89 @@
+package crud
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrLocked represents a record that is locked by another owner.
+var ErrLocked = errors.New("Record is locked")
+
+// Lease is a lock on a record held by an owner until it expires.
+type Lease struct {
+	// Owner of the lease, for example the ID of the operation that holds the lock.
+	Owner string `json:"owner" bson:"owner"`
+
+	// Expires is when the lease expires, after which another owner may take the lock.
+	Expires time.Time `json:"expires" bson:"expires"`
+}
+
+// IsExpired returns true when the lease has expired.
+func (l Lease) IsExpired() bool {
+	return !time.Now().Before(l.Expires)
+}
+
+// HasLock indicates that a store can lock records, so that processes sharing
+// the store can coordinate changes to the same record.
+type HasLock interface {
+	// Lock acquires a lease on the record for the owner that expires after the
+	// ttl. When the owner already holds the lease, it is renewed. Returns
+	// ErrLocked when another owner holds a lease that has not expired.
+	Lock(itemType string, name string, owner string, ttl time.Duration) error
+
+	// Unlock releases the owner's lease on the record. Nothing is changed when
+	// the record is not locked by the owner.
+	Unlock(itemType string, name string, owner string) error
+}
+
+// newLease creates a lease for the owner, and returns it marshaled for storage.
+func newLease(owner string, ttl time.Duration) (Lease, []byte, error) {
+	if owner == "" {
+		return Lease{}, nil, errors.New("the lock owner must be set")
+	}
+	if ttl <= 0 {
+		return Lease{}, nil, fmt.Errorf("invalid lock ttl %s, it must be positive", ttl)
+	}
+
+	lease := Lease{Owner: owner, Expires: time.Now().Add(ttl)}
+	data, err := json.Marshal(lease)
+	return lease, data, errors.Wrap(err, "could not marshal the lease")
+}
+
+// parseLease reads a lease saved in a store.
+func parseLease(data []byte) (Lease, error) {
+	var lease Lease
+	err := json.Unmarshal(data, &lease)
+	return lease, errors.Wrap(err, "could not parse the lease")
+}
+
+// LockedError is returned when a record cannot be locked because another owner
+// holds a lease on it. Its cause is ErrLocked.
+type LockedError struct {
+	ItemType string
+	Name     string
+
+	// Lease held by the other owner.
+	Lease Lease
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s %s is locked by %s until %s", e.ItemType, e.Name, e.Lease.Owner, e.Lease.Expires.Format(time.RFC3339))
+}
+
+// Cause returns ErrLocked, so that the error can be identified with errors.Cause.
+func (e *LockedError) Cause() error {
+	return ErrLocked
+}
+
+func lockedError(itemType string, name string, lease Lease) error {
+	return &LockedError{ItemType: itemType, Name: name, Lease: lease}
+}
+
+// IsLocked returns true when the error indicates that a record is locked by
+// another owner.
+func IsLocked(err error) bool {
+	return err != nil && errors.Cause(err) == ErrLocked
+}
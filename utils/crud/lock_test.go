@@ -0,0 +1,128 @@
+package crud
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	stores := map[string]func(t *testing.T) (Store, func()){
+		"filesystem": newTestFileSystemStore,
+		"bolt": func(t *testing.T) (Store, func()) {
+			return newTestBoltStore(t)
+		},
+		"mock": func(t *testing.T) (Store, func()) {
+			return NewBackingStore(NewMockStore()), func() {}
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s, cleanup := newStore(t)
+			defer cleanup()
+			locker := s.(HasLock)
+
+			t.Run("lock held by another owner", func(t *testing.T) {
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner1", time.Minute))
+				defer locker.Unlock(testItemType, "item1", "owner1")
+
+				err := locker.Lock(testItemType, "item1", "owner2", time.Minute)
+				require.Error(t, err)
+				assert.True(t, IsLocked(err), "expected ErrLocked, got %v", err)
+				assert.Contains(t, err.Error(), "locked by owner1")
+				assert.Equal(t, "owner1", err.(*LockedError).Lease.Owner)
+
+				require.NoError(t, locker.Lock(testItemType, "item2", "owner2", time.Minute), "other records should not be locked")
+				require.NoError(t, locker.Unlock(testItemType, "item2", "owner2"))
+			})
+
+			t.Run("renew", func(t *testing.T) {
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner1", time.Minute))
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner1", time.Minute), "the owner should be able to renew its lease")
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner1"))
+			})
+
+			t.Run("unlock", func(t *testing.T) {
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner1", time.Minute))
+
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner2"), "unlocking a lease held by another owner should do nothing")
+				assert.True(t, IsLocked(locker.Lock(testItemType, "item1", "owner2", time.Minute)))
+
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner1"))
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner2", time.Minute), "the lock should be available after it is released")
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner2"))
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner2"), "unlocking twice should do nothing")
+			})
+
+			t.Run("stale lease expires", func(t *testing.T) {
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner1", 10*time.Millisecond))
+				time.Sleep(20 * time.Millisecond)
+
+				require.NoError(t, locker.Lock(testItemType, "item1", "owner2", time.Minute), "an expired lease should be taken over")
+				assert.True(t, IsLocked(locker.Lock(testItemType, "item1", "owner1", time.Minute)))
+
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner1"), "the previous owner should not be able to release the new lease")
+				assert.True(t, IsLocked(locker.Lock(testItemType, "item1", "owner3", time.Minute)))
+				require.NoError(t, locker.Unlock(testItemType, "item1", "owner2"))
+			})
+
+			t.Run("invalid lease", func(t *testing.T) {
+				assert.Error(t, locker.Lock(testItemType, "item1", "", time.Minute), "the owner is required")
+				assert.Error(t, locker.Lock(testItemType, "item1", "owner1", 0), "the ttl must be positive")
+			})
+		})
+	}
+}
+
+func TestFileSystemStore_Lock_Concurrent(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	s := NewFileSystemStore(tmpdir, nil)
+
+	// Start with an expired lease, so that each owner also competes to remove it
+	require.NoError(t, s.Lock(testItemType, "item1", "stale", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	const owners = 10
+	var wg sync.WaitGroup
+	acquired := make(chan string, owners)
+	for i := 0; i < owners; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			if err := s.Lock(testItemType, "item1", owner, time.Minute); err == nil {
+				acquired <- owner
+			} else if !IsLocked(err) {
+				t.Errorf("unexpected error from Lock: %v", err)
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+	close(acquired)
+
+	var winners []string
+	for owner := range acquired {
+		winners = append(winners, owner)
+	}
+	assert.Len(t, winners, 1, "only one owner should acquire the lock")
+
+	files, err := ioutil.ReadDir(filepath.Join(tmpdir, locksDir, testItemType))
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "temporary files should be cleaned up")
+}
+
+func TestBackingStore_Lock_NotSupported(t *testing.T) {
+	s := NewBackingStore(struct{ Store }{NewMockStore()})
+
+	err := s.Lock(testItemType, "item1", "owner1", time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support locking")
+}
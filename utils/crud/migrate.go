@@ -0,0 +1,353 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Migration actions recorded for each item in a MigrationReport.
+const (
+	// MigrationCopied indicates that the item was copied to the destination.
+	MigrationCopied = "copied"
+
+	// MigrationSkipped indicates that the item already existed in the
+	// destination with the same data, for example from a previous migration
+	// that did not complete.
+	MigrationSkipped = "skipped"
+)
+
+// MigrateOptions configures how to migrate data between stores.
+type MigrateOptions struct {
+	// ItemTypes to migrate, for example the claim store's item types.
+	ItemTypes []string
+
+	// DryRun reports which items would be copied without changing the destination.
+	DryRun bool
+}
+
+// MigrationReport describes the items that were migrated, or would be
+// migrated when DryRun is set.
+type MigrationReport struct {
+	DryRun bool
+	Items  []MigratedItem
+}
+
+// MigratedItem is an item in a MigrationReport.
+type MigratedItem struct {
+	ItemType string
+	Group    string
+	Name     string
+
+	// Action taken for the item, for example MigrationCopied. When DryRun is
+	// set, the action that would have been taken.
+	Action string
+}
+
+// Count returns the number of items that had the specified action applied.
+func (r MigrationReport) Count(action string) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Migrate copies every item of the specified item types, with their groups,
+// from the source store to the destination store. The source store must
+// implement HasListGroups.
+//
+// Items that already exist in the destination with the same data are skipped,
+// so a migration that did not complete can be resumed by running it again.
+// Each copied item is read back from the destination and compared to the
+// source, and must be listed in its group.
+//
+// When both stores implement HasQuery, the fields of each item are copied
+// too. When only the destination implements HasQuery, the items are saved
+// without fields, and are not matched by its queries until they are saved with
+// their fields again, for example with claim.Store.ReindexInstallations after
+// migrating the claim store's item types.
+func Migrate(src Store, dest Store, opts MigrateOptions) (MigrationReport, error) {
+	report := MigrationReport{DryRun: opts.DryRun}
+
+	lister, ok := src.(HasListGroups)
+	if !ok {
+		return report, fmt.Errorf("cannot migrate from a %T store because it does not support listing groups", src)
+	}
+
+	for _, store := range []Store{src, dest} {
+		if bs, ok := store.(*BackingStore); ok {
+			handleClose, err := bs.HandleConnect()
+			defer handleClose()
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+
+	m := migrator{src: src, dest: dest, dryRun: opts.DryRun}
+	if srcQuery, ok := queryStore(src); ok {
+		if destQuery, ok := queryStore(dest); ok {
+			m.srcQuery, m.destQuery = srcQuery, destQuery
+		}
+	}
+
+	for _, itemType := range opts.ItemTypes {
+		items, err := listMigrationItems(src, lister, itemType)
+		if err != nil {
+			return report, err
+		}
+
+		listed := newGroupIndex(dest, itemType)
+		var copied []MigratedItem
+		for _, item := range items {
+			item.Action, err = m.migrateItem(item, listed)
+			if err != nil {
+				return report, err
+			}
+			report.Items = append(report.Items, item)
+			if item.Action == MigrationCopied {
+				copied = append(copied, item)
+			}
+		}
+
+		if !opts.DryRun {
+			if err := verifyListed(dest, itemType, copied); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// queryStore returns the store as a HasQuery, when it supports queries. A
+// BackingStore only supports queries when its data store does.
+func queryStore(store Store) (HasQuery, bool) {
+	if bs, ok := store.(*BackingStore); ok {
+		if _, ok := bs.GetDataStore().(HasQuery); !ok {
+			return nil, false
+		}
+		return bs, true
+	}
+	q, ok := store.(HasQuery)
+	return q, ok
+}
+
+// listMigrationItems lists the items of an item type in every group,
+// including items that are not in a group.
+func listMigrationItems(src Store, lister HasListGroups, itemType string) ([]MigratedItem, error) {
+	groups, err := lister.ListGroups(itemType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list the groups of %s", itemType)
+	}
+
+	var items []MigratedItem
+	listed := make(map[MigratedItem]bool)
+	groupNames := make(map[string]bool, len(groups))
+	itemNames := make(map[string]bool)
+	for _, group := range groups {
+		groupNames[group] = true
+
+		names, err := listGroup(src, itemType, group)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			item := MigratedItem{ItemType: itemType, Group: group, Name: name}
+			if !listed[item] {
+				listed[item] = true
+				itemNames[name] = true
+				items = append(items, item)
+			}
+		}
+	}
+
+	names, err := listGroup(src, itemType, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		item := MigratedItem{ItemType: itemType, Name: name}
+		if listed[item] {
+			continue
+		}
+
+		// Depending on the store, listing the empty group also returns the
+		// items in every group, which are already listed because names are
+		// unique within an item type.
+		if itemNames[name] {
+			continue
+		}
+
+		// Other stores also return the group names, so a name that is also a
+		// group is only an item when it can be read. The filesystem store
+		// fails to read the directory of a group.
+		if groupNames[name] {
+			if _, err := src.Read(itemType, name); err != nil {
+				continue
+			}
+		}
+
+		listed[item] = true
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// listGroup lists the items in a group, handling stores that return an error
+// for an empty group.
+func listGroup(store Store, itemType string, group string) ([]string, error) {
+	names, err := store.List(itemType, group)
+	if err != nil && !isNotExist(err) {
+		return nil, errors.Wrapf(err, "could not list %s in group %q", itemType, group)
+	}
+	return names, nil
+}
+
+// migrator copies items from the source store to the destination store.
+type migrator struct {
+	src    Store
+	dest   Store
+	dryRun bool
+
+	// srcQuery and destQuery are set when both stores implement HasQuery, so
+	// that the fields of the items are copied.
+	srcQuery  HasQuery
+	destQuery HasQuery
+}
+
+// migrateItem copies an item to the destination, and verifies the copy,
+// returning the action taken. Whether the copy is listed in its group is
+// verified afterwards for all the items of the item type, see verifyListed.
+func (m migrator) migrateItem(item MigratedItem, listed groupIndex) (string, error) {
+	data, err := m.src.Read(item.ItemType, item.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read %s %s from the source store", item.ItemType, item.Name)
+	}
+
+	var fields Fields
+	if m.srcQuery != nil {
+		fields, err = m.srcQuery.ReadFields(item.ItemType, item.Name)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not read the fields of %s %s from the source store", item.ItemType, item.Name)
+		}
+	}
+
+	if err := m.verifyItem(item, data, fields); err == nil {
+		ok, err := listed.contains(item.Group, item.Name)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return MigrationSkipped, nil
+		}
+	}
+
+	if m.dryRun {
+		return MigrationCopied, nil
+	}
+
+	if m.destQuery != nil {
+		err = m.destQuery.SaveWithFields(item.ItemType, item.Group, item.Name, data, fields)
+	} else {
+		err = m.dest.Save(item.ItemType, item.Group, item.Name, data)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "could not save %s %s to the destination store", item.ItemType, item.Name)
+	}
+
+	if err := m.verifyItem(item, data, fields); err != nil {
+		return "", errors.Wrapf(err, "verification of the migrated %s %s failed", item.ItemType, item.Name)
+	}
+	return MigrationCopied, nil
+}
+
+// verifyItem checks that the item exists in the destination store with the
+// expected data and fields.
+func (m migrator) verifyItem(item MigratedItem, want []byte, wantFields Fields) error {
+	got, err := m.dest.Read(item.ItemType, item.Name)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return errors.New("the data does not match the source store")
+	}
+
+	if m.destQuery != nil {
+		gotFields, err := m.destQuery.ReadFields(item.ItemType, item.Name)
+		if err != nil {
+			return err
+		}
+		if !fieldsEqual(wantFields, gotFields) {
+			return errors.New("the fields do not match the source store")
+		}
+	}
+	return nil
+}
+
+func fieldsEqual(a Fields, b Fields) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if got, ok := b[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyListed checks that the copied items of an item type are listed in
+// their group in the store.
+func verifyListed(store Store, itemType string, items []MigratedItem) error {
+	listed := newGroupIndex(store, itemType)
+	for _, item := range items {
+		ok, err := listed.contains(item.Group, item.Name)
+		if err != nil {
+			return errors.Wrapf(err, "verification of the migrated %s %s failed", item.ItemType, item.Name)
+		}
+		if !ok {
+			return fmt.Errorf("verification of the migrated %s %s failed: the item is not listed in group %q", item.ItemType, item.Name, item.Group)
+		}
+	}
+	return nil
+}
+
+// groupIndex lists each group of an item type in a store once, so that
+// checking whether items are listed in their group does not list the group
+// again for every item.
+type groupIndex struct {
+	store    Store
+	itemType string
+	groups   map[string]map[string]bool
+}
+
+func newGroupIndex(store Store, itemType string) groupIndex {
+	return groupIndex{
+		store:    store,
+		itemType: itemType,
+		groups:   make(map[string]map[string]bool),
+	}
+}
+
+// contains returns true when the item is listed in the group.
+func (idx groupIndex) contains(group string, name string) (bool, error) {
+	names, ok := idx.groups[group]
+	if !ok {
+		list, err := listGroup(idx.store, idx.itemType, group)
+		if err != nil {
+			return false, err
+		}
+		names = make(map[string]bool, len(list))
+		for _, n := range list {
+			names[n] = true
+		}
+		idx.groups[group] = names
+	}
+	return names[name], nil
+}
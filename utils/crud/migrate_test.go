@@ -0,0 +1,218 @@
+package crud
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateMigrationData creates a source store with grouped and ungrouped items.
+func generateMigrationData(t *testing.T) Store {
+	src := NewBackingStore(NewMockStore())
+	require.NoError(t, src.Save("installations", "", "foo", nil))
+	require.NoError(t, src.Save("installations", "", "bar", nil))
+	require.NoError(t, src.Save("claims", "foo", "claim1", []byte("foo claim 1")))
+	require.NoError(t, src.Save("claims", "foo", "claim2", []byte("foo claim 2")))
+	require.NoError(t, src.Save("claims", "bar", "claim3", []byte("bar claim 3")))
+	return src
+}
+
+func newTestFileSystemStore(t *testing.T) (Store, func()) {
+	tmpdir, err := ioutil.TempDir("", "cnab-go")
+	require.NoError(t, err)
+	return NewFileSystemStore(filepath.Join(tmpdir, "store"), map[string]string{"claims": ".json"}), func() { os.RemoveAll(tmpdir) }
+}
+
+func assertMigrated(t *testing.T, dest Store) {
+	t.Helper()
+
+	installations, err := dest.List("installations", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar"}, installations)
+
+	claims, err := dest.List("claims", "foo")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"claim1", "claim2"}, claims)
+
+	claims, err = dest.List("claims", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"claim3"}, claims)
+
+	data, err := dest.Read("claims", "claim3")
+	require.NoError(t, err)
+	assert.Equal(t, "bar claim 3", string(data))
+}
+
+func TestMigrate(t *testing.T) {
+	opts := MigrateOptions{ItemTypes: []string{"installations", "claims"}}
+
+	t.Run("mock to filesystem", func(t *testing.T) {
+		src := generateMigrationData(t)
+		dest, cleanup := newTestFileSystemStore(t)
+		defer cleanup()
+
+		report, err := Migrate(src, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.False(t, report.DryRun)
+		assert.Equal(t, 5, report.Count(MigrationCopied))
+		assertMigrated(t, dest)
+	})
+
+	t.Run("filesystem to bolt", func(t *testing.T) {
+		fs, cleanup := newTestFileSystemStore(t)
+		defer cleanup()
+		_, err := Migrate(generateMigrationData(t), fs, opts)
+		require.NoError(t, err, "Migrate failed")
+
+		dest, cleanupBolt := newTestBoltStore(t)
+		defer cleanupBolt()
+
+		report, err := Migrate(fs, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 5, report.Count(MigrationCopied))
+		assertMigrated(t, dest)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		src := generateMigrationData(t)
+		dest := NewBackingStore(NewMockStore())
+
+		// Simulate a previous migration that was interrupted
+		require.NoError(t, dest.Save("installations", "", "bar", nil))
+		require.NoError(t, dest.Save("claims", "foo", "claim1", []byte("foo claim 1")))
+		require.NoError(t, dest.Save("claims", "foo", "claim2", []byte("partial")))
+
+		report, err := Migrate(src, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 2, report.Count(MigrationSkipped))
+		assert.Equal(t, 3, report.Count(MigrationCopied))
+		assertMigrated(t, dest)
+
+		data, err := dest.Read("claims", "claim2")
+		require.NoError(t, err)
+		assert.Equal(t, "foo claim 2", string(data), "items with different data should be copied again")
+
+		report, err = Migrate(src, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 5, report.Count(MigrationSkipped), "a completed migration should not copy anything")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		src := generateMigrationData(t)
+		dest := NewBackingStore(NewMockStore())
+		require.NoError(t, dest.Save("installations", "", "bar", nil))
+
+		report, err := Migrate(src, dest, MigrateOptions{ItemTypes: opts.ItemTypes, DryRun: true})
+		require.NoError(t, err, "Migrate failed")
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Count(MigrationSkipped))
+		assert.Equal(t, 4, report.Count(MigrationCopied))
+
+		claims, err := dest.List("claims", "foo")
+		require.NoError(t, err)
+		assert.Empty(t, claims, "nothing should be copied during a dry run")
+	})
+
+	t.Run("ungrouped item named like a group", func(t *testing.T) {
+		src := generateMigrationData(t)
+		require.NoError(t, src.Save("claims", "", "foo", []byte("legacy foo claim")))
+		require.NoError(t, src.Save("claims", "", "claim4", []byte("ungrouped claim 4")))
+
+		fs, cleanup := newTestFileSystemStore(t)
+		defer cleanup()
+		report, err := Migrate(src, fs, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 7, report.Count(MigrationCopied), "the ungrouped items should be migrated")
+
+		dest, cleanupBolt := newTestBoltStore(t)
+		defer cleanupBolt()
+		report, err = Migrate(fs, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 7, report.Count(MigrationCopied), "the ungrouped items should be migrated from the filesystem")
+		assertMigrated(t, dest)
+
+		ungrouped, err := dest.List("claims", "")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"foo", "claim4"}, ungrouped)
+		data, err := dest.Read("claims", "foo")
+		require.NoError(t, err)
+		assert.Equal(t, "legacy foo claim", string(data))
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		src := newFieldsStore()
+		require.NoError(t, src.SaveWithFields("installations", "", "foo", nil, Fields{"status": "succeeded"}))
+		require.NoError(t, src.Save("installations", "", "bar", nil))
+		dest := newFieldsStore()
+
+		report, err := Migrate(NewBackingStore(src), NewBackingStore(dest), opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 2, report.Count(MigrationCopied))
+		assert.Equal(t, Fields{"status": "succeeded"}, dest.fields["installations/foo"], "the fields should be copied")
+		assert.Empty(t, dest.fields["installations/bar"])
+
+		// An item that was copied without its fields is copied again
+		delete(dest.fields, "installations/foo")
+		report, err = Migrate(NewBackingStore(src), NewBackingStore(dest), opts)
+		require.NoError(t, err, "Migrate failed")
+		assert.Equal(t, 1, report.Count(MigrationCopied))
+		assert.Equal(t, Fields{"status": "succeeded"}, dest.fields["installations/foo"])
+	})
+
+	t.Run("groups are listed once", func(t *testing.T) {
+		src := generateMigrationData(t)
+		dest := countingStore{MockStore: NewMockStore(), lists: map[string]int{}}
+
+		_, err := Migrate(src, dest, opts)
+		require.NoError(t, err, "Migrate failed")
+		for group, count := range dest.lists {
+			assert.LessOrEqual(t, count, 2, "group %s should be listed once to skip items, and once to verify them", group)
+		}
+	})
+
+	t.Run("source cannot list groups", func(t *testing.T) {
+		src := NewBackingStore(struct{ Store }{NewMockStore()})
+		_, err := Migrate(src, NewMockStore(), opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not support listing groups")
+	})
+}
+
+// fieldsStore is a MockStore that saves the fields of its items.
+type fieldsStore struct {
+	MockStore
+	fields map[string]Fields
+}
+
+func newFieldsStore() fieldsStore {
+	return fieldsStore{MockStore: NewMockStore(), fields: map[string]Fields{}}
+}
+
+func (s fieldsStore) SaveWithFields(itemType string, group string, name string, data []byte, fields Fields) error {
+	s.fields[itemType+"/"+name] = fields
+	return s.MockStore.Save(itemType, group, name, data)
+}
+
+func (s fieldsStore) ReadFields(itemType string, name string) (Fields, error) {
+	return s.fields[itemType+"/"+name], nil
+}
+
+func (s fieldsStore) Query(query Query) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+// countingStore is a MockStore that counts how many times each group is listed.
+type countingStore struct {
+	MockStore
+	lists map[string]int
+}
+
+func (s countingStore) List(itemType string, group string) ([]string, error) {
+	s.lists[itemType+"/"+group]++
+	return s.MockStore.List(itemType, group)
+}
@@ -3,12 +3,17 @@ package crud
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 )
 
 // The main point of these tests is to catch any case where the interface
 // changes. But we also provide a mock for testing.
 var _ Store = MockStore{}
+var _ HasListGroups = MockStore{}
+var _ HasLock = MockStore{}
 
 type item struct {
 	itemType, group, name string
@@ -39,6 +44,9 @@ type MockStore struct {
 	// itemType -> group -> list of keys
 	groups map[string]*itemGroup
 
+	// locks stores the leases on the mocked data
+	locks *mockLocks
+
 	// DeleteMock replaces the default Delete implementation with the specified function.
 	// This allows for simulating failures.
 	DeleteMock func(itemType string, name string) error
@@ -60,9 +68,17 @@ func NewMockStore() MockStore {
 	return MockStore{
 		groups: map[string]*itemGroup{},
 		data:   map[string]*item{},
+		locks:  &mockLocks{leases: map[string]Lease{}},
 	}
 }
 
+// mockLocks are the leases held on items in a MockStore, which may be used
+// concurrently.
+type mockLocks struct {
+	mu     sync.Mutex
+	leases map[string]Lease
+}
+
 func (s MockStore) Connect() error {
 	// Keep track of Connect calls for test asserts later
 	count, err := s.GetConnectCount()
@@ -106,6 +122,45 @@ func (s MockStore) List(itemType string, group string) ([]string, error) {
 	return nil, nil
 }
 
+func (s MockStore) ListGroups(itemType string) ([]string, error) {
+	groups := make([]string, 0)
+	for _, g := range s.groups {
+		if g.itemType == itemType && g.group != "" {
+			groups = append(groups, g.group)
+		}
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+func (s MockStore) Lock(itemType string, name string, owner string, ttl time.Duration) error {
+	lease, _, err := newLease(owner, ttl)
+	if err != nil {
+		return err
+	}
+
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+
+	key := s.key(itemType, name)
+	if current, ok := s.locks.leases[key]; ok && current.Owner != owner && !current.IsExpired() {
+		return lockedError(itemType, name, current)
+	}
+	s.locks.leases[key] = lease
+	return nil
+}
+
+func (s MockStore) Unlock(itemType string, name string, owner string) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+
+	key := s.key(itemType, name)
+	if current, ok := s.locks.leases[key]; ok && current.Owner == owner {
+		delete(s.locks.leases, key)
+	}
+	return nil
+}
+
 func (s MockStore) Save(itemType string, group string, name string, data []byte) error {
 	if s.SaveMock != nil {
 		return s.SaveMock(itemType, name, data)
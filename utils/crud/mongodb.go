@@ -3,15 +3,25 @@ package crud
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 )
 
 // MongoCollectionPrefix is applied to every collection.
 const MongoCollectionPrefix = "cnab_"
 
 var _ Store = &mongoDBStore{}
+var _ HasListGroups = &mongoDBStore{}
+var _ HasLock = &mongoDBStore{}
+var _ HasQuery = &mongoDBStore{}
+
+// mongoLocksCollection is the collection, without the prefix, that contains
+// the leases on items.
+const mongoLocksCollection = "_locks"
 
 type mongoDBStore struct {
 	url         string
@@ -21,9 +31,10 @@ type mongoDBStore struct {
 }
 
 type doc struct {
-	Name  string `json:"name"`
-	Group string `json:"group"`
-	Data  []byte `json:"data"`
+	Name   string `json:"name"`
+	Group  string `json:"group"`
+	Data   []byte `json:"data"`
+	Fields Fields `json:"fields"`
 }
 
 // NewMongoDBStore creates a new storage engine that uses MongoDB
@@ -89,10 +100,77 @@ func (s *mongoDBStore) List(itemType string, group string) ([]string, error) {
 	return buf, nil
 }
 
+func (s *mongoDBStore) ListGroups(itemType string) ([]string, error) {
+	collection := s.getCollection(itemType)
+
+	var res []string
+	if err := collection.Find(nil).Distinct("group", &res); err != nil {
+		return []string{}, wrapErr(err)
+	}
+	groups := make([]string, 0, len(res))
+	for _, group := range res {
+		if group != "" {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
 func (s *mongoDBStore) Save(itemType string, group string, name string, data []byte) error {
 	collection := s.getCollection(itemType)
 
-	return wrapErr(collection.Insert(doc{Name: name, Group: group, Data: data}))
+	// Replace the existing document, as the other stores do. The collection
+	// does not have a unique index on the name, so inserting would add a
+	// second document, and Read could return either one. The fields of the
+	// item are kept.
+	update := bson.M{"$set": bson.M{"name": name, "group": group, "data": data}}
+	_, err := collection.Upsert(map[string]string{"name": name}, update)
+	return wrapErr(err)
+}
+
+func (s *mongoDBStore) SaveWithFields(itemType string, group string, name string, data []byte, fields Fields) error {
+	collection := s.getCollection(itemType)
+
+	_, err := collection.Upsert(map[string]string{"name": name}, doc{Name: name, Group: group, Data: data, Fields: fields})
+	return wrapErr(err)
+}
+
+func (s *mongoDBStore) ReadFields(itemType string, name string) (Fields, error) {
+	collection := s.getCollection(itemType)
+
+	res := doc{}
+	if err := collection.Find(map[string]string{"name": name}).Select(bson.M{"fields": 1}).One(&res); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, ErrRecordDoesNotExist
+		}
+		return nil, wrapErr(err)
+	}
+	return res.Fields, nil
+}
+
+// Query finds the items that match the query, sorted and paginated by the
+// database.
+func (s *mongoDBStore) Query(query Query) ([]string, error) {
+	selector, sortBy, err := mongoQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getCollection(query.ItemType).Find(selector).Select(bson.M{"name": 1}).Sort(sortBy...).Skip(query.Skip)
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+
+	var res []doc
+	if err := q.All(&res); err != nil {
+		return nil, wrapErr(err)
+	}
+	names := make([]string, 0, len(res))
+	for _, v := range res {
+		names = append(names, v.Name)
+	}
+	return names, nil
 }
 
 func (s *mongoDBStore) Read(itemType string, name string) ([]byte, error) {
@@ -114,6 +192,89 @@ func (s *mongoDBStore) Delete(itemType string, name string) error {
 	return wrapErr(collection.Remove(map[string]string{"name": name}))
 }
 
+// lockDoc is a lease on an item, identified by ITEMTYPE/NAME.
+type lockDoc struct {
+	ID    string `bson:"_id"`
+	Lease `bson:",inline"`
+}
+
+// Lock acquires a lease on the record. The lease is replaced only when it is
+// held by the owner or has expired, otherwise inserting the lease fails
+// because its id already exists.
+func (s *mongoDBStore) Lock(itemType string, name string, owner string, ttl time.Duration) error {
+	lease, _, err := newLease(owner, ttl)
+	if err != nil {
+		return err
+	}
+
+	collection := s.getCollection(mongoLocksCollection)
+	id := lockID(itemType, name)
+	selector := bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"expires": bson.M{"$lte": time.Now()}},
+		},
+	}
+	_, err = collection.Upsert(selector, lockDoc{ID: id, Lease: lease})
+	if mgo.IsDup(err) {
+		var current lockDoc
+		if err := collection.FindId(id).One(&current); err != nil {
+			return wrapErr(err)
+		}
+		return lockedError(itemType, name, current.Lease)
+	}
+	return wrapErr(err)
+}
+
+func (s *mongoDBStore) Unlock(itemType string, name string, owner string) error {
+	collection := s.getCollection(mongoLocksCollection)
+
+	err := collection.Remove(bson.M{"_id": lockID(itemType, name), "owner": owner})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return wrapErr(err)
+}
+
+// mongoQuery translates a query into a selector of the documents and the
+// fields that they are sorted by.
+func mongoQuery(query Query) (bson.M, []string, error) {
+	if err := query.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	selector := bson.M{}
+	if query.Group != "" {
+		selector["group"] = query.Group
+	}
+	for _, f := range query.Filters {
+		key := "fields." + f.Field
+		conditions, ok := selector[key].(bson.M)
+		if !ok {
+			conditions = bson.M{}
+			selector[key] = conditions
+		}
+		// The operators are named after the query operators of MongoDB
+		conditions["$"+string(f.Operator)] = f.Value
+	}
+
+	sortBy := []string{"name"}
+	if query.SortBy != "" {
+		sortBy = []string{"fields." + query.SortBy, "name"}
+	}
+	if query.Descending {
+		for i := range sortBy {
+			sortBy[i] = "-" + sortBy[i]
+		}
+	}
+	return selector, sortBy, nil
+}
+
+func lockID(itemType string, name string) string {
+	return itemType + "/" + name
+}
+
 func wrapErr(err error) error {
 	if err == nil {
 		return err
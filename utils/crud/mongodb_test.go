@@ -3,7 +3,9 @@ package crud
 import (
 	"testing"
 
+	"github.com/globalsign/mgo/bson"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseDBName(t *testing.T) {
@@ -20,3 +22,35 @@ func TestParseDBName(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "", name)
 }
+
+func TestMongoQuery(t *testing.T) {
+	selector, sortBy, err := mongoQuery(Query{
+		ItemType: "installations",
+		Group:    "prod",
+		Filters: []Filter{
+			{Field: "status", Operator: FilterEqual, Value: "failed"},
+			{Field: "created", Operator: FilterGreaterOrEqual, Value: "2020-05-01"},
+			{Field: "created", Operator: FilterLess, Value: "2020-06-01"},
+		},
+		SortBy:     "created",
+		Descending: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"group":         "prod",
+		"fields.status": bson.M{"$eq": "failed"},
+		"fields.created": bson.M{
+			"$gte": "2020-05-01",
+			"$lt":  "2020-06-01",
+		},
+	}, selector)
+	assert.Equal(t, []string{"-fields.created", "-name"}, sortBy)
+
+	selector, sortBy, err = mongoQuery(Query{ItemType: "installations"})
+	require.NoError(t, err)
+	assert.Empty(t, selector)
+	assert.Equal(t, []string{"name"}, sortBy)
+
+	_, _, err = mongoQuery(Query{ItemType: "installations", Filters: []Filter{{Field: "status", Operator: "ne"}}})
+	assert.EqualError(t, err, `invalid query, unsupported operator "ne" on field status`)
+}
@@ -0,0 +1,90 @@
+package crud
+
+import (
+	"fmt"
+)
+
+// Fields are saved next to the data of an item, so that the item can be
+// selected by them with a Query. Fields are not encrypted, unlike the data of
+// some items, so they must not contain sensitive values.
+type Fields map[string]string
+
+// FilterOperator compares the value of a field with the value of a Filter.
+type FilterOperator string
+
+const (
+	// FilterEqual matches items with a field that is equal to the value.
+	FilterEqual FilterOperator = "eq"
+
+	// FilterGreaterOrEqual matches items with a field that is greater than or
+	// equal to the value.
+	FilterGreaterOrEqual FilterOperator = "gte"
+
+	// FilterLess matches items with a field that is less than the value.
+	FilterLess FilterOperator = "lt"
+)
+
+// Filter selects items by one of their fields. Values are compared as strings.
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Value    string
+}
+
+// Query selects items of an item type by their group and fields.
+type Query struct {
+	ItemType string
+
+	// Group only matches the items in this group, when set.
+	Group string
+
+	// Filters that an item must match, all of them.
+	Filters []Filter
+
+	// SortBy is the field used to sort the items. Items are sorted by name
+	// when it is not set, or when their fields are equal.
+	SortBy string
+
+	// Descending sorts the items in descending order.
+	Descending bool
+
+	// Skip is the number of matching items to skip.
+	Skip int
+
+	// Limit is the maximum number of items to return. All matching items are
+	// returned when the limit is not set.
+	Limit int
+}
+
+// Validate the query.
+func (q Query) Validate() error {
+	if q.ItemType == "" {
+		return fmt.Errorf("invalid query, the item type is required")
+	}
+	for _, f := range q.Filters {
+		switch f.Operator {
+		case FilterEqual, FilterGreaterOrEqual, FilterLess:
+		default:
+			return fmt.Errorf("invalid query, unsupported operator %q on field %s", f.Operator, f.Field)
+		}
+	}
+	if q.Skip < 0 || q.Limit < 0 {
+		return fmt.Errorf("invalid query, skip %d and limit %d cannot be negative", q.Skip, q.Limit)
+	}
+	return nil
+}
+
+// HasQuery indicates that a store can save fields with its items, and select
+// items by their fields itself, for example by translating a Query into a
+// database query.
+type HasQuery interface {
+	// SaveWithFields saves the item, and replaces its fields.
+	SaveWithFields(itemType string, group string, name string, data []byte, fields Fields) error
+
+	// ReadFields returns the fields of the item, which are empty when the item
+	// was saved without fields.
+	ReadFields(itemType string, name string) (Fields, error)
+
+	// Query returns the names of the items that match the query.
+	Query(query Query) ([]string, error)
+}
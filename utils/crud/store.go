@@ -3,6 +3,9 @@ package crud
 // Store is a simplified interface to a key-blob store supporting CRUD operations.
 type Store interface {
 	List(itemType string, group string) ([]string, error)
+
+	// Save creates the item, or replaces its data when it was already saved,
+	// so that saving an item again does not create a duplicate.
 	Save(itemType string, group string, name string, data []byte) error
 	Read(itemType string, name string) ([]byte, error)
 	Delete(itemType string, name string) error
@@ -19,3 +22,11 @@ type HasConnect interface {
 type HasClose interface {
 	Close() error
 }
+
+// HasListGroups indicates that a store can list the groups of an item type,
+// for example so that all of its data can be migrated to another store.
+type HasListGroups interface {
+	// ListGroups returns the names of the groups of an item type, sorted in
+	// ascending order. Items saved without a group are not included.
+	ListGroups(itemType string) ([]string, error)
+}
@@ -0,0 +1,35 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SaveReplaces(t *testing.T) {
+	fs, cleanupFS := newTestFileSystemStore(t)
+	defer cleanupFS()
+	bolt, cleanupBolt := newTestBoltStore(t)
+	defer cleanupBolt()
+
+	stores := map[string]Store{
+		"mock":       NewBackingStore(NewMockStore()),
+		"filesystem": fs,
+		"bolt":       bolt,
+	}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, s.Save("claims", "foo", "claim1", []byte("first")))
+			require.NoError(t, s.Save("claims", "foo", "claim1", []byte("second")), "saving an item again should not fail")
+
+			data, err := s.Read("claims", "claim1")
+			require.NoError(t, err)
+			assert.Equal(t, "second", string(data))
+
+			names, err := s.List("claims", "foo")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"claim1"}, names, "saving an item again should not create a duplicate")
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package yamlconv converts values unmarshaled from YAML into values that can
+// be marshaled to JSON.
+package yamlconv
+
+import "fmt"
+
+// JSONValue converts a value unmarshaled from YAML into a value that can be
+// marshaled to JSON, by converting the maps, which YAML unmarshals with
+// interface{} keys, to maps with string keys.
+func JSONValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			k, ok := key.(string)
+			if !ok {
+				k = fmt.Sprint(key)
+			}
+			m[k] = JSONValue(value)
+		}
+		return m
+	case map[string]interface{}:
+		for key, value := range t {
+			t[key] = JSONValue(value)
+		}
+		return t
+	case []interface{}:
+		for i, value := range t {
+			t[i] = JSONValue(value)
+		}
+		return t
+	default:
+		return v
+	}
+}
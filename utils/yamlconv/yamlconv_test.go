@@ -0,0 +1,39 @@
+package yamlconv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestJSONValue(t *testing.T) {
+	var doc interface{}
+	err := yaml.Unmarshal([]byte(`
+name: web
+ports: [80, 443]
+labels:
+  app: web
+1: one
+servers:
+- host: a
+  weight: 0.5
+`), &doc)
+	require.NoError(t, err)
+
+	value := JSONValue(doc)
+	assert.Equal(t, map[string]interface{}{
+		"name":   "web",
+		"ports":  []interface{}{80, 443},
+		"labels": map[string]interface{}{"app": "web"},
+		"1":      "one",
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "weight": 0.5},
+		},
+	}, value)
+
+	_, err = json.Marshal(value)
+	assert.NoError(t, err)
+}